@@ -63,7 +63,7 @@ func (src *WFS) ToV3(dst *pdoknlv3.WFS) error {
 		dst.Spec.PodSpecPatch = ConvertResources(*src.Spec.Kubernetes.Resources)
 	}
 
-	dst.Spec.Options = &ConvertOptionsV2ToV3(src.Spec.Options).BaseOptions
+	dst.Spec.Options = &pdoknlv3.WFSOptions{BaseOptions: ConvertOptionsV2ToV3(src.Spec.Options).BaseOptions}
 
 	if src.Spec.Kubernetes.HealthCheck != nil {
 		dst.Spec.HealthCheck = &pdoknlv3.HealthCheckWFS{
@@ -202,6 +202,7 @@ func convertV2FeatureTypeToV3(src FeatureType) pdoknlv3.FeatureType {
 //nolint:revive
 func (dst *WFS) ConvertFrom(srcRaw conversion.Hub) error {
 	src := srcRaw.(*pdoknlv3.WFS)
+
 	log.Printf("ConvertFrom: Converting WFS from Hub version v3 to Spoke version v2beta1;"+
 		"source: %s/%s, target: %s/%s", src.Namespace, src.Name, dst.Namespace, dst.Name)
 
@@ -211,7 +212,7 @@ func (dst *WFS) ConvertFrom(srcRaw conversion.Hub) error {
 
 	dst.Spec.Kubernetes = NewV2KubernetesObject(src.Spec.Lifecycle, src.Spec.PodSpecPatch, src.Spec.HorizontalPodAutoscalerPatch)
 
-	dst.Spec.Options = ConvertOptionsV3ToV2(&pdoknlv3.Options{BaseOptions: *src.Spec.Options})
+	dst.Spec.Options = ConvertOptionsV3ToV2(&pdoknlv3.Options{BaseOptions: src.Spec.Options.BaseOptions})
 
 	if src.Spec.HealthCheck != nil {
 		dst.Spec.Kubernetes.HealthCheck = &HealthCheck{
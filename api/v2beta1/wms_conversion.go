@@ -193,6 +193,7 @@ func convertHealthCheckToV3(v2 *HealthCheck) *pdoknlv3.HealthCheckWMS {
 //nolint:revive
 func (dst *WMS) ConvertFrom(srcRaw conversion.Hub) error {
 	src := srcRaw.(*pdoknlv3.WMS)
+
 	log.Printf("ConvertFrom: Converting WMS from Hub version v3 to Spoke version v2beta1;"+
 		"source: %s/%s, target: %s/%s", src.Namespace, src.Name, dst.Namespace, dst.Name)
 
@@ -52,4 +52,9 @@ var (
 		Kind:       "WMS",
 		APIVersion: GroupVersion.String(),
 	}
+
+	TypeMetaWCS = metav1.TypeMeta{
+		Kind:       "WCS",
+		APIVersion: GroupVersion.String(),
+	}
 )
@@ -9,6 +9,7 @@ import (
 
 	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -19,6 +20,7 @@ type ServiceType string
 const (
 	ServiceTypeWMS ServiceType = "WMS"
 	ServiceTypeWFS ServiceType = "WFS"
+	ServiceTypeWCS ServiceType = "WCS"
 )
 
 // HorizontalPodAutoscalerPatch - copy of autoscalingv2.HorizontalPodAutoscalerSpec without ScaleTargetRef
@@ -30,10 +32,10 @@ type HorizontalPodAutoscalerPatch struct {
 	Behavior    *autoscalingv2.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
 }
 
-// WMSWFS is the common interface used for both WMS and WFS resources.
+// WMSWFS is the common interface used for the WMS, WFS and WCS resources.
 // +kubebuilder:object:generate=false
 type WMSWFS interface {
-	*WFS | *WMS
+	*WFS | *WMS | *WCS
 	metav1.Object
 
 	GroupKind() schema.GroupKind
@@ -51,12 +53,15 @@ type WMSWFS interface {
 	URL() smoothoperatormodel.URL
 	IngressRouteURLs(includeServiceURLWhenEmpty bool) smoothoperatormodel.IngressRouteURLs
 
-	// DatasetMetadataIds returns list of all configured metadata identifiers configured on Layers or Featuretypes
+	// DatasetMetadataIds returns list of all configured metadata identifiers configured on Layers, Featuretypes or Coverages
 	DatasetMetadataIDs() []string
 
 	GeoPackages() []*Gpkg
 
 	ReadinessQueryString() (string, string, error)
+
+	// ServiceStatus returns the status including the extents read from the data
+	ServiceStatus() *Status
 }
 
 // Mapfile references a ConfigMap key where an external mapfile is stored.
@@ -83,6 +88,77 @@ type BaseOptions struct {
 	// +kubebuilder:default:=true
 	// +kubebuilder:validation:Optional
 	PrefetchData bool `json:"prefetchData"`
+
+	// Whether to read the prefetched geopackages and TIFFs from the node-local data cache instead of
+	// downloading them into every pod. Requires prefetchData and a data cache enabled on the operator.
+	// +kubebuilder:default:=false
+	// +kubebuilder:validation:Optional
+	UseDataCache bool `json:"useDataCache"`
+
+	// Whether a data-refresh sidecar swaps changed geopackages without restarting the pods.
+	// The mapfile then points to a symlink per layer, a new blobKey only updates the data manifest.
+	// Requires prefetchData, and enough ephemeral storage to hold the old and new geopackage during the swap.
+	// +kubebuilder:default:=false
+	// +kubebuilder:validation:Optional
+	DataRefresh bool `json:"dataRefresh"`
+
+	// Whether an auto-extent init container reads the extent of every layer, featureType or coverage without a bbox
+	// from its geopackage, TIFF or PostGIS table. The extents are reported in the status and are used in the
+	// mapfile and capabilities after the next rollout, a bbox in the spec always takes precedence.
+	// +kubebuilder:default:=false
+	// +kubebuilder:validation:Optional
+	AutoExtent bool `json:"autoExtent"`
+
+	// Whether the ephemeral storage of the mapserver container is sized from the prefetched geopackages and TIFFs,
+	// plus the headroom configured on the operator. The size replaces the ephemeral-storage of the podSpecPatch and
+	// is reported in the status, a size above 10Gi gets an ephemeral volume. Until the size of every prefetched
+	// blob is known the podSpecPatch applies.
+	// +kubebuilder:default:=false
+	// +kubebuilder:validation:Optional
+	AutoEphemeralStorage bool `json:"autoEphemeralStorage"`
+
+	// TopologySpread configures how the pods are spread over zones and nodes.
+	// If omitted the pods are spread with a maxSkew of 1.
+	// +kubebuilder:validation:Optional
+	TopologySpread *TopologySpread `json:"topologySpread,omitempty"`
+}
+
+// TopologySpread configures the default topologySpreadConstraints over zones and hostnames.
+type TopologySpread struct {
+	// Whether to add the topologySpreadConstraints to the pods.
+	// +kubebuilder:default:=true
+	// +kubebuilder:validation:Optional
+	Enabled bool `json:"enabled"`
+
+	// The maximum difference in number of pods between two zones or nodes.
+	// +kubebuilder:default:=1
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Optional
+	MaxSkew int32 `json:"maxSkew"`
+
+	// How to deal with pods that can't be scheduled while satisfying the spread over zones.
+	// Defaults to ScheduleAnyway, so pods are still scheduled on nodes without a topology.kubernetes.io/zone label.
+	// Use DoNotSchedule for a hard spread over zones.
+	// +kubebuilder:default:=ScheduleAnyway
+	// +kubebuilder:validation:Enum=DoNotSchedule;ScheduleAnyway
+	// +kubebuilder:validation:Optional
+	ZoneWhenUnsatisfiable corev1.UnsatisfiableConstraintAction `json:"zoneWhenUnsatisfiable"`
+
+	// How to deal with pods that can't be scheduled while satisfying the spread over nodes.
+	// Defaults to ScheduleAnyway, so a rollout isn't blocked when there are fewer nodes than pods in a zone.
+	// +kubebuilder:default:=ScheduleAnyway
+	// +kubebuilder:validation:Enum=DoNotSchedule;ScheduleAnyway
+	// +kubebuilder:validation:Optional
+	HostnameWhenUnsatisfiable corev1.UnsatisfiableConstraintAction `json:"hostnameWhenUnsatisfiable"`
+}
+
+func GetDefaultTopologySpread() *TopologySpread {
+	return &TopologySpread{
+		Enabled:                   true,
+		MaxSkew:                   1,
+		ZoneWhenUnsatisfiable:     corev1.ScheduleAnyway,
+		HostnameWhenUnsatisfiable: corev1.ScheduleAnyway,
+	}
 }
 
 // Options configures optional behaviors of the operator, like ingress, casing, and data prefetching.
@@ -108,6 +184,61 @@ func GetDefaultOptions() *Options {
 	}
 }
 
+// Status of the WMS, WFS and WCS resources
+type Status struct {
+	smoothoperatormodel.OperatorStatus `json:",inline"`
+
+	// Extents read from the data when autoExtent is enabled, these can be copied into the bbox of the spec
+	Extents []DataExtent `json:"extents,omitempty"`
+
+	// Blobs with their size, read when a geopackage or TIFF has prefetch auto or autoEphemeralStorage is enabled
+	Blobs []BlobSize `json:"blobs,omitempty"`
+
+	// EphemeralStorage of the mapserver container computed from the blob sizes when autoEphemeralStorage is enabled
+	EphemeralStorage *resource.Quantity `json:"ephemeralStorage,omitempty"`
+}
+
+// BlobSize is the size of a blob on blob storage
+type BlobSize struct {
+	BlobKey string `json:"blobKey"`
+
+	// Size in bytes
+	Size int64 `json:"size"`
+}
+
+// DataExtent is the extent of the data of a layer, featureType or coverage
+type DataExtent struct {
+	// Name of the layer, featureType or coverage
+	Name string `json:"name"`
+
+	// CRS of the bbox, the dataEPSG of a WMS or the defaultCrs of the featureType or coverage
+	CRS string `json:"crs"`
+
+	// BBox of the data in easting/northing order
+	// +kubebuilder:validation:Type=object
+	BBox smoothoperatormodel.BBox `json:"bbox"`
+}
+
+// GetExtent returns the extent of the data of a layer, featureType or coverage
+// GetBlobSize returns the size of the blob in bytes, nil if it's unknown
+func (status *Status) GetBlobSize(blobKey string) *int64 {
+	for i := range status.Blobs {
+		if status.Blobs[i].BlobKey == blobKey {
+			return &status.Blobs[i].Size
+		}
+	}
+	return nil
+}
+
+func (status *Status) GetExtent(name string) *DataExtent {
+	for i := range status.Extents {
+		if status.Extents[i].Name == name {
+			return &status.Extents[i]
+		}
+	}
+	return nil
+}
+
 // BaseService holds all shared Services field for all apis
 type BaseService struct {
 	// Geonovum subdomein
@@ -205,6 +336,7 @@ type Data struct {
 
 // Gpkg configures a Geopackage data source
 // +kubebuilder:validation:Type=object
+// +kubebuilder:validation:XValidation:rule="!has(self.filter) || !has(self.sqlView)", message="filter and sqlView are mutually exclusive"
 type Gpkg struct {
 	// Blobkey identifies the location/bucket of the .gpkg file
 	// +kubebuilder:validation:Pattern:=^.+\/.+\/.+\.gpkg$
@@ -221,10 +353,35 @@ type Gpkg struct {
 	// Columns to visualize for this table
 	// +kubebuilder:validation:MinItems:=1
 	Columns []Column `json:"columns"`
+
+	// Filter restricting the features of the table, a CQL expression on the columns, e.g. "class = 'highway'".
+	// Mutually exclusive with sqlView
+	// +kubebuilder:validation:MinLength:=1
+	Filter *string `json:"filter,omitempty"`
+
+	// SQLView replaces the table by the result of this SELECT query, the columns should be part of the result.
+	// Mutually exclusive with filter
+	// +kubebuilder:validation:MinLength:=1
+	SQLView *string `json:"sqlView,omitempty"`
+
+	// PrimaryKey is the column uniquely identifying a feature, replaces the fuuid column as feature id
+	// +kubebuilder:validation:MinLength:=1
+	PrimaryKey *string `json:"primaryKey,omitempty"`
+
+	// DefaultSort orders the features, gives stable paging. Defaults to the primaryKey if set
+	DefaultSort *DefaultSort `json:"defaultSort,omitempty"`
+
+	// Prefetch overrides the prefetchData option for this geopackage, optional. With "always" the blob is downloaded
+	// before mapserver starts, with "never" it's streamed from blob storage with range requests. With "auto" it's
+	// downloaded when it fits in the ephemeral storage of the mapserver container, its size is read when a pod
+	// starts. Until the size is known it's streamed.
+	// +kubebuilder:validation:Enum=always;never;auto
+	Prefetch *string `json:"prefetch,omitempty"`
 }
 
 // Postgis - reference to table in a Postgres database
 // +kubebuilder:validation:Type=object
+// +kubebuilder:validation:XValidation:rule="!has(self.filter) || !has(self.sqlView)", message="filter and sqlView are mutually exclusive"
 type Postgis struct {
 	// TableName in postGIS
 	// +kubebuilder:validation:MinLength=1
@@ -237,6 +394,23 @@ type Postgis struct {
 	// Columns to expose from table
 	// +kubebuilder:validation:MinItems=1
 	Columns []Column `json:"columns"`
+
+	// Filter restricting the features of the table, a CQL expression on the columns, e.g. "class = 'highway'".
+	// Mutually exclusive with sqlView
+	// +kubebuilder:validation:MinLength=1
+	Filter *string `json:"filter,omitempty"`
+
+	// SQLView replaces the table by the result of this SELECT query, the columns should be part of the result.
+	// Mutually exclusive with filter
+	// +kubebuilder:validation:MinLength=1
+	SQLView *string `json:"sqlView,omitempty"`
+
+	// PrimaryKey is the column uniquely identifying a feature, replaces the fuuid column as feature id
+	// +kubebuilder:validation:MinLength=1
+	PrimaryKey *string `json:"primaryKey,omitempty"`
+
+	// DefaultSort orders the features, gives stable paging. Defaults to the primaryKey if set
+	DefaultSort *DefaultSort `json:"defaultSort,omitempty"`
 }
 
 // TIF configures a GeoTIFF raster data source
@@ -264,8 +438,39 @@ type TIF struct {
 	// "When a band represents nominal or ordinal data the class name (from styling) can be included in the getFeatureInfo"
 	// +kubebuilder:default:=false
 	GetFeatureInfoIncludesClass bool `json:"getFeatureInfoIncludesClass,omitempty"`
+
+	// The bands of the raster that are rendered, e.g. [4, 1, 2] for a false color image, optional. Only used by a WMS
+	// +kubebuilder:validation:MinItems:=1
+	// +kubebuilder:validation:MaxItems:=4
+	// +kubebuilder:validation:items:Minimum:=1
+	Bands []int32 `json:"bands,omitempty"`
+
+	// The value of the pixels that have no data and are transparent, optional. Only used by a WMS
+	// +kubebuilder:validation:Pattern="^-?[0-9]+([.][0-9]+)?$"
+	NoData *string `json:"noData,omitempty"`
+
+	// Scales the values of the raster to 0-255, either AUTO or min,max, optional. Only used by a WMS
+	// +kubebuilder:validation:Pattern="^(AUTO|-?[0-9]+([.][0-9]+)?,-?[0-9]+([.][0-9]+)?)$"
+	Scale *string `json:"scale,omitempty"`
+
+	// Prefetch overrides the prefetchData option for this TIFF, optional. With "always" the blob is downloaded
+	// before mapserver starts, with "never" it's streamed from blob storage with range requests. With "auto" it's
+	// downloaded when it fits in the ephemeral storage of the mapserver container, its size is read when a pod
+	// starts. Until the size is known it's streamed.
+	// A streamed TIFF must be a cloud optimized GeoTIFF, this is validated when the pod starts.
+	// +kubebuilder:validation:Enum=always;never;auto
+	Prefetch *string `json:"prefetch,omitempty"`
 }
 
+const (
+	PrefetchAlways = "always"
+	PrefetchNever  = "never"
+	PrefetchAuto   = "auto"
+)
+
+// GeometryColumnType is the Column.Type of the column holding the geometry
+const GeometryColumnType = "geometry"
+
 // Column maps a source column name to an optional alias for output.
 // +kubebuilder:validation:Type=object
 type Column struct {
@@ -276,6 +481,26 @@ type Column struct {
 	// Alias for the column in the service output.
 	// +kubebuilder:validation:MinLength=1
 	Alias *string `json:"alias,omitempty"`
+
+	// Type of the column, used in the DescribeFeatureType schema. A geometry column replaces the default geom column
+	// +kubebuilder:validation:Enum=string;int;double;date;geometry
+	Type *string `json:"type,omitempty"`
+
+	// Nullable marks the column as optional in the DescribeFeatureType schema
+	Nullable *bool `json:"nullable,omitempty"`
+}
+
+// DefaultSort orders the features of a table
+// +kubebuilder:validation:Type=object
+type DefaultSort struct {
+	// Column to sort on
+	// +kubebuilder:validation:MinLength=1
+	Column string `json:"column"`
+
+	// Order of the sort, ascending or descending
+	// +kubebuilder:validation:Enum=ASC;DESC
+	// +kubebuilder:default=ASC
+	Order string `json:"order,omitempty"`
 }
 
 func SetHost(url string) {
@@ -323,6 +548,50 @@ func (d *BaseData) GetGeometryType() *string {
 	}
 }
 
+func (d *BaseData) GetPrimaryKey() *string {
+	switch {
+	case d.Gpkg != nil:
+		return d.Gpkg.PrimaryKey
+	case d.Postgis != nil:
+		return d.Postgis.PrimaryKey
+	default:
+		return nil
+	}
+}
+
+func (d *BaseData) GetDefaultSort() *DefaultSort {
+	switch {
+	case d.Gpkg != nil:
+		return d.Gpkg.DefaultSort
+	case d.Postgis != nil:
+		return d.Postgis.DefaultSort
+	default:
+		return nil
+	}
+}
+
+func (d *BaseData) GetFilter() *string {
+	switch {
+	case d.Gpkg != nil:
+		return d.Gpkg.Filter
+	case d.Postgis != nil:
+		return d.Postgis.Filter
+	default:
+		return nil
+	}
+}
+
+func (d *BaseData) GetSQLView() *string {
+	switch {
+	case d.Gpkg != nil:
+		return d.Gpkg.SQLView
+	case d.Postgis != nil:
+		return d.Postgis.SQLView
+	default:
+		return nil
+	}
+}
+
 func (o Options) UseWebserviceProxy() bool {
 	// options.DisableWebserviceProxy not set or false
 	return !o.DisableWebserviceProxy
@@ -3,8 +3,11 @@ package v3
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"slices"
+	"strings"
 
+	"github.com/pdok/mapserver-operator/internal/crs"
 	smoothoperatorv1 "github.com/pdok/smooth-operator/api/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -141,6 +144,150 @@ func ValidateEphemeralStorage(podSpecPatch v1.PodSpec, allErrs *field.ErrorList)
 	}
 }
 
+var (
+	filterStringLiteralRegex = regexp.MustCompile(`'(?:[^']|'')*'`)
+	filterIdentifierRegex    = regexp.MustCompile(`"([^"]+)"|\b([A-Za-z_]\w*)\b\s*(\()?`)
+	filterKeywords           = []string{"AND", "OR", "NOT", "LIKE", "ILIKE", "IN", "IS", "NULL", "BETWEEN", "TRUE", "FALSE"}
+	sqlViewRegex             = regexp.MustCompile(`(?is)^\s*SELECT\s`)
+	sqlQuotedRegex           = regexp.MustCompile(`'(?:[^']|'')*'|"(?:[^"]|"")*"`)
+)
+
+// defaultIDColumn identifies the features when no primaryKey is configured
+const defaultIDColumn = "fuuid"
+
+// ValidateBaseData checks that a filter only references the configured columns and that a sqlView is a single SELECT query
+func ValidateBaseData(data BaseData, path *field.Path, allErrs *field.ErrorList) {
+	var dataPath *field.Path
+	var columns []Column
+	switch {
+	case data.Gpkg != nil:
+		dataPath, columns = path.Child("gpkg"), data.Gpkg.Columns
+	case data.Postgis != nil:
+		dataPath, columns = path.Child("postgis"), data.Postgis.Columns
+	default:
+		return
+	}
+
+	if filter := data.GetFilter(); filter != nil {
+		columnNames := []string{geometryValueReference}
+		for _, column := range columns {
+			columnNames = append(columnNames, column.Name)
+		}
+
+		unquoted := filterStringLiteralRegex.ReplaceAllString(*filter, "''")
+		if strings.Contains(unquoted, ";") {
+			*allErrs = append(*allErrs, field.Invalid(
+				dataPath.Child("filter"),
+				*filter,
+				"must be a single expression without ;",
+			))
+		}
+		for _, match := range filterIdentifierRegex.FindAllStringSubmatch(unquoted, -1) {
+			column := match[1]
+			if column == "" {
+				// Skip functions and operators
+				if match[3] != "" || slices.Contains(filterKeywords, strings.ToUpper(match[2])) {
+					continue
+				}
+				column = match[2]
+			}
+			if !slices.Contains(columnNames, column) {
+				*allErrs = append(*allErrs, field.Invalid(
+					dataPath.Child("filter"),
+					column,
+					"must only reference columns",
+				))
+			}
+		}
+	}
+
+	columnNames := []string{}
+	geometryColumns := 0
+	for _, column := range columns {
+		if column.Type != nil && *column.Type == GeometryColumnType {
+			geometryColumns++
+			if geometryColumns > 1 {
+				*allErrs = append(*allErrs, field.Invalid(
+					dataPath.Child("columns"),
+					column.Name,
+					"only one column can be of type geometry",
+				))
+			}
+			continue
+		}
+		columnNames = append(columnNames, column.Name)
+	}
+
+	if primaryKey := data.GetPrimaryKey(); primaryKey != nil && !slices.Contains(columnNames, *primaryKey) {
+		*allErrs = append(*allErrs, field.Invalid(
+			dataPath.Child("primaryKey"),
+			*primaryKey,
+			"must be one of the (non geometry) columns",
+		))
+	}
+
+	if defaultSort := data.GetDefaultSort(); defaultSort != nil {
+		sortColumns := columnNames
+		if data.GetPrimaryKey() == nil {
+			sortColumns = append(sortColumns, defaultIDColumn)
+		}
+		if !slices.Contains(sortColumns, defaultSort.Column) {
+			*allErrs = append(*allErrs, field.Invalid(
+				dataPath.Child("defaultSort").Child("column"),
+				defaultSort.Column,
+				"must be one of the (non geometry) columns",
+			))
+		}
+	}
+
+	if sqlView := data.GetSQLView(); sqlView != nil {
+		if !sqlViewRegex.MatchString(*sqlView) {
+			*allErrs = append(*allErrs, field.Invalid(
+				dataPath.Child("sqlView"),
+				*sqlView,
+				"must be a SELECT query",
+			))
+		} else if strings.Contains(sqlQuotedRegex.ReplaceAllString(*sqlView, ""), ";") {
+			// A ; outside string literals and quoted identifiers would chain another statement
+			*allErrs = append(*allErrs, field.Invalid(
+				dataPath.Child("sqlView"),
+				*sqlView,
+				"must be a single SELECT query without ;",
+			))
+		}
+	}
+}
+
+// ValidateCRS checks that the CRS is part of the CRS registry of the operator
+func ValidateCRS(code string, path *field.Path, allErrs *field.ErrorList) {
+	if !crs.IsSupported(code) {
+		*allErrs = append(*allErrs, field.NotSupported(path, code, crs.Codes()))
+	}
+}
+
+func ValidateOptions(options Options, allErrs *field.ErrorList) {
+	if options.UseDataCache && !options.PrefetchData {
+		*allErrs = append(*allErrs, field.Invalid(
+			field.NewPath("spec").Child("options").Child("useDataCache"),
+			options.UseDataCache,
+			"requires prefetchData",
+		))
+	}
+	if options.DataRefresh && !options.PrefetchData {
+		*allErrs = append(*allErrs, field.Invalid(
+			field.NewPath("spec").Child("options").Child("dataRefresh"),
+			options.DataRefresh,
+			"requires prefetchData",
+		))
+	}
+	if options.DataRefresh && options.UseDataCache {
+		*allErrs = append(*allErrs, field.Forbidden(
+			field.NewPath("spec").Child("options").Child("dataRefresh"),
+			"cannot be combined with useDataCache",
+		))
+	}
+}
+
 func ValidateInspire[O WMSWFS](obj O, allErrs *field.ErrorList, allWarnings *[]string) {
 	if obj.Inspire() == nil {
 		return
@@ -183,6 +330,14 @@ func ValidateInspire[O WMSWFS](obj O, allErrs *field.ErrorList, allWarnings *[]s
 		))
 	}
 
+	if obj.Type() == ServiceTypeWCS && len(datasetIDs) > 1 {
+		*allErrs = append(*allErrs, field.Invalid(
+			field.NewPath("spec").Child("service").Child("coverages[*]").Child("datasetMetadataUrl").Child("csw").Child("metadataIdentifier"),
+			datasetIDs,
+			"when Inspire, all coverages need use the same datasetMetadataUrl.csw.metadataIdentifier",
+		))
+	}
+
 }
 
 func ValidateOwnerInfo[O WMSWFS](c client.Client, obj O, allErrs *field.ErrorList) {
@@ -221,6 +376,11 @@ func ValidateOwnerInfo[O WMSWFS](c client.Client, obj O, allErrs *field.ErrorLis
 		if ownerInfo.Spec.WMS == nil {
 			*allErrs = append(*allErrs, field.Required(fieldPath, "spec.WMS missing in "+ownerInfo.Name))
 		}
+	case ServiceTypeWCS:
+		// The WCS capabilities use the same OWS service provider as the WFS capabilities
+		if ownerInfo.Spec.WFS == nil {
+			*allErrs = append(*allErrs, field.Required(fieldPath, "spec.WFS missing in "+ownerInfo.Name))
+		}
 	}
 
 }
@@ -0,0 +1,100 @@
+package v3
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pdok/mapserver-operator/internal/crs"
+	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidateBaseDataSQLView(t *testing.T) {
+	tests := []struct {
+		name    string
+		sqlView string
+		valid   bool
+	}{
+		{name: "select", sqlView: "SELECT fuuid, geom FROM roads WHERE class = 'highway'", valid: true},
+		{name: "columns named like statements", sqlView: "SELECT drop_date, deleted, \"update\", insert_count FROM roads", valid: true},
+		{name: "semicolon in a string literal", sqlView: "SELECT * FROM roads WHERE name = 'a;b' OR name = 'it''s;'", valid: true},
+		{name: "semicolon in a quoted identifier", sqlView: "SELECT \"a;b\" FROM roads", valid: true},
+		{name: "not a select", sqlView: "DELETE FROM roads", valid: false},
+		{name: "chained statement", sqlView: "SELECT 1; DROP TABLE x", valid: false},
+		{name: "chained statement after a string literal", sqlView: "SELECT * FROM roads WHERE name = 'a'; DROP TABLE x", valid: false},
+		{name: "trailing semicolon", sqlView: "SELECT * FROM roads;", valid: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allErrs := field.ErrorList{}
+			data := BaseData{Gpkg: &Gpkg{SQLView: smoothoperatorutils.Pointer(tt.sqlView)}}
+			ValidateBaseData(data, field.NewPath("data"), &allErrs)
+			if valid := len(allErrs) == 0; valid != tt.valid {
+				t.Errorf("ValidateBaseData(%q) valid = %v, want %v: %v", tt.sqlView, valid, tt.valid, allErrs)
+			}
+		})
+	}
+}
+
+func TestValidateBaseDataFilter(t *testing.T) {
+	columns := []Column{{Name: "class"}, {Name: "notes"}, {Name: "drop_date"}, {Name: "in"}}
+	tests := []struct {
+		name   string
+		filter string
+		valid  bool
+	}{
+		{name: "columns", filter: "class = 'highway' AND notes IS NOT NULL", valid: true},
+		{name: "columns named like keywords", filter: "drop_date > '2020-01-01' OR \"in\" = 1", valid: true},
+		{name: "keyword in a string literal", filter: "class = 'drop; table'", valid: true},
+		{name: "chained statement", filter: "class = 'a'; DROP TABLE x", valid: false},
+		{name: "semicolon between columns", filter: "class = 'a'; notes = 'b'", valid: false},
+		{name: "unknown column", filter: "secret = 1", valid: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allErrs := field.ErrorList{}
+			data := BaseData{Gpkg: &Gpkg{Columns: columns, Filter: smoothoperatorutils.Pointer(tt.filter)}}
+			ValidateBaseData(data, field.NewPath("data"), &allErrs)
+			if valid := len(allErrs) == 0; valid != tt.valid {
+				t.Errorf("ValidateBaseData(%q) valid = %v, want %v: %v", tt.filter, valid, tt.valid, allErrs)
+			}
+		})
+	}
+}
+
+// TestValidateCRSWithLoadedRegistry checks that a CRS added by a registry loaded with --crs-registry is accepted, the
+// CRD patterns only check the syntax of the codes
+func TestValidateCRSWithLoadedRegistry(t *testing.T) {
+	defer func() {
+		// Restore the built-in registry
+		if err := crs.LoadRegistry(filepath.Join("..", "..", "internal", "crs", "registry.yaml")); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	path := filepath.Join(t.TempDir(), "registry.yaml")
+	registry := `
+wgs84BBox: {minx: "2.5", miny: "50", maxx: "7.5", maxy: "56"}
+crs:
+  - code: EPSG:4171
+    proj4: +proj=longlat +ellps=GRS80
+    axisOrder: yx
+`
+	if err := os.WriteFile(path, []byte(registry), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := crs.LoadRegistry(path); err != nil {
+		t.Fatal(err)
+	}
+
+	allErrs := field.ErrorList{}
+	ValidateCRS("EPSG:4171", field.NewPath("defaultCrs"), &allErrs)
+	if len(allErrs) != 0 {
+		t.Errorf("ValidateCRS(EPSG:4171) = %v, want no errors", allErrs)
+	}
+	ValidateCRS("EPSG:28992", field.NewPath("defaultCrs"), &allErrs)
+	if len(allErrs) != 1 {
+		t.Errorf("ValidateCRS(EPSG:28992) = %v, want 1 error", allErrs)
+	}
+}
@@ -0,0 +1,345 @@
+/*
+MIT License
+
+Copyright (c) 2024 Publieke Dienstverlening op de Kaart
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package v3
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	smoothoperatormodel "github.com/pdok/smooth-operator/model"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// versionName=v3
+// +kubebuilder:resource:path=wcs
+// +kubebuilder:resource:categories=pdok
+// +kubebuilder:printcolumn:name="ReadyPods",type=integer,JSONPath=`.status.podSummary[0].ready`
+// +kubebuilder:printcolumn:name="DesiredPods",type=integer,JSONPath=`.status.podSummary[0].total`
+// +kubebuilder:printcolumn:name="ReconcileStatus",type=string,JSONPath=`.status.conditions[?(@.type == "Reconciled")].reason`
+// +kubebuilder:printcolumn:name="EphemeralStorage",type=string,JSONPath=`.status.ephemeralStorage`,priority=1
+
+// WCS is the Schema for the wcs API.
+type WCS struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WCSSpec `json:"spec"`
+	Status Status  `json:"status,omitempty"`
+}
+
+func (wcs *WCS) OperatorStatus() *smoothoperatormodel.OperatorStatus {
+	return &wcs.Status.OperatorStatus
+}
+
+func (wcs *WCS) ServiceStatus() *Status {
+	return &wcs.Status
+}
+
+// +kubebuilder:object:root=true
+
+// WCSList contains a list of WCS.
+type WCSList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WCS `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WCS{}, &WCSList{})
+}
+
+// WCSSpec is the spec of a Web Coverage Service
+// +kubebuilder:validation:XValidation:rule="!has(self.ingressRouteUrls) || self.ingressRouteUrls.exists_one(x, x.url == self.service.url)",messageExpression="'ingressRouteUrls should include service.url '+self.service.url"
+type WCSSpec struct {
+	// Optional lifecycle settings
+	Lifecycle *smoothoperatormodel.Lifecycle `json:"lifecycle,omitempty"`
+
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// Strategic merge patch for the pod in the deployment. E.g. to patch the resources or add extra env vars.
+	PodSpecPatch                 corev1.PodSpec                `json:"podSpecPatch"`
+	HorizontalPodAutoscalerPatch *HorizontalPodAutoscalerPatch `json:"horizontalPodAutoscalerPatch,omitempty"`
+
+	// Options configures optional behaviors of the operator, like ingress, casing, and data prefetching.
+	Options *BaseOptions `json:"options,omitempty"`
+
+	// Custom healthcheck options
+	HealthCheck *HealthCheckWCS `json:"healthCheck,omitempty"`
+
+	// Optional list of URLs where the service can be reached
+	// By default only the spec.service.url is used
+	IngressRouteURLs smoothoperatormodel.IngressRouteURLs `json:"ingressRouteUrls,omitempty"`
+
+	// service configuration
+	Service WCSService `json:"service"`
+}
+
+// +kubebuilder:validation:XValidation:message="otherCrs can't contain the defaultCrs",rule="!has(self.otherCrs) || (has(self.otherCrs) && !(self.defaultCrs in self.otherCrs))",fieldPath=".otherCrs"
+type WCSService struct {
+	BaseService `json:",inline"`
+
+	// Inspire holds INSPIRE-specific metadata for the service.
+	Inspire *WFSInspire `json:"inspire,omitempty"`
+
+	// Default CRS of the coverages, one of the CRS registry of the operator
+	// +kubebuilder:validation:Pattern:="^EPSG:[0-9]+$"
+	DefaultCrs string `json:"defaultCrs"`
+
+	// Other CRS a coverage can be requested in, from the CRS registry of the operator
+	// +kubebuilder:validation:MinItems:=1
+	// +kubebuilder:validation:items:Pattern:="^EPSG:[0-9]+$"
+	OtherCrs []string `json:"otherCrs,omitempty"`
+
+	// Service bounding box
+	Bbox *Bbox `json:"bbox,omitempty"`
+
+	// OutputFormats a coverage can be requested in, the first one is the default
+	// +kubebuilder:default:={"image/tiff"}
+	// +kubebuilder:validation:MinItems:=1
+	// +kubebuilder:validation:items:Enum=image/tiff;image/png;image/jpeg;image/x-aaigrid;application/x-netcdf
+	OutputFormats []string `json:"outputFormats,omitempty"`
+
+	// Coverages configurations
+	// +kubebuilder:validation:MinItems:=1
+	// +kubebuilder:validation:Type=array
+	Coverages []Coverage `json:"coverages"`
+}
+
+func (s WCSService) KeywordsIncludingInspireKeyword() []string {
+	keywords := s.Keywords
+	if s.Inspire != nil && !slices.Contains(keywords, "infoCoverageAccessService") {
+		keywords = append(keywords, "infoCoverageAccessService")
+	}
+
+	return keywords
+}
+
+// HealthCheckWCS is the struct with all fields to configure custom healthchecks
+type HealthCheckWCS struct {
+	// +kubebuilder:validation:XValidation:rule="self.lowerAscii().contains('service=wcs')",message="a valid healthcheck contains 'Service=WCS'"
+	// +kubebuilder:validation:XValidation:rule="self.lowerAscii().contains('request=')",message="a valid healthcheck contains 'Request='"
+	Querystring string `json:"querystring"`
+	// +kubebuilder:validation:Pattern=(image/tiff|image/png|text/xml)
+	Mimetype string `json:"mimetype"`
+}
+
+// Coverage defines a WCS coverage
+type Coverage struct {
+	// Name of the coverage, used as the CoverageId
+	// +kubebuilder:validation:Pattern:=`^[A-Za-z_][A-Za-z0-9_.\-]*$`
+	Name string `json:"name"`
+
+	// Title of the coverage
+	// +kubebuilder:validation:MinLength:=1
+	Title string `json:"title"`
+
+	// Abstract of the coverage
+	// +kubebuilder:validation:MinLength:=1
+	Abstract string `json:"abstract"`
+
+	// Keywords of the coverage
+	// +kubebuilder:validation:MinItems:=1
+	// +kubebuilder:validation:items:MinLength:=1
+	Keywords []string `json:"keywords"`
+
+	// Metadata URL
+	// +kubebuilder:validation:Type=object
+	DatasetMetadataURL *MetadataURL `json:"datasetMetadataUrl,omitempty"`
+
+	// Optional coverage bbox
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type:=object
+	Bbox *FeatureBbox `json:"bbox,omitempty"`
+
+	// Coverage data connection
+	// +kubebuilder:validation:Type=object
+	Data CoverageData `json:"data"`
+
+	// Bands of the coverage, these can be selected with a RANGESUBSET.
+	// If omitted the bands are named after their index in the raster.
+	// +kubebuilder:validation:MinItems:=1
+	Bands []Band `json:"bands,omitempty"`
+}
+
+// CoverageData holds the raster source of a coverage
+type CoverageData struct {
+	// TIF configures a GeoTIFF or VRT raster source
+	TIF TIF `json:"tif"`
+}
+
+// Band describes a single band (range field) of a coverage
+type Band struct {
+	// Name of the band, used in the RANGESUBSET parameter
+	// +kubebuilder:validation:Pattern:=`^[A-Za-z_][A-Za-z0-9_.\-]*$`
+	Name string `json:"name"`
+
+	// Description of the band
+	// +kubebuilder:validation:MinLength:=1
+	Description *string `json:"description,omitempty"`
+
+	// Unit of measure of the band values, e.g. m
+	// +kubebuilder:validation:MinLength:=1
+	UOM *string `json:"uom,omitempty"`
+
+	// Value that marks a cell without data
+	// +kubebuilder:validation:Pattern="^-?[0-9]+([.][0-9]*)?$"
+	NilValue *string `json:"nilValue,omitempty"`
+}
+
+func (wcs *WCS) HasPostgisData() bool {
+	return false
+}
+
+func (wcs *WCS) GroupKind() schema.GroupKind {
+	return schema.GroupKind{Group: GroupVersion.Group, Kind: wcs.Kind}
+}
+
+func (wcs *WCS) Inspire() *WFSInspire {
+	return wcs.Spec.Service.Inspire
+}
+
+func (wcs *WCS) Mapfile() *Mapfile {
+	return wcs.Spec.Service.Mapfile
+}
+
+func (wcs *WCS) Type() ServiceType {
+	return ServiceTypeWCS
+}
+
+func (wcs *WCS) TypedName() string {
+	name := wcs.GetName()
+	typeSuffix := strings.ToLower(string(ServiceTypeWCS))
+
+	if strings.HasSuffix(name, typeSuffix) {
+		return name
+	}
+
+	return name + "-" + typeSuffix
+}
+
+func (wcs *WCS) PodSpecPatch() corev1.PodSpec {
+	return wcs.Spec.PodSpecPatch
+}
+
+func (wcs *WCS) HorizontalPodAutoscalerPatch() *HorizontalPodAutoscalerPatch {
+	return wcs.Spec.HorizontalPodAutoscalerPatch
+}
+
+func (wcs *WCS) Options() Options {
+	if wcs.Spec.Options == nil {
+		return *GetDefaultOptions()
+	}
+
+	return Options{BaseOptions: *wcs.Spec.Options}
+}
+
+func (wcs *WCS) URL() smoothoperatormodel.URL {
+	return wcs.Spec.Service.URL
+}
+
+func (wcs *WCS) DatasetMetadataIDs() []string {
+	ids := []string{}
+
+	for _, coverage := range wcs.Spec.Service.Coverages {
+		if coverage.DatasetMetadataURL != nil && coverage.DatasetMetadataURL.CSW != nil {
+			if id := coverage.DatasetMetadataURL.CSW.MetadataIdentifier; !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids
+}
+
+// GeoPackages returns no geopackages, a WCS only serves rasters
+func (wcs *WCS) GeoPackages() []*Gpkg {
+	return make([]*Gpkg, 0)
+}
+
+func (wcs *WCS) GetUniqueTiffBlobKeys() []string {
+	blobKeys := map[string]bool{}
+	for _, coverage := range wcs.Spec.Service.Coverages {
+		blobKeys[coverage.Data.TIF.BlobKey] = true
+	}
+	keys := slices.Collect(maps.Keys(blobKeys))
+	sort.Strings(keys)
+	return keys
+}
+
+// GetTIFs returns the TIFFs of the coverages
+func (wcs *WCS) GetTIFs() []*TIF {
+	tifs := []*TIF{}
+	for _, coverage := range wcs.Spec.Service.Coverages {
+		tifs = append(tifs, &coverage.Data.TIF)
+	}
+	return tifs
+}
+
+// GetOutputFormats returns the configured output formats, or the default image/tiff
+func (wcs *WCS) GetOutputFormats() []string {
+	if len(wcs.Spec.Service.OutputFormats) == 0 {
+		return []string{"image/tiff"}
+	}
+
+	return wcs.Spec.Service.OutputFormats
+}
+
+func (wcs *WCS) ReadinessQueryString() (string, string, error) {
+	if hc := wcs.Spec.HealthCheck; hc != nil {
+		return hc.Querystring, hc.Mimetype, nil
+	}
+
+	if len(wcs.Spec.Service.Coverages) == 0 {
+		return "", "", errors.New("cannot get readiness probe for WCS, coverages could not be found")
+	}
+
+	return "SERVICE=WCS&VERSION=2.0.1&REQUEST=DescribeCoverage&COVERAGEID=" + wcs.Spec.Service.Coverages[0].Name, "text/xml", nil
+}
+
+func (wcs *WCS) IngressRouteURLs(includeServiceURLWhenEmpty bool) smoothoperatormodel.IngressRouteURLs {
+	if len(wcs.Spec.IngressRouteURLs) == 0 {
+		if includeServiceURLWhenEmpty {
+			return smoothoperatormodel.IngressRouteURLs{{URL: wcs.Spec.Service.URL}}
+		}
+
+		return smoothoperatormodel.IngressRouteURLs{}
+	}
+
+	return wcs.Spec.IngressRouteURLs
+}
+
+func (wcs *WCS) OwnerInfoRef() string {
+	return wcs.Spec.Service.OwnerInfoRef
+}
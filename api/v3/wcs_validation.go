@@ -0,0 +1,139 @@
+package v3
+
+import (
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	sharedValidation "github.com/pdok/smooth-operator/pkg/validation"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func (wcs *WCS) ValidateCreate(c client.Client) ([]string, error) {
+	return ValidateCreate(c, wcs, ValidateWCS)
+}
+
+func (wcs *WCS) ValidateUpdate(c client.Client, wcsOld *WCS) ([]string, error) {
+	return ValidateUpdate(c, wcs, wcsOld, ValidateWCS)
+}
+
+// ValidateCreateWCS validates WCS creation without k8s client
+func ValidateCreateWCS(wcs *WCS, warnings *[]string, allErrs *field.ErrorList) {
+	validateCreateWMSWFS(nil, wcs, warnings, allErrs, ValidateWCS)
+}
+
+// ValidateUpdateWCS validates WCS update without k8s client
+func ValidateUpdateWCS(wcs *WCS, wcsOld *WCS, warnings *[]string, allErrs *field.ErrorList) {
+	validateUpdateWMSWFS(nil, wcs, wcsOld, warnings, allErrs, ValidateWCS)
+}
+
+func ValidateWCS(wcs *WCS, warnings *[]string, allErrs *field.ErrorList) {
+	if strings.Contains(wcs.GetName(), "wcs") {
+		sharedValidation.AddWarning(
+			warnings,
+			*field.NewPath("metadata").Child("name"),
+			"name should not contain wcs",
+			wcs.GroupVersionKind(),
+			wcs.GetName(),
+		)
+	}
+
+	service := wcs.Spec.Service
+	path := field.NewPath("spec").Child("service")
+
+	ValidateCRS(service.DefaultCrs, path.Child("defaultCrs"), allErrs)
+
+	if service.Mapfile != nil && service.Bbox != nil {
+		sharedValidation.AddWarning(
+			warnings,
+			*path.Child("bbox"),
+			"is not used when service.mapfile is configured",
+			wcs.GroupVersionKind(),
+			wcs.GetName(),
+		)
+	}
+
+	crsses := []string{}
+	for i, crs := range service.OtherCrs {
+		ValidateCRS(crs, path.Child("otherCrs").Index(i), allErrs)
+		if slices.Contains(crsses, crs) {
+			*allErrs = append(*allErrs, field.Duplicate(
+				path.Child("otherCrs").Index(i),
+				crs,
+			))
+		} else {
+			crsses = append(crsses, crs)
+		}
+	}
+
+	outputFormats := []string{}
+	for i, outputFormat := range service.OutputFormats {
+		if slices.Contains(outputFormats, outputFormat) {
+			*allErrs = append(*allErrs, field.Duplicate(
+				path.Child("outputFormats").Index(i),
+				outputFormat,
+			))
+		} else {
+			outputFormats = append(outputFormats, outputFormat)
+		}
+	}
+
+	ValidateInspire(wcs, allErrs, warnings)
+
+	ValidateOptions(wcs.Options(), allErrs)
+
+	if wcs.Options().DataRefresh {
+		*allErrs = append(*allErrs, field.Forbidden(
+			field.NewPath("spec").Child("options").Child("dataRefresh"),
+			"only applies to geopackages, a WCS only serves rasters",
+		))
+	}
+
+	if wcs.Spec.HorizontalPodAutoscalerPatch != nil {
+		ValidateHorizontalPodAutoscalerPatch(*wcs.Spec.HorizontalPodAutoscalerPatch, allErrs)
+	}
+
+	podSpecPatch := wcs.Spec.PodSpecPatch
+	ValidateEphemeralStorage(podSpecPatch, allErrs)
+
+	ValidateCoverages(wcs, warnings, allErrs)
+}
+
+func ValidateCoverages(wcs *WCS, warnings *[]string, allErrs *field.ErrorList) {
+	names := []string{}
+	path := field.NewPath("spec").Child("service").Child("coverages")
+	for index, coverage := range wcs.Spec.Service.Coverages {
+		if slices.Contains(names, coverage.Name) {
+			*allErrs = append(*allErrs, field.Duplicate(
+				path.Index(index).Child("name"),
+				coverage.Name,
+			))
+		} else {
+			names = append(names, coverage.Name)
+		}
+
+		if wcs.Spec.Service.Mapfile != nil && coverage.Bbox != nil && coverage.Bbox.DefaultCRS != nil {
+			sharedValidation.AddWarning(
+				warnings,
+				*path.Index(index).Child("bbox").Child("defaultCrs"),
+				"is not used when service.mapfile is configured",
+				wcs.GroupVersionKind(),
+				wcs.GetName(),
+			)
+		}
+
+		bandNames := []string{}
+		for bandIndex, band := range coverage.Bands {
+			if slices.Contains(bandNames, band.Name) {
+				*allErrs = append(*allErrs, field.Duplicate(
+					path.Index(index).Child("bands").Index(bandIndex).Child("name"),
+					band.Name,
+				))
+			} else {
+				bandNames = append(bandNames, band.Name)
+			}
+		}
+	}
+}
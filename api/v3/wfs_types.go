@@ -49,17 +49,22 @@ import (
 // +kubebuilder:printcolumn:name="ReadyPods",type=integer,JSONPath=`.status.podSummary[0].ready`
 // +kubebuilder:printcolumn:name="DesiredPods",type=integer,JSONPath=`.status.podSummary[0].total`
 // +kubebuilder:printcolumn:name="ReconcileStatus",type=string,JSONPath=`.status.conditions[?(@.type == "Reconciled")].reason`
+// +kubebuilder:printcolumn:name="EphemeralStorage",type=string,JSONPath=`.status.ephemeralStorage`,priority=1
 
 // WFS is the Schema for the wfs API.
 type WFS struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec   WFSSpec                            `json:"spec"`
-	Status smoothoperatormodel.OperatorStatus `json:"status,omitempty"`
+	Spec   WFSSpec `json:"spec"`
+	Status Status  `json:"status,omitempty"`
 }
 
 func (wfs *WFS) OperatorStatus() *smoothoperatormodel.OperatorStatus {
+	return &wfs.Status.OperatorStatus
+}
+
+func (wfs *WFS) ServiceStatus() *Status {
 	return &wfs.Status
 }
 
@@ -76,6 +81,24 @@ func init() {
 	SchemeBuilder.Register(&WFS{}, &WFSList{})
 }
 
+// SupportedWFSOutputFormats are the GetFeature output formats the mapfile-generator has an OUTPUTFORMAT for.
+// The GML formats are native to mapserver, the others are written through OGR.
+var SupportedWFSOutputFormats = []string{
+	GML32OutputFormat,
+	"text/xml; subtype=gml/3.2.1",
+	"text/xml; subtype=gml/3.1.1",
+	"application/json; subtype=geojson",
+	"application/json",
+	"text/csv",
+	"application/geopackage+sqlite3",
+}
+
+// GML32OutputFormat is the output format every WFS 2.0.0 should offer
+const GML32OutputFormat = "application/gml+xml; version=3.2"
+
+// MaxCountDefault is the hard cap on the wfs_maxfeatures of a featureType
+const MaxCountDefault = 10000
+
 // WFSSpec vertegenwoordigt de hoofdstruct voor de YAML-configuratie
 // +kubebuilder:validation:XValidation:rule="!has(self.ingressRouteUrls) || self.ingressRouteUrls.exists_one(x, x.url == self.service.url)",messageExpression="'ingressRouteUrls should include service.url '+self.service.url"
 type WFSSpec struct {
@@ -90,7 +113,7 @@ type WFSSpec struct {
 	HorizontalPodAutoscalerPatch *HorizontalPodAutoscalerPatch `json:"horizontalPodAutoscalerPatch,omitempty"`
 	// TODO omitting the options field or setting an empty value results in incorrect defaulting of the options
 	// Options configures optional behaviors of the operator, like ingress, casing, and data prefetching.
-	Options *BaseOptions `json:"options,omitempty"`
+	Options *WFSOptions `json:"options,omitempty"`
 
 	// Custom healthcheck options
 	HealthCheck *HealthCheckWFS `json:"healthCheck,omitempty"`
@@ -110,13 +133,13 @@ type WFSService struct {
 	// Inspire holds INSPIRE-specific metadata for the service.
 	Inspire *WFSInspire `json:"inspire,omitempty"`
 
-	// Default CRS (DataEPSG)
-	// +kubebuilder:validation:Pattern:="^EPSG:(28992|25831|25832|3034|3035|3857|4258|4326)$"
+	// Default CRS (DataEPSG), one of the CRS registry of the operator
+	// +kubebuilder:validation:Pattern:="^EPSG:[0-9]+$"
 	DefaultCrs string `json:"defaultCrs"`
 
-	// Other supported CRS
+	// Other supported CRS, from the CRS registry of the operator
 	// +kubebuilder:validation:MinItems:=1
-	// +kubebuilder:validation:items:Pattern:="^EPSG:(28992|25831|25832|3034|3035|3857|4258|4326)$"
+	// +kubebuilder:validation:items:Pattern:="^EPSG:[0-9]+$"
 	OtherCrs []string `json:"otherCrs,omitempty"`
 
 	// Service bounding box
@@ -126,12 +149,32 @@ type WFSService struct {
 	// +kubebuilder:validation:Minimum:=1
 	CountDefault *int `json:"countDefault,omitempty"`
 
+	// OutputFormats a feature can be requested in with GetFeature, see SupportedWFSOutputFormats.
+	// If omitted the GML and GeoJSON formats are offered
+	// +kubebuilder:validation:MinItems:=1
+	OutputFormats []string `json:"outputFormats,omitempty"`
+
+	// StoredQueries offered next to the mandatory urn:ogc:def:query:OGC-WFS::GetFeatureById
+	// +kubebuilder:validation:MinItems:=1
+	StoredQueries []StoredQuery `json:"storedQueries,omitempty"`
+
 	// FeatureTypes configurations
 	// +kubebuilder:validation:MinItems:=1
 	// +kubebuilder:validation:Type=array
 	FeatureTypes []FeatureType `json:"featureTypes"`
 }
 
+// WFSOptions are the Options of the WFS, the BaseOptions extended with the options exclusively used by the WFS
+// +kubebuilder:validation:Type=object
+type WFSOptions struct {
+	BaseOptions `json:",inline"`
+
+	// OgcAPIFeatures additionally serves the featuretypes as OGC API Features under <service.url>/ogc.
+	// +kubebuilder:default:=false
+	// +kubebuilder:validation:Optional
+	OgcAPIFeatures bool `json:"ogcApiFeatures"`
+}
+
 func (s WFSService) KeywordsIncludingInspireKeyword() []string {
 	keywords := s.Keywords
 	if s.Inspire != nil && !slices.Contains(keywords, "infoFeatureAccessService") {
@@ -192,6 +235,24 @@ type FeatureType struct {
 	// +kubebuilder:validation:Type:=object
 	Bbox *FeatureBbox `json:"bbox,omitempty"`
 
+	// Optional subset of service.outputFormats for this featureType
+	// +kubebuilder:validation:MinItems:=1
+	OutputFormats []string `json:"outputFormats,omitempty"`
+
+	// Optional default CRS of this featureType, overrides service.defaultCrs
+	// +kubebuilder:validation:Pattern:="^EPSG:[0-9]+$"
+	DefaultCrs *string `json:"defaultCrs,omitempty"`
+
+	// Optional other supported CRS of this featureType, overrides service.otherCrs
+	// +kubebuilder:validation:MinItems:=1
+	// +kubebuilder:validation:items:Pattern:="^EPSG:[0-9]+$"
+	OtherCrs []string `json:"otherCrs,omitempty"`
+
+	// Optional CountDefault of this featureType, overrides service.countDefault -> wfs_maxfeatures of the layer in the mapfile, at most MaxCountDefault
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=10000
+	CountDefault *int `json:"countDefault,omitempty"`
+
 	// FeatureType data connection
 	// +kubebuilder:validation:Type=object
 	// +kubebuilder:validation:XValidation:rule="has(self.gpkg) || has(self.postgis)", message="At least one of the datasource should be provided (postgis, gpkg)"
@@ -210,6 +271,49 @@ type FeatureBbox struct {
 	WGS84 *smoothoperatormodel.BBox `json:"wgs84,omitempty"`
 }
 
+// StoredQuery is a predefined query on a single featureType
+type StoredQuery struct {
+	// ID of the stored query, e.g. urn:ogc:def:query:OGC-WFS::GetBuildingById
+	// +kubebuilder:validation:Pattern:=`^\S+$`
+	ID string `json:"id"`
+
+	// Title of the stored query
+	// +kubebuilder:validation:MinLength:=1
+	Title string `json:"title"`
+
+	// Abstract of the stored query
+	// +kubebuilder:validation:MinLength:=1
+	Abstract *string `json:"abstract,omitempty"`
+
+	// Name of the featureType the stored query returns
+	// +kubebuilder:validation:MinLength:=1
+	FeatureType string `json:"featureType"`
+
+	// Parameters of the stored query
+	Parameters []StoredQueryParameter `json:"parameters,omitempty"`
+
+	// Filter Encoding 2.0 filter (fes:Filter) template. Parameters are referenced as ${name},
+	// columns of the featureType as <fes:ValueReference>column</fes:ValueReference>.
+	// +kubebuilder:validation:MinLength:=1
+	Filter string `json:"filter"`
+}
+
+// StoredQueryParameter is a parameter of a StoredQuery
+type StoredQueryParameter struct {
+	// Name of the parameter
+	// +kubebuilder:validation:Pattern:=`^[a-zA-Z_][a-zA-Z0-9_]*$`
+	Name string `json:"name"`
+
+	// Title of the parameter
+	// +kubebuilder:validation:MinLength:=1
+	Title *string `json:"title,omitempty"`
+
+	// XML schema type of the parameter
+	// +kubebuilder:default:="xs:string"
+	// +kubebuilder:validation:Enum="xs:string";"xs:integer";"xs:double";"xs:boolean";"xs:date";"xs:dateTime"
+	Type string `json:"type,omitempty"`
+}
+
 func (wfs *WFS) HasPostgisData() bool {
 	for _, featureType := range wfs.Spec.Service.FeatureTypes {
 		if featureType.Data.Postgis != nil {
@@ -259,7 +363,40 @@ func (wfs *WFS) Options() Options {
 		return *GetDefaultOptions()
 	}
 
-	return Options{BaseOptions: *wfs.Spec.Options}
+	return Options{BaseOptions: wfs.Spec.Options.BaseOptions}
+}
+
+// OgcAPIFeaturesEnabled returns whether the featuretypes are also served as OGC API Features
+func (wfs *WFS) OgcAPIFeaturesEnabled() bool {
+	return wfs.Spec.Options != nil && wfs.Spec.Options.OgcAPIFeatures
+}
+
+// GetOutputFormats returns the output formats of the featureType, falling back to those of the service.
+// Returns nil when neither are configured, the defaults are left to the mapfile- and capabilities-generator then.
+func (wfs *WFS) GetOutputFormats(featureType FeatureType) []string {
+	if len(featureType.OutputFormats) > 0 {
+		return featureType.OutputFormats
+	}
+
+	return wfs.Spec.Service.OutputFormats
+}
+
+// GetDefaultCrs returns the default CRS of the featureType, falling back to that of the service.
+func (wfs *WFS) GetDefaultCrs(featureType FeatureType) string {
+	if featureType.DefaultCrs != nil {
+		return *featureType.DefaultCrs
+	}
+
+	return wfs.Spec.Service.DefaultCrs
+}
+
+// GetOtherCrs returns the other CRS of the featureType, falling back to those of the service.
+func (wfs *WFS) GetOtherCrs(featureType FeatureType) []string {
+	if len(featureType.OtherCrs) > 0 {
+		return featureType.OtherCrs
+	}
+
+	return wfs.Spec.Service.OtherCrs
 }
 
 func (wfs *WFS) URL() smoothoperatormodel.URL {
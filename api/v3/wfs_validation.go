@@ -1,7 +1,9 @@
 package v3
 
 import (
+	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -11,6 +13,17 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
+const (
+	getFeatureByIDStoredQuery = "urn:ogc:def:query:OGC-WFS::GetFeatureById"
+	// geometryValueReference is the name of the geometry property of a featureType in the service output
+	geometryValueReference = "geom"
+)
+
+var (
+	storedQueryParameterRegex      = regexp.MustCompile(`\$\{([^}]*)}`)
+	storedQueryValueReferenceRegex = regexp.MustCompile(`<(?:\w+:)?ValueReference>\s*(?:\w+:)?([^<\s]+)\s*</`)
+)
+
 func (wfs *WFS) ValidateCreate(c client.Client) ([]string, error) {
 	return ValidateCreate(c, wfs, ValidateWFS)
 }
@@ -43,12 +56,7 @@ func ValidateWFS(wfs *WFS, warnings *[]string, allErrs *field.ErrorList) {
 	service := wfs.Spec.Service
 	path := field.NewPath("spec").Child("service")
 
-	if service.Mapfile == nil && service.DefaultCrs != "EPSG:28992" && service.Bbox == nil {
-		*allErrs = append(*allErrs, field.Required(
-			path.Child("bbox").Child("defaultCRS"),
-			"when service.defaultCRS is not 'EPSG:28992'",
-		))
-	}
+	ValidateCRS(service.DefaultCrs, path.Child("defaultCrs"), allErrs)
 
 	if service.Mapfile != nil && service.Bbox != nil {
 		sharedValidation.AddWarning(
@@ -60,8 +68,19 @@ func ValidateWFS(wfs *WFS, warnings *[]string, allErrs *field.ErrorList) {
 		)
 	}
 
+	if service.Mapfile != nil && wfs.OgcAPIFeaturesEnabled() {
+		sharedValidation.AddWarning(
+			warnings,
+			*field.NewPath("spec").Child("options").Child("ogcApiFeatures"),
+			"the OGCAPI metadata should be part of service.mapfile",
+			wfs.GroupVersionKind(),
+			wfs.GetName(),
+		)
+	}
+
 	crsses := []string{}
 	for i, crs := range service.OtherCrs {
+		ValidateCRS(crs, path.Child("otherCrs").Index(i), allErrs)
 		if slices.Contains(crsses, crs) {
 			*allErrs = append(*allErrs, field.Duplicate(
 				path.Child("otherCrs").Index(i),
@@ -72,8 +91,19 @@ func ValidateWFS(wfs *WFS, warnings *[]string, allErrs *field.ErrorList) {
 		}
 	}
 
+	validateWFSOutputFormats(service.OutputFormats, path.Child("outputFormats"), allErrs)
+	if len(service.OutputFormats) > 0 && !slices.Contains(service.OutputFormats, GML32OutputFormat) {
+		*allErrs = append(*allErrs, field.Invalid(
+			path.Child("outputFormats"),
+			service.OutputFormats,
+			"should contain '"+GML32OutputFormat+"'",
+		))
+	}
+
 	ValidateInspire(wfs, allErrs, warnings)
 
+	ValidateOptions(wfs.Options(), allErrs)
+
 	if wfs.Spec.HorizontalPodAutoscalerPatch != nil {
 		ValidateHorizontalPodAutoscalerPatch(*wfs.Spec.HorizontalPodAutoscalerPatch, allErrs)
 	}
@@ -82,6 +112,8 @@ func ValidateWFS(wfs *WFS, warnings *[]string, allErrs *field.ErrorList) {
 	ValidateEphemeralStorage(podSpecPatch, allErrs)
 
 	ValidateFeatureTypes(wfs, warnings, allErrs)
+
+	ValidateStoredQueries(wfs, allErrs)
 }
 
 func ValidateFeatureTypes(wfs *WFS, warnings *[]string, allErrs *field.ErrorList) {
@@ -97,6 +129,47 @@ func ValidateFeatureTypes(wfs *WFS, warnings *[]string, allErrs *field.ErrorList
 			names = append(names, featureType.Name)
 		}
 
+		ValidateBaseData(featureType.Data, path.Index(index).Child("data"), allErrs)
+
+		validateWFSOutputFormats(featureType.OutputFormats, path.Index(index).Child("outputFormats"), allErrs)
+		for i, outputFormat := range featureType.OutputFormats {
+			if len(wfs.Spec.Service.OutputFormats) > 0 && !slices.Contains(wfs.Spec.Service.OutputFormats, outputFormat) {
+				*allErrs = append(*allErrs, field.Invalid(
+					path.Index(index).Child("outputFormats").Index(i),
+					outputFormat,
+					"should be one of service.outputFormats",
+				))
+			}
+		}
+
+		validateCountDefault(featureType.CountDefault, path.Index(index).Child("countDefault"), allErrs)
+
+		if featureType.DefaultCrs != nil {
+			ValidateCRS(*featureType.DefaultCrs, path.Index(index).Child("defaultCrs"), allErrs)
+		}
+		crsses := []string{}
+		for i, crs := range featureType.OtherCrs {
+			ValidateCRS(crs, path.Index(index).Child("otherCrs").Index(i), allErrs)
+			if slices.Contains(crsses, crs) {
+				*allErrs = append(*allErrs, field.Duplicate(
+					path.Index(index).Child("otherCrs").Index(i),
+					crs,
+				))
+			} else {
+				crsses = append(crsses, crs)
+			}
+		}
+
+		// Only checked when the featureType overrides the CRSs, the service CRSs are accepted as they are
+		defaultCrs := wfs.GetDefaultCrs(featureType)
+		if (featureType.DefaultCrs != nil || len(featureType.OtherCrs) > 0) && slices.Contains(wfs.GetOtherCrs(featureType), defaultCrs) {
+			*allErrs = append(*allErrs, field.Invalid(
+				path.Index(index).Child("defaultCrs"),
+				defaultCrs,
+				"is also one of the otherCrs",
+			))
+		}
+
 		if wfs.Spec.Service.Mapfile != nil && featureType.Bbox != nil && featureType.Bbox.DefaultCRS != nil {
 			sharedValidation.AddWarning(
 				warnings,
@@ -109,3 +182,114 @@ func ValidateFeatureTypes(wfs *WFS, warnings *[]string, allErrs *field.ErrorList
 
 	}
 }
+
+func validateCountDefault(countDefault *int, path *field.Path, allErrs *field.ErrorList) {
+	if countDefault != nil && (*countDefault < 1 || *countDefault > MaxCountDefault) {
+		*allErrs = append(*allErrs, field.Invalid(
+			path,
+			*countDefault,
+			"should be between 1 and "+strconv.Itoa(MaxCountDefault),
+		))
+	}
+}
+
+func ValidateStoredQueries(wfs *WFS, allErrs *field.ErrorList) {
+	ids := []string{getFeatureByIDStoredQuery}
+	path := field.NewPath("spec").Child("service").Child("storedQueries")
+	for index, storedQuery := range wfs.Spec.Service.StoredQueries {
+		queryPath := path.Index(index)
+		if slices.Contains(ids, storedQuery.ID) {
+			*allErrs = append(*allErrs, field.Duplicate(
+				queryPath.Child("id"),
+				storedQuery.ID,
+			))
+		} else {
+			ids = append(ids, storedQuery.ID)
+		}
+
+		parameters := []string{}
+		for i, parameter := range storedQuery.Parameters {
+			if slices.Contains(parameters, parameter.Name) {
+				*allErrs = append(*allErrs, field.Duplicate(
+					queryPath.Child("parameters").Index(i).Child("name"),
+					parameter.Name,
+				))
+			} else {
+				parameters = append(parameters, parameter.Name)
+			}
+		}
+
+		used := []string{}
+		for _, match := range storedQueryParameterRegex.FindAllStringSubmatch(storedQuery.Filter, -1) {
+			if !slices.Contains(parameters, match[1]) {
+				*allErrs = append(*allErrs, field.Invalid(
+					queryPath.Child("filter"),
+					match[0],
+					"parameter is not defined in parameters",
+				))
+			}
+			used = append(used, match[1])
+		}
+		for i, parameter := range storedQuery.Parameters {
+			if !slices.Contains(used, parameter.Name) {
+				*allErrs = append(*allErrs, field.Invalid(
+					queryPath.Child("parameters").Index(i).Child("name"),
+					parameter.Name,
+					"parameter is not used in the filter",
+				))
+			}
+		}
+
+		featureTypeIndex := slices.IndexFunc(wfs.Spec.Service.FeatureTypes, func(featureType FeatureType) bool {
+			return featureType.Name == storedQuery.FeatureType
+		})
+		if featureTypeIndex == -1 {
+			*allErrs = append(*allErrs, field.Invalid(
+				queryPath.Child("featureType"),
+				storedQuery.FeatureType,
+				"should be one of service.featureTypes[*].name",
+			))
+			continue
+		}
+
+		columns := []string{geometryValueReference}
+		if featureTypeColumns := wfs.Spec.Service.FeatureTypes[featureTypeIndex].Data.GetColumns(); featureTypeColumns != nil {
+			for _, column := range *featureTypeColumns {
+				columns = append(columns, column.Name)
+				if column.Alias != nil {
+					columns = append(columns, *column.Alias)
+				}
+			}
+		}
+		for _, match := range storedQueryValueReferenceRegex.FindAllStringSubmatch(storedQuery.Filter, -1) {
+			if !slices.Contains(columns, match[1]) {
+				*allErrs = append(*allErrs, field.Invalid(
+					queryPath.Child("filter"),
+					match[1],
+					"column is not one of the columns of featureType "+storedQuery.FeatureType,
+				))
+			}
+		}
+	}
+}
+
+func validateWFSOutputFormats(outputFormats []string, path *field.Path, allErrs *field.ErrorList) {
+	seen := []string{}
+	for i, outputFormat := range outputFormats {
+		switch {
+		case !slices.Contains(SupportedWFSOutputFormats, outputFormat):
+			*allErrs = append(*allErrs, field.NotSupported(
+				path.Index(i),
+				outputFormat,
+				SupportedWFSOutputFormats,
+			))
+		case slices.Contains(seen, outputFormat):
+			*allErrs = append(*allErrs, field.Duplicate(
+				path.Index(i),
+				outputFormat,
+			))
+		default:
+			seen = append(seen, outputFormat)
+		}
+	}
+}
@@ -28,10 +28,12 @@ import (
 	"errors"
 	"fmt"
 	"maps"
+	"path"
 	"slices"
 	"sort"
 	"strings"
 
+	"github.com/pdok/mapserver-operator/internal/legendtool"
 	smoothoperatormodel "github.com/pdok/smooth-operator/model"
 
 	corev1 "k8s.io/api/core/v1"
@@ -45,6 +47,35 @@ const (
 	GroupLayer = "groupLayer"
 )
 
+// SLDExtension is the extension of styling assets that contain an SLD 1.0/SE 1.1 document
+const SLDExtension = ".sld"
+
+// Defaults of a legend
+const (
+	DefaultLegendFormat = legendtool.DefaultFormat
+	DefaultLegendWidth  = 78
+	DefaultLegendHeight = 20
+)
+
+// MaxLayerDepth is the number of levels of the layer tree (including the toplayer) that the CRD allows
+const MaxLayerDepth = 6
+
+var maxLayerDepth = MaxLayerDepth
+
+// SetMaxLayerDepth caps the number of levels of the layer tree (including the toplayer) that passes validation
+func SetMaxLayerDepth(depth int) error {
+	if depth < 2 || depth > MaxLayerDepth {
+		return fmt.Errorf("max layer depth must be between 2 and %d", MaxLayerDepth)
+	}
+
+	maxLayerDepth = depth
+	return nil
+}
+
+func GetMaxLayerDepth() int {
+	return maxLayerDepth
+}
+
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
@@ -58,17 +89,22 @@ const (
 // +kubebuilder:printcolumn:name="ReadyPods",type=integer,JSONPath=`.status.podSummary[0].ready`
 // +kubebuilder:printcolumn:name="DesiredPods",type=integer,JSONPath=`.status.podSummary[0].total`
 // +kubebuilder:printcolumn:name="ReconcileStatus",type=string,JSONPath=`.status.conditions[?(@.type == "Reconciled")].reason`
+// +kubebuilder:printcolumn:name="EphemeralStorage",type=string,JSONPath=`.status.ephemeralStorage`,priority=1
 
 // WMS is the Schema for the wms API.
 type WMS struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec   WMSSpec                            `json:"spec"`
-	Status smoothoperatormodel.OperatorStatus `json:"status,omitempty"`
+	Spec   WMSSpec `json:"spec"`
+	Status Status  `json:"status,omitempty"`
 }
 
 func (wms *WMS) OperatorStatus() *smoothoperatormodel.OperatorStatus {
+	return &wms.Status.OperatorStatus
+}
+
+func (wms *WMS) ServiceStatus() *Status {
 	return &wms.Status
 }
 
@@ -115,7 +151,7 @@ type WMSSpec struct {
 	Service WMSService `json:"service"`
 }
 
-// +kubebuilder:validation:XValidation:message="service requires styling, either through service.mapfile, or stylingAssets.configMapRefs",rule=has(self.mapfile) || (has(self.stylingAssets) && has(self.stylingAssets.configMapRefs))
+// +kubebuilder:validation:XValidation:message="service requires styling, either through service.mapfile, or stylingAssets.configMapRefs or stylingAssets.blobKeys",rule=has(self.mapfile) || (has(self.stylingAssets) && (has(self.stylingAssets.configMapRefs) || has(self.stylingAssets.blobKeys)))
 // +kubebuilder:validation:XValidation:message="when using service.mapfile, don't include stylingAssets.configMapRefs",rule=!has(self.mapfile) || (!has(self.stylingAssets) || !has(self.stylingAssets.configMapRefs))
 type WMSService struct {
 	BaseService `json:",inline"`
@@ -141,6 +177,10 @@ type WMSService struct {
 	// Optional. Required files for the styling of the service
 	StylingAssets *StylingAssets `json:"stylingAssets,omitempty"`
 
+	// Optional. Custom GetFeatureInfo HTML templates, preferred over the templates of the featureinfo-generator
+	// +kubebuilder:validation:MinItems:=1
+	FeatureInfoTemplates []FeatureInfoTemplatesRef `json:"featureInfoTemplates,omitempty"`
+
 	// Custom mapfile
 	Mapfile *Mapfile `json:"mapfile,omitempty"`
 
@@ -174,9 +214,9 @@ type HealthCheckWMS struct {
 // StylingAssets contains the files references needed for styling
 // +kubebuilder:validation:XValidation:message="At least one of blobKeys or configMapRefs is required",rule="has(self.blobKeys) || has(self.configMapRefs)"
 type StylingAssets struct {
-	// BlobKeys contains symbol image (.png/.svg) or font (.ttf) keys on blob storage, format: container/key/file.(png|ttf)
+	// BlobKeys contains symbol image (.png/.svg), font (.ttf) or SLD (.sld) keys on blob storage, format: container/key/file.(png|ttf|svg|sld)
 	// +kubebuilder:validation:MinItems:=1
-	// +kubebuilder:validation:items:Pattern:=^.+\/.+\/.+\.(png|ttf|svg)$
+	// +kubebuilder:validation:items:Pattern:=^.+\/.+\/.+\.(png|ttf|svg|sld)$
 	BlobKeys []string `json:"blobKeys,omitempty"`
 
 	// +kubebuilder:validation:MinItems:=1
@@ -188,12 +228,24 @@ type ConfigMapRef struct {
 	// +kubebuilder:validation:MinLength:=1
 	Name string `json:"name"`
 
-	// Keys contains styling assets that contain mapfile code (.style|.symbol), required if you use symbols in your styles
+	// Keys contains styling assets that contain mapfile code (.style|.symbol) or SLD 1.0/SE 1.1 documents (.sld), required if you use symbols in your styles
 	// +kubebuilder:validation:MinItems:=1
-	// +kubebuilder:validation:items:Pattern:=^\S*.\.(style|symbol)
+	// +kubebuilder:validation:items:Pattern:=^\S*.\.(style|symbol|sld)
 	Keys []string `json:"keys,omitempty"`
 }
 
+type FeatureInfoTemplatesRef struct {
+	// Name is the name of the ConfigMap
+	// +kubebuilder:validation:MinLength:=1
+	Name string `json:"name"`
+
+	// Keys contains HTML templates in the MapServer template format. A key <layer name>.html is the template of that layer,
+	// unless the layer sets featureInfo.template. A key feature-info.html replaces the generated template of the service
+	// +kubebuilder:validation:MinItems:=1
+	// +kubebuilder:validation:items:Pattern:=^\S+\.html$
+	Keys []string `json:"keys"`
+}
+
 // +kubebuilder:validation:XValidation:message="A layer should have exactly one of sublayers or data", rule="(has(self.data) || has(self.layers)) && !(has(self.data) && has(self.layers))"
 // +kubebuilder:validation:XValidation:message="A layer with data attribute should have styling", rule="!has(self.data) || has(self.styles)"
 // +kubebuilder:validation:XValidation:message="A layer should have a title when visible", rule="!self.visible || has(self.title)"
@@ -247,6 +299,17 @@ type Layer struct {
 	// Mapfile setting, sets "LABEL_NO_CLIP=ON"
 	LabelNoClip bool `json:"labelNoClip,omitempty"`
 
+	// Whether GetFeatureInfo requests are answered for the layer, defaults to true.
+	// A GroupLayer is only queryable if one of its layers is
+	// +kubebuilder:validation:Optional
+	Queryable *bool `json:"queryable,omitempty"`
+
+	// Whether the layer covers the layers below it, advertised in the capabilities
+	Opaque bool `json:"opaque,omitempty"`
+
+	// GetFeatureInfo settings of a DataLayer
+	FeatureInfo *FeatureInfo `json:"featureInfo,omitempty"`
+
 	// Data (gpkg/postgis/tif) used by the layer
 	Data *Data `json:"data,omitempty"`
 
@@ -256,8 +319,25 @@ type Layer struct {
 	Layers []Layer `json:"layers,omitempty"`
 }
 
+type FeatureInfo struct {
+	// Set to false to show the layer on the map without answering GetFeatureInfo requests, defaults to true
+	// +kubebuilder:validation:Optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Columns of the data that are included in the GetFeatureInfo response, all columns if omitted.
+	// The feature id is always included for vector data
+	// +kubebuilder:validation:MinItems:=1
+	// +kubebuilder:validation:items:MinLength:=1
+	Columns []string `json:"columns,omitempty"`
+
+	// Template is a key in spec.service.featureInfoTemplates with the HTML template of the layer, instead of the generated one
+	// +kubebuilder:validation:MinLength:=1
+	Template *string `json:"template,omitempty"`
+}
+
 type WMSBoundingBox struct {
-	// +kubebuilder:validation:Pattern:="^(EPSG:(28992|25831|25832|3034|3035|3857|4258|4326)|CRS:84)$"
+	// CRS of the bounding box, one of the CRS registry of the operator
+	// +kubebuilder:validation:Pattern:="^(EPSG:[0-9]+|CRS:84)$"
 	CRS  string                   `json:"crs"`
 	BBox smoothoperatormodel.BBox `json:"bbox"`
 }
@@ -289,12 +369,41 @@ type Style struct {
 	// +kubebuilder:validation:MinLength:=1
 	Abstract *string `json:"abstract,omitempty"`
 
+	// The visualization of the style, either mapfile code (.style) or an SLD 1.0/SE 1.1 document (.sld).
+	// A key in stylingAssets.configMapRefs or, for an SLD, the file name of a key in stylingAssets.blobKeys
 	// +kubebuilder:validation:MinLength:=1
 	Visualization *string `json:"visualization,omitempty"`
 
+	// Declarative styling of a TIF layer, instead of a visualization
+	Colormap *Colormap `json:"colormap,omitempty"`
+
 	Legend *Legend `json:"legend,omitempty"`
 }
 
+// Colormap colors a single band raster, the labels of the entries make up the legend
+type Colormap struct {
+	// Ramp interpolates the colors between consecutive entries, classified only colors the exact values of the entries
+	// +kubebuilder:validation:Enum=ramp;classified
+	Type string `json:"type"`
+
+	// The entries of the colormap, in ascending order of value for a ramp
+	// +kubebuilder:validation:MinItems:=1
+	Entries []ColormapEntry `json:"entries"`
+}
+
+type ColormapEntry struct {
+	// +kubebuilder:validation:Pattern="^-?[0-9]+([.][0-9]+)?$"
+	Value string `json:"value"`
+
+	// Color in hex, e.g. #FF0000
+	// +kubebuilder:validation:Pattern="^#[0-9A-Fa-f]{6}$"
+	Color string `json:"color"`
+
+	// Label of the entry in the legend, entries without a label are left out of the legend
+	// +kubebuilder:validation:MinLength:=1
+	Label *string `json:"label,omitempty"`
+}
+
 type Legend struct {
 	// The width of the legend in px, defaults to 78
 	// + kubebuilder:default=78
@@ -304,8 +413,9 @@ type Legend struct {
 	// + kubebuilder:default=20
 	Height int32 `json:"height,omitempty"`
 
-	// Format of the legend, defaults to image/png
+	// Format of the legend, defaults to image/png. The extension of the blobKey must match the format
 	// +kubebuilder:default="image/png"
+	// +kubebuilder:validation:Enum=image/png;image/jpeg;image/gif;image/svg+xml
 	Format string `json:"format,omitempty"`
 
 	// Location of the legend on the blobstore
@@ -379,6 +489,87 @@ func (stylingAssets *StylingAssets) GetAllConfigMapRefKeys() []string {
 	return keys
 }
 
+// GetSLDBlobKey returns the key in BlobKeys of the SLD document with the given file name, nil if there is none
+func (stylingAssets *StylingAssets) GetSLDBlobKey(fileName string) *string {
+	if stylingAssets == nil || !strings.HasSuffix(fileName, SLDExtension) {
+		return nil
+	}
+	for _, blobKey := range stylingAssets.BlobKeys {
+		if path.Base(blobKey) == fileName {
+			return &blobKey
+		}
+	}
+	return nil
+}
+
+// GetFeatureInfoTemplateKeys returns the keys of all featureInfoTemplates ConfigMaps
+func (wmsService *WMSService) GetFeatureInfoTemplateKeys() []string {
+	keys := []string{}
+	for _, ref := range wmsService.FeatureInfoTemplates {
+		keys = append(keys, ref.Keys...)
+	}
+	return keys
+}
+
+// GetFeatureInfoTemplate returns the custom HTML template of the layer, nil if the layer uses the generated template
+func (wmsService *WMSService) GetFeatureInfoTemplate(layer *Layer) *string {
+	if layer.FeatureInfo != nil && layer.FeatureInfo.Template != nil {
+		return layer.FeatureInfo.Template
+	}
+	if layer.Name == nil {
+		return nil
+	}
+	if template := *layer.Name + ".html"; slices.Contains(wmsService.GetFeatureInfoTemplateKeys(), template) {
+		return &template
+	}
+	return nil
+}
+
+// IsSLD returns whether the visualization of the style is an SLD document instead of mapfile code
+func (style *Style) IsSLD() bool {
+	return style.Visualization != nil && strings.HasSuffix(*style.Visualization, SLDExtension)
+}
+
+// Types of a Colormap
+const (
+	ColormapTypeRamp       = "ramp"
+	ColormapTypeClassified = "classified"
+)
+
+// GetFormat returns the format of the legend, DefaultLegendFormat if not set
+func (legend *Legend) GetFormat() string {
+	if legend == nil || legend.Format == "" {
+		return DefaultLegendFormat
+	}
+	return legend.Format
+}
+
+// GetExtension returns the extension of the published legend, a nil legend is generated as a png
+func (legend *Legend) GetExtension() string {
+	return legendtool.GetExtension(legend.GetFormat())
+}
+
+// GetWidth returns the width of the legend in px, DefaultLegendWidth if not set
+func (legend *Legend) GetWidth() int32 {
+	if legend == nil || legend.Width == 0 {
+		return DefaultLegendWidth
+	}
+	return legend.Width
+}
+
+// GetHeight returns the height of the legend in px, DefaultLegendHeight if not set
+func (legend *Legend) GetHeight() int32 {
+	if legend == nil || legend.Height == 0 {
+		return DefaultLegendHeight
+	}
+	return legend.Height
+}
+
+// HasMatchingExtension checks that the extension of the blobKey belongs to the format
+func (legend *Legend) HasMatchingExtension() bool {
+	return slices.Contains(legendtool.Extensions[legend.GetFormat()], strings.ToLower(path.Ext(legend.BlobKey)))
+}
+
 type AnnotatedLayer struct {
 	// The name of the group that this layer belongs to, nil if it is not a member of a group. Groups can be a member of the toplayer as a group
 	GroupName *string
@@ -388,9 +579,12 @@ type AnnotatedLayer struct {
 	IsGroupLayer bool
 	// Contains actual data
 	IsDataLayer bool
+	// Depth of the layer in the tree, 1 for the toplayer
+	Depth int
 	Layer
 }
 
+// GetAnnotatedLayers returns all layers of the tree, depth first starting with the toplayer
 func (wmsService *WMSService) GetAnnotatedLayers() []AnnotatedLayer {
 	result := make([]AnnotatedLayer, 0)
 
@@ -399,30 +593,26 @@ func (wmsService *WMSService) GetAnnotatedLayers() []AnnotatedLayer {
 		IsTopLayer:   true,
 		IsGroupLayer: true,
 		IsDataLayer:  false,
+		Depth:        1,
 		Layer:        wmsService.Layer,
 	})
+	appendAnnotatedSublayers(&result, wmsService.Layer, 2)
 
-	for _, middleLayer := range wmsService.Layer.Layers {
-		result = append(result, AnnotatedLayer{
-			GroupName:    wmsService.Layer.Name,
+	return result
+}
+
+func appendAnnotatedSublayers(result *[]AnnotatedLayer, layer Layer, depth int) {
+	for _, childLayer := range layer.Layers {
+		*result = append(*result, AnnotatedLayer{
+			GroupName:    layer.Name,
 			IsTopLayer:   false,
-			IsGroupLayer: middleLayer.IsGroupLayer(),
-			IsDataLayer:  middleLayer.IsDataLayer(),
-			Layer:        middleLayer,
+			IsGroupLayer: childLayer.IsGroupLayer(),
+			IsDataLayer:  childLayer.IsDataLayer(),
+			Depth:        depth,
+			Layer:        childLayer,
 		})
-
-		for _, bottomLayer := range middleLayer.Layers {
-			result = append(result, AnnotatedLayer{
-				GroupName:    middleLayer.Name,
-				IsTopLayer:   false,
-				IsGroupLayer: false,
-				IsDataLayer:  true,
-				Layer:        bottomLayer,
-			})
-		}
+		appendAnnotatedSublayers(result, childLayer, depth+1)
 	}
-
-	return result
 }
 
 // GetAllSublayers - get all sublayers of a layer, the result does not include the layer itself
@@ -434,25 +624,41 @@ func (layer *Layer) GetAllSublayers() []Layer {
 	return layers
 }
 
+// GetDepth returns the number of levels of the layer tree, 1 for a layer without sublayers
+func (layer *Layer) GetDepth() int {
+	depth := 0
+	for _, childLayer := range layer.Layers {
+		depth = max(depth, childLayer.GetDepth())
+	}
+	return depth + 1
+}
+
+// GetParentLayer returns the layer in the tree that has the (named) layer as sublayer, nil for the toplayer
 func (wmsService *WMSService) GetParentLayer(layer Layer) *Layer {
-	if wmsService.Layer.Layers == nil {
+	if layer.Name == nil {
 		return nil
 	}
+	return getParentLayer(&wmsService.Layer, *layer.Name)
+}
 
-	for _, middleLayer := range wmsService.Layer.Layers {
-		if middleLayer.Name == layer.Name {
-			return &wmsService.Layer
+func getParentLayer(parent *Layer, name string) *Layer {
+	for i := range parent.Layers {
+		childLayer := &parent.Layers[i]
+		if childLayer.Name != nil && *childLayer.Name == name {
+			return parent
 		}
-
-		for _, bottomLayer := range middleLayer.Layers {
-			if bottomLayer.Name == layer.Name {
-				return &middleLayer
-			}
+		if found := getParentLayer(childLayer, name); found != nil {
+			return found
 		}
 	}
 	return nil
 }
 
+// IsTopLayer returns whether the layer is the toplayer of the service
+func (wmsService *WMSService) IsTopLayer(layer *Layer) bool {
+	return layer == &wmsService.Layer
+}
+
 func (layer *Layer) hasData() bool {
 	switch {
 	case layer.Data == nil:
@@ -483,18 +689,23 @@ func (layer *Layer) IsGroupLayer() bool {
 	return len(layer.Layers) > 0
 }
 
-// IsTopLayer - a layer is a toplayer if and only if it has sublayers that are group layers.
-// In other words the layer is level 1 in a 3 level hierarchy.
-func (layer *Layer) IsTopLayer() bool {
+// IsQueryable returns whether GetFeatureInfo requests are answered for the layer,
+// a GroupLayer is queryable if one of its layers is
+func (layer *Layer) IsQueryable() bool {
+	if layer.Queryable != nil && !*layer.Queryable {
+		return false
+	}
 	if layer.IsGroupLayer() {
-		for _, childLayer := range layer.Layers {
-			if childLayer.IsGroupLayer() {
-				return true
-			}
-		}
+		return slices.ContainsFunc(layer.Layers, func(childLayer Layer) bool {
+			return childLayer.Visible && childLayer.IsQueryable()
+		})
 	}
+	return layer.FeatureInfo == nil || layer.FeatureInfo.Enabled == nil || *layer.FeatureInfo.Enabled
+}
 
-	return false
+// IncludesFeatureInfoColumn returns whether the column is part of the GetFeatureInfo response of the layer
+func (layer *Layer) IncludesFeatureInfoColumn(column string) bool {
+	return layer.FeatureInfo == nil || len(layer.FeatureInfo.Columns) == 0 || slices.Contains(layer.FeatureInfo.Columns, column)
 }
 
 func (layer *Layer) hasBoundingBoxForCRS(crs string) bool {
@@ -552,20 +763,22 @@ func (wms *WMS) GetUniqueTiffBlobKeys() []string {
 	return keys
 }
 
-func (wms *WMS) GetAuthority() *Authority {
-	if wms.Spec.Service.Layer.Authority != nil {
-		return wms.Spec.Service.Layer.Authority
+// GetTIFs returns the TIFFs of the data layers
+func (wms *WMS) GetTIFs() []*TIF {
+	tifs := []*TIF{}
+	for _, layer := range wms.Spec.Service.GetAnnotatedLayers() {
+		if layer.hasTIFData() {
+			tifs = append(tifs, layer.Data.TIF)
+		}
 	}
+	return tifs
+}
 
-	for _, childLayer := range wms.Spec.Service.Layer.Layers {
-		if childLayer.Authority != nil {
-			return childLayer.Authority
-		} else if childLayer.Layers != nil {
-			for _, grandChildLayer := range childLayer.Layers {
-				if grandChildLayer.Authority != nil {
-					return grandChildLayer.Authority
-				}
-			}
+// GetAuthority returns the first authority in the layer tree, depth first starting with the toplayer
+func (wms *WMS) GetAuthority() *Authority {
+	for _, layer := range wms.Spec.Service.GetAnnotatedLayers() {
+		if layer.Authority != nil {
+			return layer.Authority
 		}
 	}
 
@@ -648,17 +861,9 @@ func (wms *WMS) DatasetMetadataIDs() []string {
 func (wms *WMS) GeoPackages() []*Gpkg {
 	gpkgs := make([]*Gpkg, 0)
 
-	for _, layer := range wms.Spec.Service.Layer.Layers {
-		if layer.Data != nil {
-			if layer.Data.Gpkg != nil {
-				gpkgs = append(gpkgs, layer.Data.Gpkg)
-			}
-		} else if layer.Layers != nil {
-			for _, childLayer := range layer.Layers {
-				if childLayer.Data != nil && childLayer.Data.Gpkg != nil {
-					gpkgs = append(gpkgs, childLayer.Data.Gpkg)
-				}
-			}
+	for _, layer := range wms.Spec.Service.GetAnnotatedLayers() {
+		if layer.Data != nil && layer.Data.Gpkg != nil {
+			gpkgs = append(gpkgs, layer.Data.Gpkg)
 		}
 	}
 
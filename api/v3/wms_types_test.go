@@ -130,8 +130,10 @@ func TestLayer_setInheritedBoundingBoxes(t *testing.T) {
 }
 
 func TestLayer_GetParent(t *testing.T) {
+	childLayer4 := Layer{Name: smoothoperatorutils.Pointer("childlayer-4")}
+	childLayer3 := Layer{Name: smoothoperatorutils.Pointer("childlayer-3"), Layers: []Layer{childLayer4}}
 	childLayer2 := Layer{Name: smoothoperatorutils.Pointer("childlayer-2")}
-	childLayer1 := Layer{Name: smoothoperatorutils.Pointer("childlayer-1"), Layers: []Layer{childLayer2}}
+	childLayer1 := Layer{Name: smoothoperatorutils.Pointer("childlayer-1"), Layers: []Layer{childLayer2, childLayer3}}
 	topLayer := Layer{Name: smoothoperatorutils.Pointer("toplayer"), Layers: []Layer{childLayer1}}
 
 	type args struct {
@@ -149,6 +151,12 @@ func TestLayer_GetParent(t *testing.T) {
 			args:  args{service: WMSService{Layer: topLayer}},
 			want:  &childLayer1,
 		},
+		{
+			name:  "Test GetParent on layer deeper in the tree",
+			layer: childLayer4,
+			args:  args{service: WMSService{Layer: topLayer}},
+			want:  &childLayer3,
+		},
 		{
 			name:  "Test GetParent on layer without parent",
 			layer: topLayer,
@@ -164,3 +172,118 @@ func TestLayer_GetParent(t *testing.T) {
 		})
 	}
 }
+
+func TestWMSService_GetAnnotatedLayers(t *testing.T) {
+	service := WMSService{
+		Layer: Layer{
+			Name: smoothoperatorutils.Pointer("toplayer"),
+			Layers: []Layer{{
+				Name: smoothoperatorutils.Pointer("group-1"),
+				Layers: []Layer{{
+					Name: smoothoperatorutils.Pointer("group-2"),
+					Layers: []Layer{{
+						Name: smoothoperatorutils.Pointer("group-3"),
+						Layers: []Layer{{
+							Name: smoothoperatorutils.Pointer("data"),
+							Data: &Data{BaseData: BaseData{Gpkg: &Gpkg{BlobKey: "container/key/file.gpkg"}}},
+						}},
+					}},
+				}},
+			}},
+		},
+	}
+
+	expected := []struct {
+		name        string
+		groupName   *string
+		depth       int
+		isDataLayer bool
+	}{
+		{"toplayer", nil, 1, false},
+		{"group-1", smoothoperatorutils.Pointer("toplayer"), 2, false},
+		{"group-2", smoothoperatorutils.Pointer("group-1"), 3, false},
+		{"group-3", smoothoperatorutils.Pointer("group-2"), 4, false},
+		{"data", smoothoperatorutils.Pointer("group-3"), 5, true},
+	}
+
+	layers := service.GetAnnotatedLayers()
+	if len(layers) != len(expected) {
+		t.Fatalf("GetAnnotatedLayers() returned %d layers, want %d", len(layers), len(expected))
+	}
+	for i, want := range expected {
+		layer := layers[i]
+		if *layer.Name != want.name || !reflect.DeepEqual(layer.GroupName, want.groupName) || layer.Depth != want.depth ||
+			layer.IsDataLayer != want.isDataLayer || layer.IsGroupLayer == want.isDataLayer {
+			t.Errorf("GetAnnotatedLayers()[%d] = %v, want %v", i, layer, want)
+		}
+	}
+
+	if depth := service.Layer.GetDepth(); depth != 5 {
+		t.Errorf("GetDepth() = %d, want 5", depth)
+	}
+
+	wms := WMS{Spec: WMSSpec{Service: service}}
+	if gpkgs := wms.GeoPackages(); len(gpkgs) != 1 || gpkgs[0].BlobKey != "container/key/file.gpkg" {
+		t.Errorf("GeoPackages() = %v, want the gpkg of the data layer", gpkgs)
+	}
+}
+
+func TestLegend_HasMatchingExtension(t *testing.T) {
+	tests := []struct {
+		legend        *Legend
+		wantExtension string
+		wantMatching  bool
+	}{
+		{&Legend{BlobKey: "container/key/legend.png"}, ".png", true},
+		{&Legend{Format: "image/png", BlobKey: "container/key/legend.svg"}, ".png", false},
+		{&Legend{Format: "image/svg+xml", BlobKey: "container/key/legend.svg"}, ".svg", true},
+		{&Legend{Format: "image/jpeg", BlobKey: "container/key/legend.JPEG"}, ".jpg", true},
+		{&Legend{Format: "image/gif", BlobKey: "container/key/legend"}, ".gif", false},
+	}
+	for _, tt := range tests {
+		if extension := tt.legend.GetExtension(); extension != tt.wantExtension {
+			t.Errorf("GetExtension() of %s = %s, want %s", tt.legend.BlobKey, extension, tt.wantExtension)
+		}
+		if matching := tt.legend.HasMatchingExtension(); matching != tt.wantMatching {
+			t.Errorf("HasMatchingExtension() of %s = %v, want %v", tt.legend.BlobKey, matching, tt.wantMatching)
+		}
+	}
+
+	var generated *Legend
+	if generated.GetExtension() != ".png" || generated.GetWidth() != 78 || generated.GetHeight() != 20 {
+		t.Errorf("a generated legend must be a png of 78x20")
+	}
+}
+
+func TestWMSService_GetFeatureInfoTemplate(t *testing.T) {
+	service := WMSService{
+		FeatureInfoTemplates: []FeatureInfoTemplatesRef{{Name: "templates", Keys: []string{"layer.html", "custom.html"}}},
+	}
+
+	tests := []struct {
+		layer Layer
+		want  *string
+	}{
+		{Layer{Name: smoothoperatorutils.Pointer("layer")}, smoothoperatorutils.Pointer("layer.html")},
+		{Layer{Name: smoothoperatorutils.Pointer("layer"), FeatureInfo: &FeatureInfo{Template: smoothoperatorutils.Pointer("custom.html")}}, smoothoperatorutils.Pointer("custom.html")},
+		{Layer{Name: smoothoperatorutils.Pointer("other")}, nil},
+	}
+	for _, tt := range tests {
+		if got := service.GetFeatureInfoTemplate(&tt.layer); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetFeatureInfoTemplate() of %s = %v, want %v", *tt.layer.Name, got, tt.want)
+		}
+	}
+}
+
+func TestSetMaxLayerDepth(t *testing.T) {
+	t.Cleanup(func() { maxLayerDepth = MaxLayerDepth })
+
+	for _, depth := range []int{1, MaxLayerDepth + 1} {
+		if err := SetMaxLayerDepth(depth); err == nil {
+			t.Errorf("SetMaxLayerDepth(%d) should fail", depth)
+		}
+	}
+	if err := SetMaxLayerDepth(3); err != nil || GetMaxLayerDepth() != 3 {
+		t.Errorf("SetMaxLayerDepth(3) = %v, max layer depth %d", err, GetMaxLayerDepth())
+	}
+}
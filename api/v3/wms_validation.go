@@ -2,6 +2,8 @@ package v3
 
 import (
 	"fmt"
+	"path"
+	"strconv"
 	"strings"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -64,14 +66,32 @@ func ValidateWMS(wms *WMS, warnings *[]string, allErrs *field.ErrorList) {
 	}
 
 	ValidateInspire(wms, allErrs, warnings)
+	ValidateOptions(wms.Options(), allErrs)
 	if wms.HorizontalPodAutoscalerPatch() != nil {
 		ValidateHorizontalPodAutoscalerPatch(*wms.HorizontalPodAutoscalerPatch(), allErrs)
 	}
 	ValidateEphemeralStorage(wms.PodSpecPatch(), allErrs)
+	validateFeatureInfoTemplates(wms.Spec.Service.FeatureInfoTemplates, allErrs)
 
 	validateLayers(wms, warnings, allErrs)
 }
 
+// validateFeatureInfoTemplates checks that every key is unique, the keys of all ConfigMaps are projected into one directory
+func validateFeatureInfoTemplates(refs []FeatureInfoTemplatesRef, allErrs *field.ErrorList) {
+	keys := []string{}
+	for i, ref := range refs {
+		for j, key := range ref.Keys {
+			if slices.Contains(keys, key) {
+				*allErrs = append(*allErrs, field.Duplicate(
+					field.NewPath("spec").Child("service").Child("featureInfoTemplates").Index(i).Child("keys").Index(j),
+					key,
+				))
+			}
+			keys = append(keys, key)
+		}
+	}
+}
+
 func validateLayers(wms *WMS, warnings *[]string, allErrs *field.ErrorList) {
 
 	layerNames := []string{}
@@ -82,6 +102,7 @@ func validateLayers(wms *WMS, warnings *[]string, allErrs *field.ErrorList) {
 		IsTopLayer:   true,
 		IsGroupLayer: true,
 		IsDataLayer:  false,
+		Depth:        1,
 		Layer:        wms.Spec.Service.Layer,
 	}
 
@@ -114,6 +135,14 @@ func validateLayer(layer AnnotatedLayer, path *field.Path, groupStyles []string,
 		*layerNames = append(*layerNames, layerName)
 	}
 
+	if layer.Depth == maxLayerDepth && len(layer.Layers) > 0 {
+		*allErrs = append(*allErrs, field.Invalid(
+			path.Child("layers"),
+			layer.GetDepth()+layer.Depth-1,
+			fmt.Sprintf("the layer tree must not be deeper than %d levels", maxLayerDepth),
+		))
+	}
+
 	if layer.IsGroupLayer && layer.Data != nil {
 		*allErrs = append(*allErrs, field.Invalid(
 			path.Child("data"),
@@ -122,6 +151,14 @@ func validateLayer(layer AnnotatedLayer, path *field.Path, groupStyles []string,
 		))
 	}
 
+	if layer.Data != nil {
+		ValidateBaseData(layer.Data.BaseData, path.Child("data"), allErrs)
+	}
+
+	if layer.FeatureInfo != nil {
+		validateFeatureInfo(layer, path.Child("featureInfo"), service.GetFeatureInfoTemplateKeys(), allErrs)
+	}
+
 	validateLayerWithMapfile(layer, path, wms, warnings, allErrs)
 
 	if layer.Visible {
@@ -134,6 +171,9 @@ func validateLayer(layer AnnotatedLayer, path *field.Path, groupStyles []string,
 
 	crsses := []string{}
 	for i, bbox := range layer.BoundingBoxes {
+		if service.Mapfile == nil {
+			ValidateCRS(bbox.CRS, path.Child("boundingBoxes").Index(i).Child("crs"), allErrs)
+		}
 		if slices.Contains(crsses, bbox.CRS) {
 			*allErrs = append(*allErrs, field.Duplicate(
 				path.Child("boundingBoxes").Index(i).Child("crs"),
@@ -147,7 +187,7 @@ func validateLayer(layer AnnotatedLayer, path *field.Path, groupStyles []string,
 	styleNames := []string{}
 	for i, style := range layer.Styles {
 		stylePath := path.Child("styles").Index(i)
-		validateStyle(style, stylePath, &styleNames, &groupStyles, service.StylingAssets.GetAllConfigMapRefKeys(), layer, service.Mapfile != nil, allErrs)
+		validateStyle(style, stylePath, &styleNames, &groupStyles, getStylingFiles(service.StylingAssets), layer, service.Mapfile != nil, allErrs)
 	}
 
 	if layer.IsDataLayer {
@@ -168,6 +208,7 @@ func validateLayer(layer AnnotatedLayer, path *field.Path, groupStyles []string,
 			IsTopLayer:   false,
 			IsGroupLayer: subLayer.IsGroupLayer(),
 			IsDataLayer:  subLayer.IsDataLayer(),
+			Depth:        layer.Depth + 1,
 			Layer:        subLayer,
 		}
 		validateLayer(annotatedSubLayer, path.Child("layers").Index(i), groupStyles, layerNames, hasVisibleLayer, wms, warnings, allErrs)
@@ -202,6 +243,50 @@ func validateLayerWithMapfile(layer AnnotatedLayer, path *field.Path, wms *WMS,
 
 }
 
+func validateFeatureInfo(layer AnnotatedLayer, path *field.Path, templateKeys []string, allErrs *field.ErrorList) {
+	if layer.IsGroupLayer {
+		*allErrs = append(*allErrs, field.Invalid(
+			path,
+			layer.FeatureInfo,
+			"must not be set on a GroupLayer",
+		))
+		return
+	}
+
+	if template := layer.FeatureInfo.Template; template != nil && !slices.Contains(templateKeys, *template) {
+		*allErrs = append(*allErrs, field.Invalid(
+			path.Child("template"),
+			*template,
+			"must be defined in spec.service.featureInfoTemplates.keys",
+		))
+	}
+
+	if len(layer.FeatureInfo.Columns) == 0 || layer.Data == nil {
+		return
+	}
+	if layer.Data.GetColumns() == nil {
+		*allErrs = append(*allErrs, field.Invalid(
+			path.Child("columns"),
+			layer.FeatureInfo.Columns,
+			"can only be set for vector data",
+		))
+		return
+	}
+	columnNames := []string{}
+	for _, column := range *layer.Data.GetColumns() {
+		columnNames = append(columnNames, column.Name)
+	}
+	for i, column := range layer.FeatureInfo.Columns {
+		if !slices.Contains(columnNames, column) {
+			*allErrs = append(*allErrs, field.Invalid(
+				path.Child("columns").Index(i),
+				column,
+				"must be one of the columns of the data",
+			))
+		}
+	}
+}
+
 func tifWarnings(tif *TIF, path *field.Path, wms *WMS, warnings *[]string) {
 	if tif.Resample != "NEAREST" {
 		sharedValidation.AddWarning(
@@ -232,6 +317,22 @@ func tifWarnings(tif *TIF, path *field.Path, wms *WMS, warnings *[]string) {
 			wms.GetName(),
 		)
 	}
+
+	processing := []struct {
+		name  string
+		isSet bool
+	}{{"bands", tif.Bands != nil}, {"noData", tif.NoData != nil}, {"scale", tif.Scale != nil}}
+	for _, setting := range processing {
+		if setting.isSet {
+			sharedValidation.AddWarning(
+				warnings,
+				*path.Child("data").Child("tif").Child(setting.name),
+				"is not used when service.mapfile is configured",
+				wms.GroupVersionKind(),
+				wms.GetName(),
+			)
+		}
+	}
 }
 
 func validateStyle(style Style, path *field.Path, styleNames *[]string, groupStyles *[]string, stylingFiles []string, layer AnnotatedLayer, usesCustomMapfile bool, allErrs *field.ErrorList) {
@@ -252,6 +353,14 @@ func validateStyle(style Style, path *field.Path, styleNames *[]string, groupSty
 		))
 	}
 
+	if style.Legend != nil && !style.Legend.HasMatchingExtension() {
+		*allErrs = append(*allErrs, field.Invalid(
+			path.Child("legend").Child("blobKey"),
+			style.Legend.BlobKey,
+			"the extension must match the format "+style.Legend.GetFormat(),
+		))
+	}
+
 	if layer.IsGroupLayer {
 		if slices.Contains(*groupStyles, style.Name) {
 			*allErrs = append(*allErrs, field.Invalid(
@@ -280,12 +389,12 @@ func validateStyle(style Style, path *field.Path, styleNames *[]string, groupSty
 				style.Visualization,
 				"is not used when spec.service.mapfile is used",
 			))
-		case !usesCustomMapfile && style.Visualization == nil:
+		case !usesCustomMapfile && style.Visualization == nil && style.Colormap == nil:
 			*allErrs = append(*allErrs, field.Required(
 				path.Child("visualization"),
 				"on DataLayers when spec.service.mapfile is not used",
 			))
-		case !usesCustomMapfile && !slices.Contains(stylingFiles, *style.Visualization):
+		case !usesCustomMapfile && style.Visualization != nil && !slices.Contains(stylingFiles, *style.Visualization):
 			*allErrs = append(*allErrs, field.Invalid(
 				path.Child("visualization"),
 				style.Visualization,
@@ -294,6 +403,57 @@ func validateStyle(style Style, path *field.Path, styleNames *[]string, groupSty
 		}
 
 	}
+
+	if style.Colormap != nil {
+		validateColormap(style, path.Child("colormap"), layer, usesCustomMapfile, allErrs)
+	}
+}
+
+func validateColormap(style Style, path *field.Path, layer AnnotatedLayer, usesCustomMapfile bool, allErrs *field.ErrorList) {
+	switch {
+	case usesCustomMapfile:
+		*allErrs = append(*allErrs, field.Invalid(path, style.Colormap, "is not used when spec.service.mapfile is used"))
+		return
+	case !layer.IsDataLayer || layer.Data.TIF == nil:
+		*allErrs = append(*allErrs, field.Invalid(path, style.Colormap, "can only be set on a DataLayer with tif data"))
+		return
+	case style.Visualization != nil:
+		*allErrs = append(*allErrs, field.Invalid(path, style.Colormap, "must not be set together with a visualization"))
+	case len(layer.Data.TIF.Bands) > 1:
+		*allErrs = append(*allErrs, field.Invalid(path, style.Colormap, "requires data.tif.bands to select a single band"))
+	}
+
+	if style.Colormap.Type != ColormapTypeRamp {
+		return
+	}
+	if len(style.Colormap.Entries) < 2 {
+		*allErrs = append(*allErrs, field.Invalid(path.Child("entries"), len(style.Colormap.Entries), "a ramp needs at least 2 entries"))
+		return
+	}
+	for i := 1; i < len(style.Colormap.Entries); i++ {
+		previous, _ := strconv.ParseFloat(style.Colormap.Entries[i-1].Value, 64)
+		current, _ := strconv.ParseFloat(style.Colormap.Entries[i].Value, 64)
+		if current <= previous {
+			*allErrs = append(*allErrs, field.Invalid(
+				path.Child("entries").Index(i).Child("value"),
+				style.Colormap.Entries[i].Value,
+				"the values of a ramp must be ascending",
+			))
+		}
+	}
+}
+
+// getStylingFiles returns the files a visualization can refer to, the ConfigMap keys and the SLD documents on blob storage
+func getStylingFiles(stylingAssets *StylingAssets) []string {
+	stylingFiles := stylingAssets.GetAllConfigMapRefKeys()
+	if stylingAssets != nil {
+		for _, blobKey := range stylingAssets.BlobKeys {
+			if strings.HasSuffix(blobKey, SLDExtension) {
+				stylingFiles = append(stylingFiles, path.Base(blobKey))
+			}
+		}
+	}
+	return stylingFiles
 }
 
 func validateNotVisibleLayer(layer AnnotatedLayer, path *field.Path, wms *WMS, warnings *[]string, allErrs *field.ErrorList) {
@@ -237,6 +237,36 @@ func (in *Authority) DeepCopy() *Authority {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Band) DeepCopyInto(out *Band) {
+	*out = *in
+	if in.Description != nil {
+		in, out := &in.Description, &out.Description
+		*out = new(string)
+		**out = **in
+	}
+	if in.UOM != nil {
+		in, out := &in.UOM, &out.UOM
+		*out = new(string)
+		**out = **in
+	}
+	if in.NilValue != nil {
+		in, out := &in.NilValue, &out.NilValue
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Band.
+func (in *Band) DeepCopy() *Band {
+	if in == nil {
+		return nil
+	}
+	out := new(Band)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BaseData) DeepCopyInto(out *BaseData) {
 	*out = *in
@@ -265,6 +295,11 @@ func (in *BaseData) DeepCopy() *BaseData {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BaseOptions) DeepCopyInto(out *BaseOptions) {
 	*out = *in
+	if in.TopologySpread != nil {
+		in, out := &in.TopologySpread, &out.TopologySpread
+		*out = new(TopologySpread)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BaseOptions.
@@ -325,6 +360,63 @@ func (in *Bbox) DeepCopy() *Bbox {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlobSize) DeepCopyInto(out *BlobSize) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlobSize.
+func (in *BlobSize) DeepCopy() *BlobSize {
+	if in == nil {
+		return nil
+	}
+	out := new(BlobSize)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Colormap) DeepCopyInto(out *Colormap) {
+	*out = *in
+	if in.Entries != nil {
+		in, out := &in.Entries, &out.Entries
+		*out = make([]ColormapEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Colormap.
+func (in *Colormap) DeepCopy() *Colormap {
+	if in == nil {
+		return nil
+	}
+	out := new(Colormap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ColormapEntry) DeepCopyInto(out *ColormapEntry) {
+	*out = *in
+	if in.Label != nil {
+		in, out := &in.Label, &out.Label
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ColormapEntry.
+func (in *ColormapEntry) DeepCopy() *ColormapEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(ColormapEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Column) DeepCopyInto(out *Column) {
 	*out = *in
@@ -333,6 +425,16 @@ func (in *Column) DeepCopyInto(out *Column) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Type != nil {
+		in, out := &in.Type, &out.Type
+		*out = new(string)
+		**out = **in
+	}
+	if in.Nullable != nil {
+		in, out := &in.Nullable, &out.Nullable
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Column.
@@ -365,6 +467,60 @@ func (in *ConfigMapRef) DeepCopy() *ConfigMapRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Coverage) DeepCopyInto(out *Coverage) {
+	*out = *in
+	if in.Keywords != nil {
+		in, out := &in.Keywords, &out.Keywords
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DatasetMetadataURL != nil {
+		in, out := &in.DatasetMetadataURL, &out.DatasetMetadataURL
+		*out = new(MetadataURL)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Bbox != nil {
+		in, out := &in.Bbox, &out.Bbox
+		*out = new(FeatureBbox)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Data.DeepCopyInto(&out.Data)
+	if in.Bands != nil {
+		in, out := &in.Bands, &out.Bands
+		*out = make([]Band, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Coverage.
+func (in *Coverage) DeepCopy() *Coverage {
+	if in == nil {
+		return nil
+	}
+	out := new(Coverage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CoverageData) DeepCopyInto(out *CoverageData) {
+	*out = *in
+	in.TIF.DeepCopyInto(&out.TIF)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CoverageData.
+func (in *CoverageData) DeepCopy() *CoverageData {
+	if in == nil {
+		return nil
+	}
+	out := new(CoverageData)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Custom) DeepCopyInto(out *Custom) {
 	*out = *in
@@ -402,6 +558,37 @@ func (in *Data) DeepCopy() *Data {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataExtent) DeepCopyInto(out *DataExtent) {
+	*out = *in
+	out.BBox = in.BBox
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataExtent.
+func (in *DataExtent) DeepCopy() *DataExtent {
+	if in == nil {
+		return nil
+	}
+	out := new(DataExtent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefaultSort) DeepCopyInto(out *DefaultSort) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultSort.
+func (in *DefaultSort) DeepCopy() *DefaultSort {
+	if in == nil {
+		return nil
+	}
+	out := new(DefaultSort)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FeatureBbox) DeepCopyInto(out *FeatureBbox) {
 	*out = *in
@@ -427,6 +614,56 @@ func (in *FeatureBbox) DeepCopy() *FeatureBbox {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FeatureInfo) DeepCopyInto(out *FeatureInfo) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Columns != nil {
+		in, out := &in.Columns, &out.Columns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Template != nil {
+		in, out := &in.Template, &out.Template
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FeatureInfo.
+func (in *FeatureInfo) DeepCopy() *FeatureInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(FeatureInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FeatureInfoTemplatesRef) DeepCopyInto(out *FeatureInfoTemplatesRef) {
+	*out = *in
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FeatureInfoTemplatesRef.
+func (in *FeatureInfoTemplatesRef) DeepCopy() *FeatureInfoTemplatesRef {
+	if in == nil {
+		return nil
+	}
+	out := new(FeatureInfoTemplatesRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FeatureType) DeepCopyInto(out *FeatureType) {
 	*out = *in
@@ -445,6 +682,26 @@ func (in *FeatureType) DeepCopyInto(out *FeatureType) {
 		*out = new(FeatureBbox)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.OutputFormats != nil {
+		in, out := &in.OutputFormats, &out.OutputFormats
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DefaultCrs != nil {
+		in, out := &in.DefaultCrs, &out.DefaultCrs
+		*out = new(string)
+		**out = **in
+	}
+	if in.OtherCrs != nil {
+		in, out := &in.OtherCrs, &out.OtherCrs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CountDefault != nil {
+		in, out := &in.CountDefault, &out.CountDefault
+		*out = new(int)
+		**out = **in
+	}
 	in.Data.DeepCopyInto(&out.Data)
 }
 
@@ -468,6 +725,31 @@ func (in *Gpkg) DeepCopyInto(out *Gpkg) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Filter != nil {
+		in, out := &in.Filter, &out.Filter
+		*out = new(string)
+		**out = **in
+	}
+	if in.SQLView != nil {
+		in, out := &in.SQLView, &out.SQLView
+		*out = new(string)
+		**out = **in
+	}
+	if in.PrimaryKey != nil {
+		in, out := &in.PrimaryKey, &out.PrimaryKey
+		*out = new(string)
+		**out = **in
+	}
+	if in.DefaultSort != nil {
+		in, out := &in.DefaultSort, &out.DefaultSort
+		*out = new(DefaultSort)
+		**out = **in
+	}
+	if in.Prefetch != nil {
+		in, out := &in.Prefetch, &out.Prefetch
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Gpkg.
@@ -480,6 +762,21 @@ func (in *Gpkg) DeepCopy() *Gpkg {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthCheckWCS) DeepCopyInto(out *HealthCheckWCS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheckWCS.
+func (in *HealthCheckWCS) DeepCopy() *HealthCheckWCS {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthCheckWCS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HealthCheckWFS) DeepCopyInto(out *HealthCheckWFS) {
 	*out = *in
@@ -633,6 +930,16 @@ func (in *Layer) DeepCopyInto(out *Layer) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Queryable != nil {
+		in, out := &in.Queryable, &out.Queryable
+		*out = new(bool)
+		**out = **in
+	}
+	if in.FeatureInfo != nil {
+		in, out := &in.FeatureInfo, &out.FeatureInfo
+		*out = new(FeatureInfo)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Data != nil {
 		in, out := &in.Data, &out.Data
 		*out = new(Data)
@@ -731,7 +1038,7 @@ func (in *MetadataURL) DeepCopy() *MetadataURL {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Options) DeepCopyInto(out *Options) {
 	*out = *in
-	out.BaseOptions = in.BaseOptions
+	in.BaseOptions.DeepCopyInto(&out.BaseOptions)
 	out.WMSOptions = in.WMSOptions
 }
 
@@ -755,6 +1062,26 @@ func (in *Postgis) DeepCopyInto(out *Postgis) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Filter != nil {
+		in, out := &in.Filter, &out.Filter
+		*out = new(string)
+		**out = **in
+	}
+	if in.SQLView != nil {
+		in, out := &in.SQLView, &out.SQLView
+		*out = new(string)
+		**out = **in
+	}
+	if in.PrimaryKey != nil {
+		in, out := &in.PrimaryKey, &out.PrimaryKey
+		*out = new(string)
+		**out = **in
+	}
+	if in.DefaultSort != nil {
+		in, out := &in.DefaultSort, &out.DefaultSort
+		*out = new(DefaultSort)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Postgis.
@@ -767,6 +1094,84 @@ func (in *Postgis) DeepCopy() *Postgis {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Status) DeepCopyInto(out *Status) {
+	*out = *in
+	in.OperatorStatus.DeepCopyInto(&out.OperatorStatus)
+	if in.Extents != nil {
+		in, out := &in.Extents, &out.Extents
+		*out = make([]DataExtent, len(*in))
+		copy(*out, *in)
+	}
+	if in.Blobs != nil {
+		in, out := &in.Blobs, &out.Blobs
+		*out = make([]BlobSize, len(*in))
+		copy(*out, *in)
+	}
+	if in.EphemeralStorage != nil {
+		in, out := &in.EphemeralStorage, &out.EphemeralStorage
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Status.
+func (in *Status) DeepCopy() *Status {
+	if in == nil {
+		return nil
+	}
+	out := new(Status)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StoredQuery) DeepCopyInto(out *StoredQuery) {
+	*out = *in
+	if in.Abstract != nil {
+		in, out := &in.Abstract, &out.Abstract
+		*out = new(string)
+		**out = **in
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]StoredQueryParameter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StoredQuery.
+func (in *StoredQuery) DeepCopy() *StoredQuery {
+	if in == nil {
+		return nil
+	}
+	out := new(StoredQuery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StoredQueryParameter) DeepCopyInto(out *StoredQueryParameter) {
+	*out = *in
+	if in.Title != nil {
+		in, out := &in.Title, &out.Title
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StoredQueryParameter.
+func (in *StoredQueryParameter) DeepCopy() *StoredQueryParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(StoredQueryParameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Style) DeepCopyInto(out *Style) {
 	*out = *in
@@ -785,6 +1190,11 @@ func (in *Style) DeepCopyInto(out *Style) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Colormap != nil {
+		in, out := &in.Colormap, &out.Colormap
+		*out = new(Colormap)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Legend != nil {
 		in, out := &in.Legend, &out.Legend
 		*out = new(Legend)
@@ -837,6 +1247,26 @@ func (in *TIF) DeepCopyInto(out *TIF) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Bands != nil {
+		in, out := &in.Bands, &out.Bands
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.NoData != nil {
+		in, out := &in.NoData, &out.NoData
+		*out = new(string)
+		**out = **in
+	}
+	if in.Scale != nil {
+		in, out := &in.Scale, &out.Scale
+		*out = new(string)
+		**out = **in
+	}
+	if in.Prefetch != nil {
+		in, out := &in.Prefetch, &out.Prefetch
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TIF.
@@ -849,6 +1279,167 @@ func (in *TIF) DeepCopy() *TIF {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologySpread) DeepCopyInto(out *TopologySpread) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologySpread.
+func (in *TopologySpread) DeepCopy() *TopologySpread {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologySpread)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WCS) DeepCopyInto(out *WCS) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WCS.
+func (in *WCS) DeepCopy() *WCS {
+	if in == nil {
+		return nil
+	}
+	out := new(WCS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WCS) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WCSList) DeepCopyInto(out *WCSList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WCS, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WCSList.
+func (in *WCSList) DeepCopy() *WCSList {
+	if in == nil {
+		return nil
+	}
+	out := new(WCSList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WCSList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WCSService) DeepCopyInto(out *WCSService) {
+	*out = *in
+	in.BaseService.DeepCopyInto(&out.BaseService)
+	if in.Inspire != nil {
+		in, out := &in.Inspire, &out.Inspire
+		*out = new(WFSInspire)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OtherCrs != nil {
+		in, out := &in.OtherCrs, &out.OtherCrs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Bbox != nil {
+		in, out := &in.Bbox, &out.Bbox
+		*out = new(Bbox)
+		**out = **in
+	}
+	if in.OutputFormats != nil {
+		in, out := &in.OutputFormats, &out.OutputFormats
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Coverages != nil {
+		in, out := &in.Coverages, &out.Coverages
+		*out = make([]Coverage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WCSService.
+func (in *WCSService) DeepCopy() *WCSService {
+	if in == nil {
+		return nil
+	}
+	out := new(WCSService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WCSSpec) DeepCopyInto(out *WCSSpec) {
+	*out = *in
+	if in.Lifecycle != nil {
+		in, out := &in.Lifecycle, &out.Lifecycle
+		*out = new(model.Lifecycle)
+		(*in).DeepCopyInto(*out)
+	}
+	in.PodSpecPatch.DeepCopyInto(&out.PodSpecPatch)
+	if in.HorizontalPodAutoscalerPatch != nil {
+		in, out := &in.HorizontalPodAutoscalerPatch, &out.HorizontalPodAutoscalerPatch
+		*out = new(HorizontalPodAutoscalerPatch)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = new(BaseOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(HealthCheckWCS)
+		**out = **in
+	}
+	if in.IngressRouteURLs != nil {
+		in, out := &in.IngressRouteURLs, &out.IngressRouteURLs
+		*out = make(model.IngressRouteURLs, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Service.DeepCopyInto(&out.Service)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WCSSpec.
+func (in *WCSSpec) DeepCopy() *WCSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WCSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WFS) DeepCopyInto(out *WFS) {
 	*out = *in
@@ -924,6 +1515,22 @@ func (in *WFSList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WFSOptions) DeepCopyInto(out *WFSOptions) {
+	*out = *in
+	in.BaseOptions.DeepCopyInto(&out.BaseOptions)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WFSOptions.
+func (in *WFSOptions) DeepCopy() *WFSOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(WFSOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WFSService) DeepCopyInto(out *WFSService) {
 	*out = *in
@@ -948,6 +1555,18 @@ func (in *WFSService) DeepCopyInto(out *WFSService) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.OutputFormats != nil {
+		in, out := &in.OutputFormats, &out.OutputFormats
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StoredQueries != nil {
+		in, out := &in.StoredQueries, &out.StoredQueries
+		*out = make([]StoredQuery, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.FeatureTypes != nil {
 		in, out := &in.FeatureTypes, &out.FeatureTypes
 		*out = make([]FeatureType, len(*in))
@@ -983,8 +1602,8 @@ func (in *WFSSpec) DeepCopyInto(out *WFSSpec) {
 	}
 	if in.Options != nil {
 		in, out := &in.Options, &out.Options
-		*out = new(BaseOptions)
-		**out = **in
+		*out = new(WFSOptions)
+		(*in).DeepCopyInto(*out)
 	}
 	if in.HealthCheck != nil {
 		in, out := &in.HealthCheck, &out.HealthCheck
@@ -1130,6 +1749,13 @@ func (in *WMSService) DeepCopyInto(out *WMSService) {
 		*out = new(StylingAssets)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.FeatureInfoTemplates != nil {
+		in, out := &in.FeatureInfoTemplates, &out.FeatureInfoTemplates
+		*out = make([]FeatureInfoTemplatesRef, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Mapfile != nil {
 		in, out := &in.Mapfile, &out.Mapfile
 		*out = new(Mapfile)
@@ -1165,7 +1791,7 @@ func (in *WMSSpec) DeepCopyInto(out *WMSSpec) {
 	if in.Options != nil {
 		in, out := &in.Options, &out.Options
 		*out = new(Options)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.HealthCheck != nil {
 		in, out := &in.HealthCheck, &out.HealthCheck
@@ -0,0 +1,59 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The legend-generator generates the legends of a WMS, it runs as init container in an image with mapserv.
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/pdok/mapserver-operator/internal/legendtool"
+	"sigs.k8s.io/yaml"
+)
+
+func main() {
+	var inputPath, outputDir, mapservBinary string
+	var parallelism int
+	flag.StringVar(&inputPath, "input", "/input/input.yaml", "The YAML file with the legends to generate.")
+	flag.StringVar(&outputDir, "output", "/var/www/legend", "The directory the legends are written to.")
+	flag.StringVar(&mapservBinary, "mapserv", "mapserv", "The mapserv binary.")
+	flag.IntVar(&parallelism, "parallelism", 2, "The number of legends that are generated at the same time.")
+	flag.Parse()
+
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		slog.Error("unable to read input", "error", err)
+		os.Exit(1)
+	}
+	input := legendtool.Input{}
+	if err = yaml.Unmarshal(data, &input); err != nil {
+		slog.Error("unable to parse input", "error", err)
+		os.Exit(1)
+	}
+
+	generator := legendtool.Generator{
+		OutputDir:   outputDir,
+		Parallelism: parallelism,
+		Mapserv:     legendtool.NewMapserv(mapservBinary),
+	}
+	if err = generator.Run(context.Background(), input); err != nil {
+		slog.Error("unable to generate all legends", "error", err)
+		os.Exit(1)
+	}
+}
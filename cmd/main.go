@@ -17,12 +17,19 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/pdok/mapserver-operator/internal/controller/blobmetadata"
 	"github.com/pdok/mapserver-operator/internal/controller/types"
+	"github.com/pdok/mapserver-operator/internal/crs"
+	"github.com/pdok/mapserver-operator/internal/tracing"
 
 	"github.com/go-logr/zapr"
 	"github.com/pdok/smooth-operator/pkg/integrations/logging"
@@ -80,11 +87,24 @@ func main() {
 	var tlsOpts []func(*tls.Config)
 	var host string
 	var mapserverDebugLevel int
-	var multitoolImage, mapfileGeneratorImage, mapserverImage, capabilitiesGeneratorImage, featureinfoGeneratorImage, ogcWebserviceProxyImage, apacheExporterImage string
+	var multitoolImage, mapfileGeneratorImage, mapserverImage, capabilitiesGeneratorImage, featureinfoGeneratorImage, ogcWebserviceProxyImage, apacheExporterImage, legendGeneratorImage string
 	var slackWebhookURL string
 	var logLevel int
 	var setUptimeOperatorAnnotations bool
 	var storageClassName string
+	var blobsEndpoint string
+	var blobsMeasureInterval time.Duration
+	var ephemeralStorageHeadroom int64
+	var otlpEndpoint string
+	var otlpInsecure bool
+	var traceSampleRatio float64
+	var crsRegistryFile string
+	var maxWMSLayerDepth int
+	var networkPolicyConfig types.NetworkPolicyConfig
+	var networkPolicyBlobStorageCIDRs, networkPolicyPostgisCIDRs string
+	var networkPolicyBlobStoragePort, networkPolicyPostgisPort int
+	var dataCacheConfig types.DataCacheConfig
+	var dataCacheRefreshInterval int
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -104,11 +124,33 @@ func main() {
 	flag.StringVar(&featureinfoGeneratorImage, "featureinfo-generator-image", "", "The image to use in the featureinfo generator init-container.")
 	flag.StringVar(&ogcWebserviceProxyImage, "ogc-webservice-proxy-image", "", "The image to use in the ogc webservice proxy container.")
 	flag.StringVar(&apacheExporterImage, "apache-exporter-image", "", "The image to use in the apache-exporter container.")
+	flag.StringVar(&legendGeneratorImage, "legend-generator-image", "", "The image to use in the legend generator init-container, the mapserver image with the legend-generator added (legend-generator.Dockerfile).")
 	flag.IntVar(&mapserverDebugLevel, "mapserver-debug-level", 0, "Debug level for the mapserver container, between 0 (error only) and 5 (very very verbose).")
 	flag.StringVar(&slackWebhookURL, "slack-webhook-url", "", "The webhook url for sending slack messages. Disabled if left empty")
 	flag.IntVar(&logLevel, "log-level", 0, "The zapcore loglevel. 0 = info, 1 = warn, 2 = error")
 	flag.BoolVar(&setUptimeOperatorAnnotations, "set-uptime-operator-annotations", true, "When enabled IngressRoutes get annotations that are used by the pdok/uptime-operator.")
 	flag.StringVar(&storageClassName, "storage-class-name", "", "The name of the storage class to use when using an ephemeral volume.")
+	flag.StringVar(&blobsEndpoint, "blobs-endpoint", "", "The blob storage endpoint the operator reads the blob sizes from for options.autoEphemeralStorage and prefetch auto, authenticated with the BLOBS_ACCOUNT and BLOBS_KEY environment variables. The sizes are read by the blob-download of the pods if left empty.")
+	flag.DurationVar(&blobsMeasureInterval, "blobs-measure-interval", time.Hour, "How long the blob sizes read from the blobs-endpoint are kept before they are read again, they are also read again when the blobs of a resource change.")
+	flag.Int64Var(&ephemeralStorageHeadroom, "ephemeral-storage-headroom", 25, "The percentage that is added to the size of the prefetched blobs for options.autoEphemeralStorage.")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", "", "The OTLP gRPC endpoint (host:port) to export traces to. Tracing is disabled if left empty.")
+	flag.BoolVar(&otlpInsecure, "otlp-insecure", false, "If set, traces are exported to the OTLP endpoint without TLS.")
+	flag.Float64Var(&traceSampleRatio, "trace-sample-ratio", 1, "The fraction of traces to sample, between 0 and 1.")
+	flag.BoolVar(&networkPolicyConfig.Enabled, "enable-network-policy", false, "When enabled a NetworkPolicy is created for every WMS/WFS.")
+	flag.StringVar(&networkPolicyConfig.IngressNamespace, "network-policy-ingress-namespace", "traefik", "The namespace of the ingress controller that is allowed to reach the webservice.")
+	flag.StringVar(&networkPolicyConfig.MonitoringNamespace, "network-policy-monitoring-namespace", "monitoring", "The namespace that is allowed to scrape the metrics port.")
+	flag.StringVar(&networkPolicyBlobStorageCIDRs, "network-policy-blob-storage-cidrs", "", "Comma separated CIDRs of the blob storage. All destinations are allowed if left empty.")
+	flag.IntVar(&networkPolicyBlobStoragePort, "network-policy-blob-storage-port", 443, "The port of the blob storage.")
+	flag.StringVar(&networkPolicyPostgisCIDRs, "network-policy-postgis-cidrs", "", "Comma separated CIDRs of the PostGIS databases. All destinations are allowed if left empty.")
+	flag.IntVar(&networkPolicyPostgisPort, "network-policy-postgis-port", 5432, "The port of the PostGIS databases.")
+	flag.BoolVar(&dataCacheConfig.Enabled, "enable-data-cache", false, "When enabled a DaemonSet keeps the blobs of webservices with options.useDataCache on every node.")
+	flag.StringVar(&dataCacheConfig.Namespace, "data-cache-namespace", "mapserver-operator-system", "The namespace of the data cache DaemonSet.")
+	flag.StringVar(&dataCacheConfig.HostPath, "data-cache-host-path", "/var/lib/mapserver-operator/data-cache", "The directory on the nodes that holds the data cache.")
+	flag.StringVar(&dataCacheConfig.BlobsConfigMapName, "data-cache-blobs-configmap", "blobs", "The ConfigMap in the data cache namespace with the blob storage configuration.")
+	flag.StringVar(&dataCacheConfig.BlobsSecretName, "data-cache-blobs-secret", "blobs", "The Secret in the data cache namespace with the blob storage credentials.")
+	flag.IntVar(&dataCacheRefreshInterval, "data-cache-refresh-interval", 60, "The number of seconds between two checks of the data cache for new or changed blobs.")
+	flag.IntVar(&maxWMSLayerDepth, "max-wms-layer-depth", pdoknlv3.MaxLayerDepth, fmt.Sprintf("The maximum number of levels of a WMS layer tree (including the toplayer), between 2 and %d.", pdoknlv3.MaxLayerDepth))
+	flag.StringVar(&crsRegistryFile, "crs-registry", "", "The YAML file (e.g. mounted from a ConfigMap) with the supported CRSs, their default bboxes and axis order. The built-in registry is used if left empty.")
 
 	opts := zap.Options{
 		Development: true,
@@ -135,6 +177,7 @@ func main() {
 	reqFlags["featureinfo-generator-image"] = featureinfoGeneratorImage
 	reqFlags["ogc-webservice-proxy-image"] = ogcWebserviceProxyImage
 	reqFlags["apache-exporter-image"] = apacheExporterImage
+	reqFlags["legend-generator-image"] = legendGeneratorImage
 
 	for reqFlag, val := range reqFlags {
 		if val == "" {
@@ -143,10 +186,50 @@ func main() {
 		}
 	}
 
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Endpoint:    otlpEndpoint,
+		Insecure:    otlpInsecure,
+		SampleRatio: traceSampleRatio,
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
+	if err := crs.LoadRegistry(crsRegistryFile); err != nil {
+		setupLog.Error(err, "unable to load the CRS registry")
+		os.Exit(1)
+	}
+
 	pdoknlv3.SetHost(host)
+	if err := pdoknlv3.SetMaxLayerDepth(maxWMSLayerDepth); err != nil {
+		setupLog.Error(err, "invalid max-wms-layer-depth")
+		os.Exit(1)
+	}
 	mapfilegenerator.SetDebugLevel(mapserverDebugLevel)
 	controller.SetUptimeOperatorAnnotations(setUptimeOperatorAnnotations)
 	controller.SetStorageClassName(storageClassName)
+	controller.SetEphemeralStorageHeadroom(ephemeralStorageHeadroom)
+	if blobsEndpoint != "" {
+		blobMetadataClient, err := blobmetadata.NewHTTPClient(blobsEndpoint, os.Getenv("BLOBS_ACCOUNT"), os.Getenv("BLOBS_KEY"))
+		if err != nil {
+			setupLog.Error(err, "unable to create the blob metadata client")
+			os.Exit(1)
+		}
+		controller.SetBlobMetadataClient(blobMetadataClient, blobsMeasureInterval)
+	}
+
+	//nolint:gosec
+	networkPolicyConfig.BlobStoragePort = int32(networkPolicyBlobStoragePort)
+	//nolint:gosec
+	networkPolicyConfig.PostgisPort = int32(networkPolicyPostgisPort)
+	networkPolicyConfig.BlobStorageCIDRs = splitCommaSeparated(networkPolicyBlobStorageCIDRs)
+	networkPolicyConfig.PostgisCIDRs = splitCommaSeparated(networkPolicyPostgisCIDRs)
+	controller.SetNetworkPolicyConfig(networkPolicyConfig)
+
+	//nolint:gosec
+	dataCacheConfig.RefreshIntervalSecs = int32(dataCacheRefreshInterval)
+	controller.SetDataCacheConfig(dataCacheConfig)
 
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
@@ -207,6 +290,7 @@ func main() {
 			FeatureinfoGeneratorImage:  featureinfoGeneratorImage,
 			OgcWebserviceProxyImage:    ogcWebserviceProxyImage,
 			ApacheExporterImage:        apacheExporterImage,
+			LegendGeneratorImage:       legendGeneratorImage,
 		},
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "WMS")
@@ -226,6 +310,35 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "WFS")
 		os.Exit(1)
 	}
+	if err = (&controller.WCSReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Images: types.Images{
+			MultitoolImage:             multitoolImage,
+			MapfileGeneratorImage:      mapfileGeneratorImage,
+			MapserverImage:             mapserverImage,
+			CapabilitiesGeneratorImage: capabilitiesGeneratorImage,
+			ApacheExporterImage:        apacheExporterImage,
+		},
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "WCS")
+		os.Exit(1)
+	}
+	if err = (&controller.DataCacheReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Images: types.Images{
+			MultitoolImage: multitoolImage,
+		},
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DataCache")
+		os.Exit(1)
+	}
+
+	if os.Getenv("ENABLE_WEBHOOKS") != EnvFalse {
+		// Before the other webhooks, so the conversions are traced
+		webhookpdoknlv3.SetupConversionWebhookWithManager(mgr)
+	}
 
 	if os.Getenv("ENABLE_WEBHOOKS") != EnvFalse {
 		if err = webhookpdoknlv3.SetupWFSWebhookWithManager(mgr); err != nil {
@@ -241,6 +354,13 @@ func main() {
 		}
 	}
 
+	if os.Getenv("ENABLE_WEBHOOKS") != EnvFalse {
+		if err = webhookpdoknlv3.SetupWCSWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "WCS")
+			os.Exit(1)
+		}
+	}
+
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -255,6 +375,19 @@ func main() {
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
+		_ = shutdownTracing(context.Background())
 		os.Exit(1)
 	}
+	if err := shutdownTracing(context.Background()); err != nil {
+		setupLog.Error(err, "problem shutting down tracing")
+	}
+}
+
+func splitCommaSeparated(value string) (result []string) {
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
 }
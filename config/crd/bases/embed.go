@@ -14,6 +14,9 @@ var wfsCRD []byte
 //go:embed pdok.nl_wms.yaml
 var wmsCRD []byte
 
+//go:embed pdok.nl_wcs.yaml
+var wcsCRD []byte
+
 func init() {
 	wms, err := GetWmsCRD()
 	if err != nil {
@@ -34,6 +37,16 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+
+	wcs, err := GetWcsCRD()
+	if err != nil {
+		panic(err)
+	}
+
+	err = validation.AddValidator(wcs)
+	if err != nil {
+		panic(err)
+	}
 }
 
 func GetWmsCRD() (v1.CustomResourceDefinition, error) {
@@ -49,3 +62,10 @@ func GetWfsCRD() (v1.CustomResourceDefinition, error) {
 
 	return crd, err
 }
+
+func GetWcsCRD() (v1.CustomResourceDefinition, error) {
+	crd := v1.CustomResourceDefinition{}
+	err := yaml.Unmarshal(wcsCRD, &crd)
+
+	return crd, err
+}
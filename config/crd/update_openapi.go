@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
 	"github.com/pkg/errors"
 	goyaml "gopkg.in/yaml.v3"
 	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -17,6 +18,7 @@ func main() {
 
 	updateWMSV3(crdDir)
 	updateWFSV3(crdDir)
+	updateWCSV3(crdDir)
 }
 
 func updateWMSV3(crdDir string) {
@@ -69,27 +71,30 @@ func updateLayersV3(version *v1.CustomResourceDefinitionVersion) {
 	service := spec.Properties["service"]
 	layer := service.Properties["layer"]
 
-	// Level 3
-	layerSpecLevel3 := layer.DeepCopy()
-	layerSpecLevel3.Required = append(layerSpecLevel3.Required, "name")
-	layerSpecLevel3.Required = append(layerSpecLevel3.Required, "styles")
-	delete(layerSpecLevel3.Properties, "layers")
+	// Deepest level, without sublayers
+	layerSpec := layer.DeepCopy()
+	layerSpec.Required = append(layerSpec.Required, "name")
+	layerSpec.Required = append(layerSpec.Required, "styles")
+	delete(layerSpec.Properties, "layers")
 	xvals := v1.ValidationRules{}
-	for _, xval := range layerSpecLevel3.XValidations {
+	for _, xval := range layerSpec.XValidations {
 		if !strings.Contains(xval.Rule, "self.layers") {
 			xvals = append(xvals, xval)
 		}
 	}
-	layerSpecLevel3.XValidations = xvals
-
-	// Level 2
-	layerSpecLevel2 := layer.DeepCopy()
-	layerSpecLevel2.Required = append(layerSpecLevel2.Required, "name")
-	layerSpecLevel2.Required = append(layerSpecLevel2.Required, "styles")
-	bottomLayers := layerSpecLevel2.Properties["layers"]
-	bottomLayers.Description = "[OpenAPI spec injected by mapserver-operator/cmd/update_openapi.go]"
-	bottomLayers.Items = &v1.JSONSchemaPropsOrArray{Schema: layerSpecLevel3}
-	layerSpecLevel2.Properties["layers"] = bottomLayers
+	layerSpec.XValidations = xvals
+
+	// Levels between the toplayer and the deepest level
+	for level := pdoknlv3.MaxLayerDepth - 1; level >= 2; level-- {
+		parentLayerSpec := layer.DeepCopy()
+		parentLayerSpec.Required = append(parentLayerSpec.Required, "name")
+		parentLayerSpec.Required = append(parentLayerSpec.Required, "styles")
+		subLayers := parentLayerSpec.Properties["layers"]
+		subLayers.Description = "[OpenAPI spec injected by mapserver-operator/cmd/update_openapi.go]"
+		subLayers.Items = &v1.JSONSchemaPropsOrArray{Schema: layerSpec}
+		parentLayerSpec.Properties["layers"] = subLayers
+		layerSpec = parentLayerSpec
+	}
 
 	// Level 1
 	layerSpecLevel1 := layer.DeepCopy()
@@ -101,11 +106,11 @@ func updateLayersV3(version *v1.CustomResourceDefinitionVersion) {
 	delete(layerSpecLevel1.Properties, "data")
 	delete(layerSpecLevel1.Properties, "labelNoClip")
 
-	midLayers := layerSpecLevel1.Properties["layers"]
-	midLayers.Description = "[OpenAPI spec injected by mapserver-operator/cmd/update_openapi.go]"
-	midLayers.Items = &v1.JSONSchemaPropsOrArray{Schema: layerSpecLevel2}
+	subLayers := layerSpecLevel1.Properties["layers"]
+	subLayers.Description = "[OpenAPI spec injected by mapserver-operator/cmd/update_openapi.go]"
+	subLayers.Items = &v1.JSONSchemaPropsOrArray{Schema: layerSpec}
 
-	layerSpecLevel1.Properties["layers"] = midLayers
+	layerSpecLevel1.Properties["layers"] = subLayers
 
 	service.Properties["layer"] = *layerSpecLevel1
 	spec.Properties["service"] = service
@@ -158,6 +163,49 @@ func updateWFSV3(crdDir string) {
 	_ = enc.Encode(rawData)
 }
 
+func updateWCSV3(crdDir string) {
+	path := filepath.Join(crdDir, "pdok.nl_wcs.yaml")
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		panic(errors.Wrap(err, "WCS v3 manifest not found"))
+	}
+
+	content, _ := os.ReadFile(path)
+	crd := &v1.CustomResourceDefinition{}
+	err := kyaml.Unmarshal(content, &crd)
+	if err != nil {
+		panic(err)
+	}
+
+	versions := make([]v1.CustomResourceDefinitionVersion, 0)
+	for _, version := range crd.Spec.Versions {
+		if version.Name == "v3" {
+			updateMapfileV3(&version)
+
+			versions = append(versions, version)
+		} else {
+			versions = append(versions, version)
+		}
+	}
+
+	crd.Spec.Versions = versions
+	updatedContent, _ := kyaml.Marshal(crd)
+
+	// Remove the 'status' field from the yaml
+	var rawData map[string]interface{}
+	_ = goyaml.Unmarshal(updatedContent, &rawData)
+	delete(rawData, "status")
+
+	f, _ := os.OpenFile(path, os.O_TRUNC|os.O_WRONLY, 0644)
+	defer f.Close()
+
+	enc := goyaml.NewEncoder(f)
+	defer enc.Close()
+
+	enc.SetIndent(2)
+	_ = enc.Encode(rawData)
+}
+
 func updateMapfileV3(version *v1.CustomResourceDefinitionVersion) {
 	schema := version.Schema.OpenAPIV3Schema
 	spec := schema.Properties["spec"]
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/autoextent"
+	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// updateExtents copies the extents read by the auto-extent init container of the newest pod into the status.
+// A changed extent changes the generator inputs, which rolls out pods that use it.
+func updateExtents[R Reconciler, O pdoknlv3.WMSWFS](ctx context.Context, r R, obj O) error {
+	status := obj.ServiceStatus()
+	if !autoextent.UseAutoExtent(obj) {
+		if len(status.Extents) == 0 {
+			return nil
+		}
+		status.Extents = nil
+		return r.Status().Update(ctx, any(obj).(client.Object))
+	}
+
+	reconcilerClient := getReconcilerClient(r)
+	labels := addCommonLabels(obj, smoothoperatorutils.CloneOrEmptyMap(obj.GetLabels()))
+	podList := &corev1.PodList{}
+	if err := reconcilerClient.List(ctx, podList, client.InNamespace(obj.GetNamespace()), client.MatchingLabels(labels)); err != nil {
+		return fmt.Errorf("unable to list pods: %w", err)
+	}
+
+	extents := autoextent.GetExtents(obj, podList.Items)
+	if extents == nil || equality.Semantic.DeepEqual(extents, status.Extents) {
+		return nil
+	}
+	status.Extents = extents
+	return r.Status().Update(ctx, any(obj).(client.Object))
+}
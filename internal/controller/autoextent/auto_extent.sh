@@ -0,0 +1,108 @@
+#!/usr/bin/env bash
+
+# Reads the extent of the data of every layer, featureType or coverage in AUTO_EXTENT_SOURCES, one
+# "<name> <gpkg|tif|postgis> <source> <table>" per line. The extents are written to the termination message, one
+# "<name> <epsg code> <minx> <miny> <maxx> <maxy>" per line, from where the operator copies them into the status.
+# The epsg code is "-" when it can't be determined. A source that can't be read is skipped, it keeps the default extent.
+# Kubernetes truncates the termination message at 4096 bytes, so the coordinates are rounded outwards and the extents
+# that don't fit anymore are skipped.
+
+set -uo pipefail
+
+MESSAGE_PATH=${AUTO_EXTENT_MESSAGE_PATH:-/dev/termination-log}
+MAX_MESSAGE_SIZE=4096
+
+function log() {
+    echo msg=\""$1"\" "${@:2}"
+}
+
+# Prints the EPSG code of the CRS in the (WKT1 or WKT2) output of ogrinfo or gdalinfo, the last authority is the one of the CRS itself
+function epsg() {
+    local code
+    code=$(grep -oE '(ID\["EPSG",|AUTHORITY\["EPSG",")[0-9]+' | tail -n 1 | grep -oE '[0-9]+$')
+    if [ -n "$code" ]; then
+        echo "EPSG:$code"
+    else
+        echo "-"
+    fi
+}
+
+# Prints "minx miny maxx maxy" of a vector table, from the "Extent: (minx, miny) - (maxx, maxy)" line of ogrinfo
+function vector_extent() {
+    sed -nE 's/^Extent[^:]*: \(([^,]+), ([^)]+)\) - \(([^,]+), ([^)]+)\)$/\1 \2 \3 \4/p' | head -n 1
+}
+
+# Prints "minx miny maxx maxy" of a raster, from the "Lower Left" and "Upper Right" corners of gdalinfo
+function raster_extent() {
+    local info=$1
+    local lower_left upper_right
+    lower_left=$(echo "$info" | sed -nE 's/^Lower Left *\( *([^,]+), *([^)]+)\).*$/\1 \2/p')
+    upper_right=$(echo "$info" | sed -nE 's/^Upper Right *\( *([^,]+), *([^)]+)\).*$/\1 \2/p')
+    if [ -n "$lower_left" ] && [ -n "$upper_right" ]; then
+        echo "$lower_left $upper_right"
+    fi
+}
+
+# Rounds "minx miny maxx maxy" outwards, to whole units for projected coordinates and 6 decimals for degrees
+function compact_extent() {
+    awk '
+        function round(value, direction,    scale, rounded, result) {
+            scale = (value >= 1000 || value <= -1000) ? 1 : 1000000
+            rounded = int(value * scale)
+            if (direction < 0 && rounded > value * scale) rounded--
+            if (direction > 0 && rounded < value * scale) rounded++
+            result = sprintf("%.6f", rounded / scale)
+            sub(/0+$/, "", result)
+            sub(/\.$/, "", result)
+            return result
+        }
+        { print round($1, -1), round($2, -1), round($3, 1), round($4, 1) }
+    '
+}
+
+function read_extent() {
+    local type=$1
+    local source=$2
+    local table=$3
+
+    local info
+    case $type in
+        tif)
+            info=$(gdalinfo "$source") || return 1
+            extent=$(raster_extent "$info")
+            ;;
+        gpkg|postgis)
+            info=$(ogrinfo -ro -so "$source" "$table") || return 1
+            extent=$(echo "$info" | vector_extent)
+            ;;
+        *)
+            return 1
+            ;;
+    esac
+    crs=$(echo "$info" | epsg)
+    [ -n "$extent" ] || return 1
+    extent=$(echo "$extent" | compact_extent)
+}
+
+function main() {
+    : > "$MESSAGE_PATH"
+    while read -r name type source table; do
+        [ -n "$name" ] || continue
+
+        local crs extent line size
+        if read_extent "$type" "$source" "${table:-}"; then
+            log "Read extent" name=\""$name"\" crs=\""$crs"\" extent=\""$extent"\"
+            line="$name ${crs#EPSG:} $extent"
+            size=$(wc -c < "$MESSAGE_PATH")
+            if (( size + ${#line} + 1 > MAX_MESSAGE_SIZE )); then
+                log "Termination message is full, skipping" name=\""$name"\"
+                continue
+            fi
+            echo "$line" >> "$MESSAGE_PATH"
+        else
+            log "Unable to read extent, skipping" name=\""$name"\" source=\""$source"\"
+        fi
+    done <<< "$AUTO_EXTENT_SOURCES"
+}
+
+main
@@ -0,0 +1,347 @@
+package autoextent
+
+import (
+	_ "embed"
+	"slices"
+	"strconv"
+	"strings"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/constants"
+	"github.com/pdok/mapserver-operator/internal/controller/datacache"
+	"github.com/pdok/mapserver-operator/internal/controller/mapfilegenerator"
+	"github.com/pdok/mapserver-operator/internal/controller/types"
+	"github.com/pdok/mapserver-operator/internal/controller/utils"
+	"github.com/pdok/mapserver-operator/internal/crs"
+	smoothoperatormodel "github.com/pdok/smooth-operator/model"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	typeGpkg    = "gpkg"
+	typeTIF     = "tif"
+	typePostgis = "postgis"
+
+	// postgisSource connects with the libpq environment variables (PGHOST, PGUSER, ...) of the mapserver container
+	postgisSource = "PG:"
+	// unknownCRS is written by the script when the CRS of the data can't be determined, otherwise it writes the EPSG code
+	unknownCRS = "-"
+)
+
+//go:embed auto_extent.sh
+var Script string
+
+// source of a layer, featureType or coverage to read the extent from
+type source struct {
+	name       string
+	sourceType string
+	path       string
+	table      string
+	// crs of the extent in the status
+	crs string
+}
+
+// UseAutoExtent returns whether the extents are read from the data
+func UseAutoExtent[O pdoknlv3.WMSWFS](obj O) bool {
+	return obj.Options().AutoExtent
+}
+
+func getSources[O pdoknlv3.WMSWFS](obj O) (sources []source) {
+	switch webservice := any(obj).(type) {
+	case *pdoknlv3.WFS:
+		for _, featureType := range webservice.Spec.Service.FeatureTypes {
+			src := source{name: featureType.Name, crs: webservice.GetDefaultCrs(featureType)}
+			switch {
+			case featureType.Data.Gpkg != nil:
+				src.sourceType, src.table = typeGpkg, featureType.Data.Gpkg.TableName
+				src.path = *mapfilegenerator.GetFeatureTypeGeopackagePath(webservice, featureType)
+			case featureType.Data.Postgis != nil:
+				src.sourceType, src.path, src.table = typePostgis, postgisSource, featureType.Data.Postgis.TableName
+			default:
+				continue
+			}
+			sources = append(sources, src)
+		}
+	case *pdoknlv3.WMS:
+		for _, layer := range webservice.Spec.Service.GetAnnotatedLayers() {
+			if !layer.IsDataLayer || layer.Name == nil || layer.Data == nil {
+				continue
+			}
+			src := source{name: *layer.Name, crs: webservice.Spec.Service.DataEPSG}
+			switch {
+			case layer.Data.Gpkg != nil:
+				src.sourceType, src.table = typeGpkg, layer.Data.Gpkg.TableName
+				src.path = mapfilegenerator.GetLayerGeopackagePath(webservice, *layer.Name, layer.Data.Gpkg.BlobKey)
+			case layer.Data.TIF != nil:
+				src.sourceType, src.path = typeTIF, *mapfilegenerator.GetTifPath(webservice, layer.Data.TIF.BlobKey)
+			case layer.Data.Postgis != nil:
+				src.sourceType, src.path, src.table = typePostgis, postgisSource, layer.Data.Postgis.TableName
+			default:
+				continue
+			}
+			sources = append(sources, src)
+		}
+	case *pdoknlv3.WCS:
+		for _, coverage := range webservice.Spec.Service.Coverages {
+			sources = append(sources, source{
+				name:       coverage.Name,
+				sourceType: typeTIF,
+				path:       *mapfilegenerator.GetTifPath(webservice, coverage.Data.TIF.BlobKey),
+				crs:        webservice.Spec.Service.DefaultCrs,
+			})
+		}
+	}
+	return sources
+}
+
+// GetSources returns the input of the auto-extent script, one "<name> <type> <path> <table>" per line
+func GetSources[O pdoknlv3.WMSWFS](obj O) string {
+	lines := []string{}
+	for _, src := range getSources(obj) {
+		lines = append(lines, strings.TrimSpace(strings.Join([]string{src.name, src.sourceType, src.path, src.table}, " ")))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// GetAutoExtentInitContainer returns the init container that reads the extents after the blob-download.
+// It uses the mapserver image, so it reads the data with the same GDAL and environment as mapserver.
+func GetAutoExtentInitContainer[O pdoknlv3.WMSWFS](obj O, images types.Images) *corev1.Container {
+	initContainer := corev1.Container{
+		Name:            constants.AutoExtentName,
+		Image:           images.MapserverImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"bash", "-c", Script},
+		Env: []corev1.EnvVar{
+			{Name: "AUTO_EXTENT_SOURCES", Value: GetSources(obj)},
+		},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("0.5"),
+				corev1.ResourceMemory: resource.MustParse("256M"),
+			},
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("0.1"),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			utils.GetBaseVolumeMount(),
+			utils.GetDataVolumeMount(),
+		},
+	}
+	if datacache.UseDataCache(obj) {
+		initContainer.VolumeMounts = append(initContainer.VolumeMounts, datacache.GetVolumeMount())
+	}
+	return &initContainer
+}
+
+// GetExtents returns the extents in the termination message of the auto-extent init container of the newest pod
+// that completed it, reprojected to the CRS of the layer, featureType or coverage. Nil if no pod completed it yet.
+func GetExtents[O pdoknlv3.WMSWFS](obj O, pods []corev1.Pod) []pdoknlv3.DataExtent {
+	var message *string
+	var newest *corev1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		if newest != nil && !newest.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			continue
+		}
+		for _, status := range pod.Status.InitContainerStatuses {
+			if status.Name == constants.AutoExtentName && status.State.Terminated != nil && status.State.Terminated.ExitCode == 0 {
+				newest, message = pod, &status.State.Terminated.Message
+			}
+		}
+	}
+	if message == nil {
+		return nil
+	}
+	return parseMessage(obj, *message)
+}
+
+// parseMessage skips malformed lines, e.g. the last line of a truncated termination message, these keep the default extent
+func parseMessage[O pdoknlv3.WMSWFS](obj O, message string) []pdoknlv3.DataExtent {
+	sources := getSources(obj)
+	extents := []pdoknlv3.DataExtent{}
+	for _, line := range strings.Split(strings.TrimSpace(message), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 6 {
+			continue
+		}
+		name, dataCRS := fields[0], fields[1]
+		index := slices.IndexFunc(sources, func(src source) bool { return src.name == name })
+		// The layer, featureType or coverage was removed since the pod started
+		if index == -1 {
+			continue
+		}
+
+		bbox, err := parseBBox(fields[2:])
+		if err != nil {
+			continue
+		}
+		targetCRS := sources[index].crs
+		if dataCRS != unknownCRS && !strings.Contains(dataCRS, ":") {
+			dataCRS = "EPSG:" + dataCRS
+		}
+		// Data without a CRS is assumed to be in the CRS of the layer, featureType or coverage
+		if dataCRS != unknownCRS && dataCRS != targetCRS {
+			// A CRS outside the CRS registry keeps the default extent
+			if bbox, err = crs.Transform(bbox, dataCRS, targetCRS); err != nil {
+				continue
+			}
+		}
+		extents = append(extents, pdoknlv3.DataExtent{Name: name, CRS: targetCRS, BBox: bbox})
+	}
+	slices.SortFunc(extents, func(a, b pdoknlv3.DataExtent) int { return strings.Compare(a.Name, b.Name) })
+	return extents
+}
+
+// parseBBox formats the coordinates without exponent, as required by the BBox
+func parseBBox(values []string) (smoothoperatormodel.BBox, error) {
+	coordinates := make([]string, 0, len(values))
+	for _, value := range values {
+		coordinate, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return smoothoperatormodel.BBox{}, err
+		}
+		coordinates = append(coordinates, strconv.FormatFloat(coordinate, 'f', -1, 64))
+	}
+	return smoothoperatormodel.BBox{MinX: coordinates[0], MinY: coordinates[1], MaxX: coordinates[2], MaxY: coordinates[3]}, nil
+}
+
+// WithExtents returns a copy of the webservice where the extents of the status replace the omitted bboxes,
+// this copy is the input of the mapfile and capabilities generators
+func WithExtents[O pdoknlv3.WMSWFS](obj O) O {
+	if !UseAutoExtent(obj) || len(obj.ServiceStatus().Extents) == 0 {
+		return obj
+	}
+
+	switch webservice := any(obj).(type) {
+	case *pdoknlv3.WFS:
+		result := webservice.DeepCopy()
+		applyWFSExtents(result)
+		return any(result).(O)
+	case *pdoknlv3.WMS:
+		result := webservice.DeepCopy()
+		applyWMSExtents(result)
+		return any(result).(O)
+	case *pdoknlv3.WCS:
+		result := webservice.DeepCopy()
+		applyWCSExtents(result)
+		return any(result).(O)
+	}
+	return obj
+}
+
+func applyWFSExtents(wfs *pdoknlv3.WFS) {
+	service := &wfs.Spec.Service
+	var serviceBBox *smoothoperatormodel.BBox
+	for i := range service.FeatureTypes {
+		featureType := &service.FeatureTypes[i]
+		extent := wfs.Status.GetExtent(featureType.Name)
+		if extent == nil || extent.CRS != wfs.GetDefaultCrs(*featureType) {
+			continue
+		}
+		if featureType.Bbox == nil {
+			featureType.Bbox = &pdoknlv3.FeatureBbox{}
+		}
+		if featureType.Bbox.DefaultCRS == nil {
+			featureType.Bbox.DefaultCRS = extent.BBox.DeepCopy()
+		}
+		if extent.CRS == service.DefaultCrs {
+			serviceBBox = combine(serviceBBox, *featureType.Bbox.DefaultCRS)
+		}
+	}
+	if service.Bbox == nil && serviceBBox != nil {
+		service.Bbox = &pdoknlv3.Bbox{DefaultCRS: *serviceBBox}
+	}
+}
+
+func applyWCSExtents(wcs *pdoknlv3.WCS) {
+	service := &wcs.Spec.Service
+	var serviceBBox *smoothoperatormodel.BBox
+	for i := range service.Coverages {
+		coverage := &service.Coverages[i]
+		extent := wcs.Status.GetExtent(coverage.Name)
+		if extent == nil || extent.CRS != service.DefaultCrs {
+			continue
+		}
+		if coverage.Bbox == nil {
+			coverage.Bbox = &pdoknlv3.FeatureBbox{}
+		}
+		if coverage.Bbox.DefaultCRS == nil {
+			coverage.Bbox.DefaultCRS = extent.BBox.DeepCopy()
+		}
+		serviceBBox = combine(serviceBBox, *coverage.Bbox.DefaultCRS)
+	}
+	if service.Bbox == nil && serviceBBox != nil {
+		service.Bbox = &pdoknlv3.Bbox{DefaultCRS: *serviceBBox}
+	}
+}
+
+// applyWMSExtents sets the bboxes of the data layers without bboxes in every CRS advertised by the WMS,
+// group layers without bboxes get the combined bboxes of their sublayers
+func applyWMSExtents(wms *pdoknlv3.WMS) {
+	crss := []string{wms.Spec.Service.DataEPSG}
+	for _, layer := range wms.Spec.Service.GetAnnotatedLayers() {
+		for _, bbox := range layer.BoundingBoxes {
+			if !slices.Contains(crss, bbox.CRS) {
+				crss = append(crss, bbox.CRS)
+			}
+		}
+	}
+	applyLayerExtents(wms, &wms.Spec.Service.Layer, crss)
+}
+
+func applyLayerExtents(wms *pdoknlv3.WMS, layer *pdoknlv3.Layer, crss []string) {
+	if len(layer.BoundingBoxes) > 0 {
+		for i := range layer.Layers {
+			applyLayerExtents(wms, &layer.Layers[i], crss)
+		}
+		return
+	}
+
+	if layer.IsDataLayer() {
+		if layer.Name == nil {
+			return
+		}
+		extent := wms.Status.GetExtent(*layer.Name)
+		if extent == nil || extent.CRS != wms.Spec.Service.DataEPSG {
+			return
+		}
+		for _, code := range crss {
+			bbox, err := crs.Transform(extent.BBox, extent.CRS, code)
+			if err != nil {
+				continue
+			}
+			// The bboxes of the WMS are in the axis order of the CRS
+			if crs.IsNorthEast(code) {
+				bbox = smoothoperatormodel.BBox{MinX: bbox.MinY, MinY: bbox.MinX, MaxX: bbox.MaxY, MaxY: bbox.MaxX}
+			}
+			layer.BoundingBoxes = append(layer.BoundingBoxes, pdoknlv3.WMSBoundingBox{CRS: code, BBox: bbox})
+		}
+		return
+	}
+
+	bboxes := map[string]*smoothoperatormodel.BBox{}
+	for i := range layer.Layers {
+		applyLayerExtents(wms, &layer.Layers[i], crss)
+		for _, bbox := range layer.Layers[i].BoundingBoxes {
+			bboxes[bbox.CRS] = combine(bboxes[bbox.CRS], bbox.BBox)
+		}
+	}
+	for _, code := range crss {
+		if bbox, ok := bboxes[code]; ok {
+			layer.BoundingBoxes = append(layer.BoundingBoxes, pdoknlv3.WMSBoundingBox{CRS: code, BBox: *bbox})
+		}
+	}
+}
+
+func combine(bbox *smoothoperatormodel.BBox, other smoothoperatormodel.BBox) *smoothoperatormodel.BBox {
+	if bbox == nil {
+		return other.DeepCopy()
+	}
+	bbox.Combine(other)
+	return bbox
+}
@@ -0,0 +1,213 @@
+package autoextent
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/constants"
+	smoothoperatormodel "github.com/pdok/smooth-operator/model"
+	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func getWFS() *pdoknlv3.WFS {
+	return &pdoknlv3.WFS{
+		Spec: pdoknlv3.WFSSpec{
+			Options: &pdoknlv3.WFSOptions{BaseOptions: pdoknlv3.BaseOptions{PrefetchData: true, AutoExtent: true}},
+			Service: pdoknlv3.WFSService{
+				DefaultCrs: "EPSG:28992",
+				FeatureTypes: []pdoknlv3.FeatureType{
+					{
+						Name: "gpkg",
+						Data: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: "bucket/key/file.gpkg", TableName: "table"}},
+					},
+					{
+						Name: "postgis",
+						Data: pdoknlv3.BaseData{Postgis: &pdoknlv3.Postgis{TableName: "schema.table"}},
+					},
+					{
+						Name: "bbox",
+						Bbox: &pdoknlv3.FeatureBbox{DefaultCRS: &smoothoperatormodel.BBox{MinX: "1", MinY: "2", MaxX: "3", MaxY: "4"}},
+						Data: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: "bucket/key/file.gpkg", TableName: "other"}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func getWMS() *pdoknlv3.WMS {
+	return &pdoknlv3.WMS{
+		Spec: pdoknlv3.WMSSpec{
+			Options: &pdoknlv3.Options{BaseOptions: pdoknlv3.BaseOptions{PrefetchData: true, AutoExtent: true}},
+			Service: pdoknlv3.WMSService{
+				DataEPSG: "EPSG:28992",
+				Layer: pdoknlv3.Layer{
+					Name: smoothoperatorutils.Pointer("top"),
+					Layers: []pdoknlv3.Layer{
+						{
+							Name: smoothoperatorutils.Pointer("gpkg"),
+							Data: &pdoknlv3.Data{BaseData: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: "bucket/key/file.gpkg", TableName: "table"}}},
+						},
+						{
+							Name: smoothoperatorutils.Pointer("tif"),
+							BoundingBoxes: []pdoknlv3.WMSBoundingBox{
+								{CRS: "EPSG:4326", BBox: smoothoperatormodel.BBox{MinX: "50", MinY: "3", MaxX: "54", MaxY: "7"}},
+							},
+							Data: &pdoknlv3.Data{TIF: &pdoknlv3.TIF{BlobKey: "bucket/key/file.tif"}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGetSources(t *testing.T) {
+	assert.Equal(t, "gpkg gpkg /srv/data/gpkg/file.gpkg table\npostgis postgis PG: schema.table\nbbox gpkg /srv/data/gpkg/file.gpkg other\n", GetSources(getWFS()))
+	assert.Equal(t, "gpkg gpkg /srv/data/gpkg/file.gpkg table\ntif tif /srv/data/tif/file.tif\n", GetSources(getWMS()))
+	assert.Empty(t, GetSources(&pdoknlv3.WCS{}))
+}
+
+func TestGetExtents(t *testing.T) {
+	getPod := func(created time.Time, message string, exitCode int32) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)},
+			Status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{{
+					Name:  constants.AutoExtentName,
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode, Message: message}},
+				}},
+			},
+		}
+	}
+	now := time.Now()
+	pods := []corev1.Pod{
+		getPod(now.Add(-time.Hour), "gpkg EPSG:28992 0 0 1 1\n", 0),
+		getPod(now, "postgis - 1.5e+05 300000 155000.5 310000\ngpkg 28992 10 20 30 40\nremoved 28992 1 2 3 4\nbbox 28992 1 2", 0),
+		getPod(now.Add(time.Hour), "gpkg EPSG:28992 1 2 3 4\n", 1),
+	}
+
+	// The truncated last line is skipped
+	extents := GetExtents(getWFS(), pods)
+	assert.Equal(t, []pdoknlv3.DataExtent{
+		{Name: "gpkg", CRS: "EPSG:28992", BBox: smoothoperatormodel.BBox{MinX: "10", MinY: "20", MaxX: "30", MaxY: "40"}},
+		{Name: "postgis", CRS: "EPSG:28992", BBox: smoothoperatormodel.BBox{MinX: "150000", MinY: "300000", MaxX: "155000.5", MaxY: "310000"}},
+	}, extents)
+
+	assert.Nil(t, GetExtents(getWFS(), nil))
+
+	// The EPSG: prefix of older pods is accepted, invalid coordinates are skipped
+	extents = GetExtents(getWFS(), []corev1.Pod{getPod(now, "gpkg EPSG:28992 10 20 30 40\npostgis 28992 a b c d", 0)})
+	assert.Equal(t, []pdoknlv3.DataExtent{
+		{Name: "gpkg", CRS: "EPSG:28992", BBox: smoothoperatormodel.BBox{MinX: "10", MinY: "20", MaxX: "30", MaxY: "40"}},
+	}, extents)
+}
+
+func TestGetExtentsReprojects(t *testing.T) {
+	extents := parseMessage(getWFS(), "gpkg 4326 5 52 5 52\nbbox 99999 1 2 3 4\n")
+	assert.Len(t, extents, 1)
+	assert.Equal(t, "EPSG:28992", extents[0].CRS)
+	assert.True(t, strings.HasPrefix(extents[0].BBox.MinX, "1"), extents[0].BBox.MinX)
+	assert.True(t, strings.HasPrefix(extents[0].BBox.MinY, "4"), extents[0].BBox.MinY)
+}
+
+func TestWithExtents(t *testing.T) {
+	wfs := getWFS()
+	assert.Same(t, wfs, WithExtents(wfs))
+
+	wfs.Status.Extents = []pdoknlv3.DataExtent{
+		{Name: "gpkg", CRS: "EPSG:28992", BBox: smoothoperatormodel.BBox{MinX: "10", MinY: "20", MaxX: "30", MaxY: "40"}},
+		{Name: "postgis", CRS: "EPSG:28992", BBox: smoothoperatormodel.BBox{MinX: "0", MinY: "30", MaxX: "20", MaxY: "50"}},
+		{Name: "bbox", CRS: "EPSG:28992", BBox: smoothoperatormodel.BBox{MinX: "100", MinY: "200", MaxX: "300", MaxY: "400"}},
+	}
+	result := WithExtents(wfs)
+	assert.Nil(t, wfs.Spec.Service.FeatureTypes[0].Bbox)
+	assert.Equal(t, "10 20 30 40", result.Spec.Service.FeatureTypes[0].Bbox.DefaultCRS.ToExtent())
+	assert.Equal(t, "0 30 20 50", result.Spec.Service.FeatureTypes[1].Bbox.DefaultCRS.ToExtent())
+	// A bbox in the spec takes precedence
+	assert.Equal(t, "1 2 3 4", result.Spec.Service.FeatureTypes[2].Bbox.DefaultCRS.ToExtent())
+	assert.Equal(t, "0 2 30 50", result.Spec.Service.Bbox.DefaultCRS.ToExtent())
+
+	wfs.Spec.Options.AutoExtent = false
+	assert.Same(t, wfs, WithExtents(wfs))
+}
+
+func TestWithExtentsWMS(t *testing.T) {
+	wms := getWMS()
+	wms.Status.Extents = []pdoknlv3.DataExtent{
+		{Name: "gpkg", CRS: "EPSG:28992", BBox: smoothoperatormodel.BBox{MinX: "100000", MinY: "400000", MaxX: "200000", MaxY: "500000"}},
+		{Name: "tif", CRS: "EPSG:28992", BBox: smoothoperatormodel.BBox{MinX: "0", MinY: "300000", MaxX: "10", MaxY: "300010"}},
+	}
+	result := WithExtents(wms)
+
+	gpkg := result.Spec.Service.Layer.Layers[0]
+	assert.Len(t, gpkg.BoundingBoxes, 2)
+	assert.Equal(t, "EPSG:28992", gpkg.BoundingBoxes[0].CRS)
+	assert.Equal(t, "100000 400000 200000 500000", gpkg.BoundingBoxes[0].ToExtent())
+	// EPSG:4326 is in lat/lon order
+	assert.Equal(t, "EPSG:4326", gpkg.BoundingBoxes[1].CRS)
+	assert.True(t, strings.HasPrefix(gpkg.BoundingBoxes[1].BBox.MinX, "51."), gpkg.BoundingBoxes[1].BBox.MinX)
+	assert.True(t, strings.HasPrefix(gpkg.BoundingBoxes[1].BBox.MinY, "4."), gpkg.BoundingBoxes[1].BBox.MinY)
+
+	// A bbox in the spec takes precedence
+	assert.Equal(t, wms.Spec.Service.Layer.Layers[1].BoundingBoxes, result.Spec.Service.Layer.Layers[1].BoundingBoxes)
+
+	top := result.Spec.Service.Layer
+	assert.Len(t, top.BoundingBoxes, 2)
+	assert.Equal(t, "100000 400000 200000 500000", top.BoundingBoxes[0].ToExtent())
+	assert.Equal(t, "EPSG:4326", top.BoundingBoxes[1].CRS)
+	assert.Equal(t, "50", top.BoundingBoxes[1].BBox.MinX)
+	assert.Equal(t, "54", top.BoundingBoxes[1].BBox.MaxX)
+}
+
+func TestScript(t *testing.T) {
+	assert.True(t, strings.HasPrefix(Script, "#!/usr/bin/env bash"))
+	for _, function := range []string{"epsg", "vector_extent", "raster_extent", "compact_extent", "read_extent", "main"} {
+		assert.Contains(t, Script, "function "+function+"()")
+	}
+}
+
+// fakeOgrinfo prints the extent of every table in EPSG:28992
+const fakeOgrinfo = `#!/usr/bin/env bash
+echo 'PROJCRS["Amersfoort / RD New",ID["EPSG",28992]]'
+echo 'Extent: (123456.789000, 456789.123000) - (130000.000000, 460000.500000)'
+`
+
+func TestScriptWritesCompactExtents(t *testing.T) {
+	binDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "ogrinfo"), []byte(fakeOgrinfo), 0o755))
+	messagePath := filepath.Join(t.TempDir(), "termination-log")
+
+	sources := ""
+	for i := range 100 {
+		sources += fmt.Sprintf("%s-%02d gpkg /srv/data/gpkg/file.gpkg table\n", strings.Repeat("a", 60), i)
+	}
+	cmd := exec.Command("bash", "-c", Script)
+	cmd.Env = append(os.Environ(),
+		"PATH="+binDir+":"+os.Getenv("PATH"),
+		"AUTO_EXTENT_MESSAGE_PATH="+messagePath,
+		"AUTO_EXTENT_SOURCES="+sources,
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Contains(t, string(out), "Termination message is full, skipping")
+
+	message, err := os.ReadFile(messagePath)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(message), 4096)
+	lines := strings.Split(strings.TrimSpace(string(message)), "\n")
+	assert.Equal(t, strings.Repeat("a", 60)+"-00 28992 123456 456789 130000 460001", lines[0])
+	for _, line := range lines {
+		assert.Len(t, strings.Fields(line), 6)
+	}
+}
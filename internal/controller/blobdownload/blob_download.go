@@ -7,6 +7,9 @@ import (
 	"strings"
 
 	"github.com/pdok/mapserver-operator/internal/controller/constants"
+	"github.com/pdok/mapserver-operator/internal/controller/datacache"
+	"github.com/pdok/mapserver-operator/internal/controller/datarefresh"
+	"github.com/pdok/mapserver-operator/internal/controller/prefetch"
 
 	"github.com/pdok/mapserver-operator/internal/controller/types"
 
@@ -24,8 +27,28 @@ const (
 	imagesPath = "/srv/data/images"
 	fontsPath  = "/srv/data/config/fonts"
 	legendPath = "/var/www/legend"
+
+	// maxMessageSize is the size at which Kubernetes truncates the termination message
+	maxMessageSize = 4096
+)
+
+var (
+	// safeShellWordRegex matches the blob keys and paths that need no quoting in the script
+	safeShellWordRegex = regexp.MustCompile(`^[a-zA-Z0-9_./:@+${}-]+$`)
+	// shellEscaper escapes a double-quoted word, the ${BLOBS_*_BUCKET} variables of the blob keys are still expanded
+	shellEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", "$(", `\$(`)
+	// shellLiteralEscaper escapes a single-quoted word, nothing is expanded
+	shellLiteralEscaper = strings.NewReplacer(`'`, `'\''`)
 )
 
+// measureBlobsInPod is false when the operator reads the blob sizes itself
+var measureBlobsInPod = true
+
+// SetMeasureBlobs sets whether the blob-download measures the blobs for the operator
+func SetMeasureBlobs(enabled bool) {
+	measureBlobsInPod = enabled
+}
+
 //go:embed gpkg_download.sh
 var GpkgDownloadScript string
 
@@ -35,10 +58,14 @@ func GetScript() string {
 
 func GetBlobDownloadInitContainer[O pdoknlv3.WMSWFS](obj O, images types.Images) (*corev1.Container, error) {
 	blobkeys := []string{}
-	for _, gpkg := range obj.GeoPackages() {
-		// Deduplicate blobkeys to prevent double downloads
-		if !slices.Contains(blobkeys, gpkg.BlobKey) {
-			blobkeys = append(blobkeys, gpkg.BlobKey)
+	// When using the data cache or data refresh the geopackages are not downloaded by gpkg_download.sh
+	if !datacache.UseDataCache(obj) && !datarefresh.UseDataRefresh(obj) {
+		streamed := prefetch.GetStreamedBlobKeys(obj)
+		for _, gpkg := range obj.GeoPackages() {
+			// Deduplicate blobkeys to prevent double downloads
+			if !slices.Contains(blobkeys, gpkg.BlobKey) && !slices.Contains(streamed, gpkg.BlobKey) {
+				blobkeys = append(blobkeys, gpkg.BlobKey)
+			}
 		}
 	}
 
@@ -55,6 +82,11 @@ func GetBlobDownloadInitContainer[O pdoknlv3.WMSWFS](obj O, images types.Images)
 				Name:  "GEOPACKAGE_DOWNLOAD_LIST",
 				Value: strings.Join(blobkeys, ";"),
 			},
+			{
+				// The root filesystem is read-only, so the rclone config is written to /tmp
+				Name:  "RCLONE_CONFIG",
+				Value: "/tmp/rclone.conf",
+			},
 		},
 		Resources: corev1.ResourceRequirements{
 			Requests: corev1.ResourceList{
@@ -83,10 +115,17 @@ func GetBlobDownloadInitContainer[O pdoknlv3.WMSWFS](obj O, images types.Images)
 		corev1.ResourceCPU: resourceCPU,
 	}
 
-	if obj.Options().PrefetchData {
+	if prefetch.MayPrefetch(obj) {
 		mount := corev1.VolumeMount{Name: constants.InitScriptsName, MountPath: "/srv/scripts", ReadOnly: true}
 		initContainer.VolumeMounts = append(initContainer.VolumeMounts, mount)
 	}
+	if datacache.UseDataCache(obj) {
+		initContainer.VolumeMounts = append(initContainer.VolumeMounts, datacache.GetVolumeMount())
+	}
+	if datarefresh.UseDataRefresh(obj) {
+		initContainer.Env = append(initContainer.Env, datarefresh.GetEnv()...)
+		initContainer.VolumeMounts = append(initContainer.VolumeMounts, datarefresh.GetManifestVolumeMount())
+	}
 
 	return &initContainer, nil
 }
@@ -98,15 +137,31 @@ func GetArgs[W pdoknlv3.WMSWFS](webservice W) (args string, err error) {
 	case *pdoknlv3.WFS:
 		if WFS, ok := any(webservice).(*pdoknlv3.WFS); ok {
 			createConfig(&sb)
-			downloadGeopackage(&sb, WFS.Options().PrefetchData)
+			measureBlobs(&sb, WFS)
+			if datacache.UseDataCache(WFS) {
+				waitForDataCache(&sb, WFS)
+			} else if datarefresh.UseDataRefresh(WFS) {
+				refreshData(&sb)
+			} else {
+				downloadGeopackage(&sb, prefetch.MayPrefetch(WFS))
+			}
 			// In case of WFS no downloads are needed for TIFFs, styling assets and legends
 		}
 	case *pdoknlv3.WMS:
 		if WMS, ok := any(webservice).(*pdoknlv3.WMS); ok {
 			createConfig(&sb)
-			downloadGeopackage(&sb, WMS.Options().PrefetchData)
-			if err = downloadTiffs(&sb, WMS); err != nil {
-				return "", err
+			measureBlobs(&sb, WMS)
+			if datacache.UseDataCache(WMS) {
+				waitForDataCache(&sb, WMS)
+			} else {
+				if datarefresh.UseDataRefresh(WMS) {
+					refreshData(&sb)
+				} else {
+					downloadGeopackage(&sb, prefetch.MayPrefetch(WMS))
+				}
+				if err = downloadTiffs(&sb, WMS); err != nil {
+					return "", err
+				}
 			}
 			if err = downloadStylingAssets(&sb, WMS); err != nil {
 				return "", err
@@ -115,8 +170,19 @@ func GetArgs[W pdoknlv3.WMSWFS](webservice W) (args string, err error) {
 				return "", err
 			}
 		}
+	case *pdoknlv3.WCS:
+		if WCS, ok := any(webservice).(*pdoknlv3.WCS); ok {
+			createConfig(&sb)
+			measureBlobs(&sb, WCS)
+			if datacache.UseDataCache(WCS) {
+				waitForDataCache(&sb, WCS)
+			} else if err = downloadTiffs(&sb, WCS); err != nil {
+				return "", err
+			}
+			// In case of WCS there are no geopackages, styling assets or legends
+		}
 	default:
-		return "", fmt.Errorf("unexpected input, webservice should be of type WFS or WMS, webservice: %v", webservice)
+		return "", fmt.Errorf("unexpected input, webservice should be of type WFS, WMS or WCS, webservice: %v", webservice)
 	}
 	return sb.String(), nil
 }
@@ -127,23 +193,64 @@ func createConfig(sb *strings.Builder) {
 	writeLine(sb, "rclone config create --non-interactive --obscure blobs azureblob endpoint $BLOBS_ENDPOINT account $BLOBS_ACCOUNT key $BLOBS_KEY use_emulator true;")
 }
 
+// measureBlobs writes the sizes of the blobs to the termination message, from where the operator copies them into
+// the status to decide which blobs with prefetch auto are downloaded and to size the ephemeral storage.
+// The sizes are read with rclone through the authenticated blobs remote, a blob that can't be read gets no size.
+// Kubernetes truncates the termination message, so only whole lines that fit are written and the other blobs are
+// skipped, these are streamed until their size is known. Nothing is measured when the operator reads the sizes itself.
+func measureBlobs[O pdoknlv3.WMSWFS](sb *strings.Builder, obj O) {
+	blobKeys := prefetch.GetMeasuredBlobKeys(obj)
+	if !measureBlobsInPod || len(blobKeys) == 0 {
+		return
+	}
+	writeLine(sb, "function measure_blob() { local line; line=\"$1 $(rclone size --json \"blobs:/$2\" | sed -n 's/.*\"bytes\":\\([0-9]*\\).*/\\1/p')\"; "+
+		"if (( $(cat /dev/termination-log 2>/dev/null | wc -c) + ${#line} + 1 > %d )); then echo \"Termination message is full, skipping $1\"; "+
+		"else printf '%%s\\n' \"$line\" >> /dev/termination-log; fi; };", maxMessageSize)
+	for _, blobKey := range blobKeys {
+		writeLine(sb, "measure_blob %s %s;", shellQuoteLiteral(blobKey), shellQuote(blobKey))
+	}
+}
+
 func downloadGeopackage(sb *strings.Builder, prefetchData bool) {
 	if prefetchData {
 		writeLine(sb, "bash /srv/scripts/gpkg_download.sh;")
 	}
 }
 
-func downloadTiffs(sb *strings.Builder, wms *pdoknlv3.WMS) error {
-	if !wms.Options().PrefetchData {
-		return nil
+// refreshData downloads the geopackages in the data manifest, the data-refresh sidecar swaps them afterwards
+func refreshData(sb *strings.Builder) {
+	writeLine(sb, "bash /srv/scripts/%s once;", datarefresh.ScriptName)
+}
+
+// waitForDataCache blocks until the node-local data cache holds all geopackages and TIFFs
+func waitForDataCache[O pdoknlv3.WMSWFS](sb *strings.Builder, obj O) {
+	for _, blobKey := range datacache.GetBlobKeys(obj) {
+		cachedFilePath := datacache.GetCachedFilePath(blobKey)
+		writeLine(sb, "until [ -f %s ]; do echo %s; sleep 5; done;",
+			shellQuote(cachedFilePath), shellQuoteLiteral("Waiting for "+blobKey+" in the data cache"))
+	}
+}
+
+// downloadTiffs downloads the TIFFs that aren't streamed from blob storage
+func downloadTiffs[O pdoknlv3.WMSWFS](sb *strings.Builder, obj O) error {
+	var blobKeys []string
+	switch webservice := any(obj).(type) {
+	case *pdoknlv3.WMS:
+		blobKeys = webservice.GetUniqueTiffBlobKeys()
+	case *pdoknlv3.WCS:
+		blobKeys = webservice.GetUniqueTiffBlobKeys()
 	}
 
-	for _, blobKey := range wms.GetUniqueTiffBlobKeys() {
+	streamed := prefetch.GetStreamedBlobKeys(obj)
+	for _, blobKey := range blobKeys {
+		if slices.Contains(streamed, blobKey) {
+			continue
+		}
 		fileName, err := getFilenameFromBlobKey(blobKey)
 		if err != nil {
 			return err
 		}
-		writeLine(sb, "rclone copyto blobs:/%s %s/%s || exit 1;", blobKey, tifPath, fileName)
+		writeLine(sb, "rclone copyto %s %s || exit 1;", shellQuote("blobs:/"+blobKey), shellQuote(tifPath+"/"+fileName))
 	}
 	return nil
 }
@@ -164,8 +271,10 @@ func downloadStylingAssets(sb *strings.Builder, wms *pdoknlv3.WMS) error {
 		isTTF := re.MatchString(fileName)
 		if isTTF {
 			path = fontsPath
+		} else if strings.HasSuffix(fileName, pdoknlv3.SLDExtension) {
+			path = constants.SLDPath
 		}
-		writeLine(sb, "rclone copyto blobs:/%s %s/%s || exit 1;", blobKey, path, fileName)
+		writeLine(sb, "rclone copyto %s %s || exit 1;", shellQuote("blobs:/"+blobKey), shellQuote(path+"/"+fileName))
 		if isTTF {
 			fileRoot, err := getRootFromFilename(fileName)
 			if err != nil {
@@ -190,12 +299,16 @@ func downloadLegends(sb *strings.Builder, wms *pdoknlv3.WMS) error {
 		for _, layer := range layers {
 			writeLine(sb, "mkdir -p %s/%s;", legendPath, *layer.Name)
 			for _, style := range layer.Styles {
-				writeLine(sb, "rclone copyto blobs:/%s %s/%s/%s.png || exit 1;", style.Legend.BlobKey, legendPath, *layer.Name, style.Name)
+				if style.Legend == nil {
+					continue
+				}
+				legendFile := style.Name + style.Legend.GetExtension()
+				writeLine(sb, "rclone copyto %s %s || exit 1;", shellQuote("blobs:/"+style.Legend.BlobKey), shellQuote(legendPath+"/"+*layer.Name+"/"+legendFile))
 				fileName, err := getFilenameFromBlobKey(style.Legend.BlobKey)
 				if err != nil {
 					return err
 				}
-				writeLine(sb, "echo 'Copied legend %s to %s/%s/%s.png';", fileName, legendPath, *layer.Name, style.Name)
+				writeLine(sb, "echo 'Copied legend %s to %s/%s/%s';", fileName, legendPath, *layer.Name, legendFile)
 			}
 		}
 		writeLine(sb, "chown -R 999:999 %s", legendPath)
@@ -220,6 +333,19 @@ func getRootFromFilename(fileName string) (string, error) {
 	return fileName[:index], nil
 }
 
+// shellQuote quotes a blob key or path for the script when it contains e.g. spaces
+func shellQuote(value string) string {
+	if safeShellWordRegex.MatchString(value) {
+		return value
+	}
+	return `"` + shellEscaper.Replace(value) + `"`
+}
+
+// shellQuoteLiteral quotes a value in single quotes, so the ${BLOBS_*_BUCKET} variables of a blob key stay as they are
+func shellQuoteLiteral(value string) string {
+	return `'` + shellLiteralEscaper.Replace(value) + `'`
+}
+
 func writeLine(sb *strings.Builder, format string, a ...any) { //nolint:goprintffuncname
 	sb.WriteString(fmt.Sprintf(format, a...) + "\n")
 }
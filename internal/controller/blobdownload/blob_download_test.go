@@ -1,20 +1,39 @@
 package blobdownload
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/datacache"
+	"github.com/pdok/mapserver-operator/internal/controller/types"
 	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
 )
 
 const (
+	WFSArgsWithAutoPrefetch = `set -e;
+mkdir -p /srv/data/config/;
+rclone config create --non-interactive --obscure blobs azureblob endpoint $BLOBS_ENDPOINT account $BLOBS_ACCOUNT key $BLOBS_KEY use_emulator true;
+function measure_blob() { local line; line="$1 $(rclone size --json "blobs:/$2" | sed -n 's/.*"bytes":\([0-9]*\).*/\1/p')"; if (( $(cat /dev/termination-log 2>/dev/null | wc -c) + ${#line} + 1 > 4096 )); then echo "Termination message is full, skipping $1"; else printf '%s\n' "$line" >> /dev/termination-log; fi; };
+measure_blob '${BLOBS_GEOPACKAGES_BUCKET}/key/auto.gpkg' ${BLOBS_GEOPACKAGES_BUCKET}/key/auto.gpkg;
+bash /srv/scripts/gpkg_download.sh;
+`
+
 	WFSArgsWithPrefetch = `set -e;
 mkdir -p /srv/data/config/;
 rclone config create --non-interactive --obscure blobs azureblob endpoint $BLOBS_ENDPOINT account $BLOBS_ACCOUNT key $BLOBS_KEY use_emulator true;
 bash /srv/scripts/gpkg_download.sh;
+`
+	WFSArgsWithDataCache = `set -e;
+mkdir -p /srv/data/config/;
+rclone config create --non-interactive --obscure blobs azureblob endpoint $BLOBS_ENDPOINT account $BLOBS_ACCOUNT key $BLOBS_KEY use_emulator true;
+until [ -f /srv/data-cache/b44e145399e65c8c/current/file.gpkg ]; do echo 'Waiting for geopackages-bucket/key/file.gpkg in the data cache'; sleep 5; done;
 `
 	WFSArgsWithoutPrefetch = `set -e;
 mkdir -p /srv/data/config/;
@@ -37,8 +56,8 @@ mkdir -p /var/www/legend/wms-gpkg-layer-1-name;
 rclone copyto blobs:/resources-bucket/key/gpkg-layer-1-legend.png /var/www/legend/wms-gpkg-layer-1-name/wms-gpkg-style-1-name.png || exit 1;
 echo 'Copied legend gpkg-layer-1-legend.png to /var/www/legend/wms-gpkg-layer-1-name/wms-gpkg-style-1-name.png';
 mkdir -p /var/www/legend/wms-gpkg-layer-2-name;
-rclone copyto blobs:/resources-bucket/key/gpkg-layer-2-legend.png /var/www/legend/wms-gpkg-layer-2-name/wms-gpkg-style-2-name.png || exit 1;
-echo 'Copied legend gpkg-layer-2-legend.png to /var/www/legend/wms-gpkg-layer-2-name/wms-gpkg-style-2-name.png';
+rclone copyto blobs:/resources-bucket/key/gpkg-layer-2-legend.svg /var/www/legend/wms-gpkg-layer-2-name/wms-gpkg-style-2-name.svg || exit 1;
+echo 'Copied legend gpkg-layer-2-legend.svg to /var/www/legend/wms-gpkg-layer-2-name/wms-gpkg-style-2-name.svg';
 chown -R 999:999 /var/www/legend
 `
 
@@ -84,9 +103,9 @@ func TestGetArgsForWFS(t *testing.T) {
 						Service: pdoknlv3.WFSService{BaseService: pdoknlv3.BaseService{
 							Title: "wfs-prefetch-service-title",
 						}},
-						Options: &pdoknlv3.BaseOptions{
+						Options: &pdoknlv3.WFSOptions{BaseOptions: pdoknlv3.BaseOptions{
 							PrefetchData: true,
-						},
+						}},
 					},
 				},
 			},
@@ -101,9 +120,9 @@ func TestGetArgsForWFS(t *testing.T) {
 						Service: pdoknlv3.WFSService{BaseService: pdoknlv3.BaseService{
 							Title: "wfs-noprefetch-service-title",
 						}},
-						Options: &pdoknlv3.BaseOptions{
+						Options: &pdoknlv3.WFSOptions{BaseOptions: pdoknlv3.BaseOptions{
 							PrefetchData: false,
-						},
+						}},
 					},
 				},
 			},
@@ -186,7 +205,8 @@ func TestGetArgsForWMS(t *testing.T) {
 												Name:  "wms-gpkg-style-2-name",
 												Title: smoothoperatorutils.Pointer("wms-gpkg-style-2-title"),
 												Legend: &pdoknlv3.Legend{
-													BlobKey: "resources-bucket/key/gpkg-layer-2-legend.png",
+													Format:  "image/svg+xml",
+													BlobKey: "resources-bucket/key/gpkg-layer-2-legend.svg",
 												},
 											},
 										},
@@ -300,6 +320,131 @@ func TestGetArgsForWMS(t *testing.T) {
 	}
 }
 
+func TestGetArgsForWFSWithDataCache(t *testing.T) {
+	datacache.SetConfig(types.DataCacheConfig{Enabled: true})
+	t.Cleanup(func() { datacache.SetConfig(types.DataCacheConfig{}) })
+
+	wfs := &pdoknlv3.WFS{
+		Spec: pdoknlv3.WFSSpec{
+			Service: pdoknlv3.WFSService{
+				FeatureTypes: []pdoknlv3.FeatureType{
+					{Data: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: "geopackages-bucket/key/file.gpkg"}}},
+					{Data: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: "geopackages-bucket/key/file.gpkg"}}},
+				},
+			},
+			Options: &pdoknlv3.WFSOptions{BaseOptions: pdoknlv3.BaseOptions{
+				PrefetchData: true,
+				UseDataCache: true,
+			}},
+		},
+	}
+	args, err := GetArgs(wfs)
+	if err != nil {
+		t.Fatalf("GetArgs() error = %v", err)
+	}
+	if diff := cmp.Diff(WFSArgsWithDataCache, args); diff != "" {
+		t.Errorf("GetArgs() -want, +got %s", diff)
+	}
+
+	container, err := GetBlobDownloadInitContainer(wfs, types.Images{})
+	if err != nil {
+		t.Fatalf("GetBlobDownloadInitContainer() error = %v", err)
+	}
+	if container.Env[1].Value != "" {
+		t.Errorf("Expected no geopackages to download, got %s", container.Env[1].Value)
+	}
+}
+
+func TestGetArgsForWFSWithAutoPrefetch(t *testing.T) {
+	wfs := &pdoknlv3.WFS{
+		Spec: pdoknlv3.WFSSpec{
+			Service: pdoknlv3.WFSService{
+				FeatureTypes: []pdoknlv3.FeatureType{
+					{Data: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: "${BLOBS_GEOPACKAGES_BUCKET}/key/auto.gpkg", Prefetch: smoothoperatorutils.Pointer(pdoknlv3.PrefetchAuto)}}},
+					{Data: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: "${BLOBS_GEOPACKAGES_BUCKET}/key/streamed.gpkg", Prefetch: smoothoperatorutils.Pointer(pdoknlv3.PrefetchNever)}}},
+				},
+			},
+			Options: &pdoknlv3.WFSOptions{BaseOptions: pdoknlv3.BaseOptions{
+				PrefetchData: false,
+			}},
+		},
+	}
+	wfs.Status.Blobs = []pdoknlv3.BlobSize{{BlobKey: "${BLOBS_GEOPACKAGES_BUCKET}/key/auto.gpkg", Size: 1000}}
+
+	args, err := GetArgs(wfs)
+	if err != nil {
+		t.Fatalf("GetArgs() error = %v", err)
+	}
+	if diff := cmp.Diff(WFSArgsWithAutoPrefetch, args); diff != "" {
+		t.Errorf("GetArgs() -want, +got %s", diff)
+	}
+
+	// The operator reads the blob sizes itself
+	SetMeasureBlobs(false)
+	defer SetMeasureBlobs(true)
+	args, err = GetArgs(wfs)
+	if err != nil {
+		t.Fatalf("GetArgs() error = %v", err)
+	}
+	if strings.Contains(args, "measure_blob") {
+		t.Errorf("Expected no blobs to be measured, got %s", args)
+	}
+
+	container, err := GetBlobDownloadInitContainer(wfs, types.Images{})
+	if err != nil {
+		t.Fatalf("GetBlobDownloadInitContainer() error = %v", err)
+	}
+	if container.Env[1].Value != "${BLOBS_GEOPACKAGES_BUCKET}/key/auto.gpkg" {
+		t.Errorf("Expected only the prefetched geopackage to download, got %s", container.Env[1].Value)
+	}
+}
+
+func TestMeasureBlobsFitsTheTerminationMessage(t *testing.T) {
+	featureTypes := []pdoknlv3.FeatureType{
+		{Data: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: "${BLOBS_GEOPACKAGES_BUCKET}/0/it's.gpkg", Prefetch: smoothoperatorutils.Pointer(pdoknlv3.PrefetchAuto)}}},
+	}
+	for i := range 100 {
+		blobKey := fmt.Sprintf("${BLOBS_GEOPACKAGES_BUCKET}/a/long/key/of/a/geopackage/%03d.gpkg", i)
+		featureTypes = append(featureTypes, pdoknlv3.FeatureType{Data: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: blobKey, Prefetch: smoothoperatorutils.Pointer(pdoknlv3.PrefetchAuto)}}})
+	}
+	wfs := &pdoknlv3.WFS{Spec: pdoknlv3.WFSSpec{Service: pdoknlv3.WFSService{FeatureTypes: featureTypes}}}
+
+	dir := t.TempDir()
+	rclone := "#!/bin/sh\n[ \"$3\" = \"blobs:/geopackages/0/it's.gpkg\" ] || [ \"${3#blobs:/geopackages/a/}\" != \"$3\" ] || exit 1\necho '{\"count\":1,\"bytes\":123456789,\"sizeless\":0}'\n"
+	if err := os.WriteFile(filepath.Join(dir, "rclone"), []byte(rclone), 0o755); err != nil { //nolint:gosec
+		t.Fatal(err)
+	}
+	messagePath := filepath.Join(dir, "termination-log")
+
+	var sb strings.Builder
+	measureBlobs(&sb, wfs)
+	cmd := exec.Command("bash", "-c", strings.ReplaceAll(sb.String(), "/dev/termination-log", messagePath))
+	cmd.Env = []string{"PATH=" + dir + ":" + os.Getenv("PATH"), "BLOBS_GEOPACKAGES_BUCKET=geopackages"}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("measureBlobs script failed: %v, %s", err, out)
+	}
+
+	message, err := os.ReadFile(messagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(message) > maxMessageSize {
+		t.Errorf("termination message of %d bytes exceeds %d", len(message), maxMessageSize)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(message), "\n"), "\n")
+	if len(lines) == 0 || len(lines) >= len(featureTypes) {
+		t.Fatalf("expected some of the blobs to be skipped, got %d lines", len(lines))
+	}
+	if lines[0] != "${BLOBS_GEOPACKAGES_BUCKET}/0/it's.gpkg 123456789" {
+		t.Errorf("unexpected first line %q", lines[0])
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "${BLOBS_GEOPACKAGES_BUCKET}/") || !strings.HasSuffix(line, ".gpkg 123456789") {
+			t.Errorf("unexpected line %q", line)
+		}
+	}
+}
+
 func TestGetScript(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -330,3 +475,46 @@ func TestGetScript(t *testing.T) {
 		})
 	}
 }
+
+func TestWaitForDataCacheQuotesTheBlobKeys(t *testing.T) {
+	wfs := &pdoknlv3.WFS{
+		Spec: pdoknlv3.WFSSpec{
+			Service: pdoknlv3.WFSService{
+				FeatureTypes: []pdoknlv3.FeatureType{
+					{Data: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: "geopackages-bucket/key/it's $(date).gpkg"}}},
+				},
+			},
+		},
+	}
+	sb := &strings.Builder{}
+	waitForDataCache(sb, wfs)
+
+	cachedFilePath := datacache.GetCachedFilePath("geopackages-bucket/key/it's $(date).gpkg")
+	want := `until [ -f "` + strings.TrimSuffix(cachedFilePath, "it's $(date).gpkg") + `it's \$(date).gpkg" ]; ` +
+		`do echo 'Waiting for geopackages-bucket/key/it'\''s $(date).gpkg in the data cache'; sleep 5; done;` + "\n"
+	if diff := cmp.Diff(want, sb.String()); diff != "" {
+		t.Errorf("waitForDataCache() -want, +got %s", diff)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "blobs:/${BLOBS_RESOURCES_BUCKET}/key/style.sld", want: "blobs:/${BLOBS_RESOURCES_BUCKET}/key/style.sld"},
+		{value: "blobs:/${BLOBS_RESOURCES_BUCKET}/key/my style.sld", want: `"blobs:/${BLOBS_RESOURCES_BUCKET}/key/my style.sld"`},
+		{value: "/srv/data/config/styles/it's \"$(date)\".sld", want: `"/srv/data/config/styles/it's \"\$(date)\".sld"`},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.value); got != tt.want {
+			t.Errorf("shellQuote(%s) = %s, want %s", tt.value, got, tt.want)
+		}
+		cmd := exec.Command("bash", "-c", "printf '%s' "+shellQuote(tt.value))
+		// The bucket variable expands to itself, so the output equals the value
+		cmd.Env = []string{"BLOBS_RESOURCES_BUCKET=${BLOBS_RESOURCES_BUCKET}"}
+		if out, err := cmd.Output(); err != nil || string(out) != tt.value {
+			t.Errorf("bash reads %s as %s, %v", shellQuote(tt.value), out, err)
+		}
+	}
+}
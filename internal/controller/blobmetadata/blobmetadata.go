@@ -0,0 +1,156 @@
+// Package blobmetadata reads the metadata of the geopackages and TIFFs on blob storage from the operator.
+package blobmetadata
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+)
+
+const (
+	// storageVersion is the version of the blob storage REST API that is requested
+	storageVersion = "2021-08-06"
+
+	// parallelism is the number of blobs that is read at the same time
+	parallelism = 8
+)
+
+// Client reads the metadata of blobs
+type Client interface {
+	// GetSize returns the size of the blob in bytes, nil if the blob doesn't exist
+	GetSize(ctx context.Context, blobKey string) (*int64, error)
+}
+
+// HTTPClient reads the metadata with HEAD requests on the blob storage endpoint, signed with the shared key of the
+// account like the blob-download does through rclone.
+// The variables in a blobKey, e.g. ${BLOBS_GEOPACKAGES_BUCKET}, are read from the environment of the operator.
+type HTTPClient struct {
+	Endpoint string
+	Account  string
+	Key      []byte
+	Client   *http.Client
+}
+
+// NewHTTPClient returns a client for the blob storage endpoint, the key is the base64 encoded shared key of the account
+func NewHTTPClient(endpoint, account, key string) (*HTTPClient, error) {
+	if account == "" || key == "" {
+		return nil, errors.New("the account and key of the blob storage are required")
+	}
+	decodedKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("the key of the blob storage isn't base64 encoded: %w", err)
+	}
+	return &HTTPClient{
+		Endpoint: strings.TrimSuffix(endpoint, "/"),
+		Account:  account,
+		Key:      decodedKey,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (c *HTTPClient) GetSize(ctx context.Context, blobKey string) (*int64, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, c.Endpoint+"/"+os.ExpandEnv(blobKey), nil)
+	if err != nil {
+		return nil, err
+	}
+	c.sign(request)
+	response, err := c.Client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the metadata of blob %s: %w", blobKey, err)
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case response.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unable to read the metadata of blob %s: %s", blobKey, response.Status)
+	case response.ContentLength < 0:
+		return nil, fmt.Errorf("unable to read the metadata of blob %s: no content length", blobKey)
+	}
+	return &response.ContentLength, nil
+}
+
+// sign adds the shared key authorization of the blob storage to a request without body
+// see https://learn.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+func (c *HTTPClient) sign(request *http.Request) {
+	request.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	request.Header.Set("x-ms-version", storageVersion)
+
+	var headers []string
+	for name := range request.Header {
+		if name = strings.ToLower(name); strings.HasPrefix(name, "x-ms-") {
+			headers = append(headers, name+":"+request.Header.Get(name)+"\n")
+		}
+	}
+	sort.Strings(headers)
+
+	// The standard headers, from Content-Encoding up to and including Range, are all empty
+	stringToSign := request.Method + "\n" + strings.Repeat("\n", 11) +
+		strings.Join(headers, "") + c.canonicalizedResource(request.URL)
+
+	mac := hmac.New(sha256.New, c.Key)
+	mac.Write([]byte(stringToSign))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	request.Header.Set("Authorization", "SharedKey "+c.Account+":"+signature)
+}
+
+func (c *HTTPClient) canonicalizedResource(u *url.URL) string {
+	resource := "/" + c.Account + u.EscapedPath()
+	query := u.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values := query[name]
+		sort.Strings(values)
+		resource += "\n" + strings.ToLower(name) + ":" + strings.Join(values, ",")
+	}
+	return resource
+}
+
+// GetSizes returns the sizes of the blobs that exist, in the order of the blobKeys. The blobs are read in parallel.
+// A blob that can't be read keeps its size in the previous status, the errors are returned next to the sizes.
+func GetSizes(ctx context.Context, client Client, blobKeys []string, previous *pdoknlv3.Status) ([]pdoknlv3.BlobSize, error) {
+	results := make([]*int64, len(blobKeys))
+	errs := make([]error, len(blobKeys))
+
+	semaphore := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, blobKey := range blobKeys {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			results[i], errs[i] = client.GetSize(ctx, blobKey)
+		}()
+	}
+	wg.Wait()
+
+	sizes := []pdoknlv3.BlobSize{}
+	for i, blobKey := range blobKeys {
+		size := results[i]
+		if errs[i] != nil {
+			size = previous.GetBlobSize(blobKey)
+		}
+		if size != nil {
+			sizes = append(sizes, pdoknlv3.BlobSize{BlobKey: blobKey, Size: *size})
+		}
+	}
+	return sizes, errors.Join(errs...)
+}
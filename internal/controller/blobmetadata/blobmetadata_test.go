@@ -0,0 +1,106 @@
+package blobmetadata
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient map[string]int64
+
+func (c fakeClient) GetSize(_ context.Context, blobKey string) (*int64, error) {
+	if blobKey == "error" {
+		return nil, errors.New("unavailable")
+	}
+	if size, ok := c[blobKey]; ok {
+		return &size, nil
+	}
+	return nil, nil
+}
+
+func TestGetSizes(t *testing.T) {
+	client := fakeClient{"bucket/a.gpkg": 100, "bucket/b.tif": 200}
+
+	sizes, err := GetSizes(context.Background(), client, []string{"bucket/a.gpkg", "bucket/missing.gpkg", "bucket/b.tif"}, &pdoknlv3.Status{})
+	require.NoError(t, err)
+	assert.Equal(t, []pdoknlv3.BlobSize{{BlobKey: "bucket/a.gpkg", Size: 100}, {BlobKey: "bucket/b.tif", Size: 200}}, sizes)
+
+	previous := &pdoknlv3.Status{Blobs: []pdoknlv3.BlobSize{{BlobKey: "bucket/a.gpkg", Size: 50}, {BlobKey: "error", Size: 300}}}
+	sizes, err = GetSizes(context.Background(), client, []string{"bucket/a.gpkg", "error"}, previous)
+	assert.Error(t, err)
+	assert.Equal(t, []pdoknlv3.BlobSize{{BlobKey: "bucket/a.gpkg", Size: 100}, {BlobKey: "error", Size: 300}}, sizes)
+
+	sizes, err = GetSizes(context.Background(), client, []string{"error"}, &pdoknlv3.Status{})
+	assert.Error(t, err)
+	assert.Empty(t, sizes)
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	_, err := NewHTTPClient("http://blobs", "", "a2V5")
+	assert.Error(t, err)
+	_, err = NewHTTPClient("http://blobs", "account", "not base64")
+	assert.Error(t, err)
+	client, err := NewHTTPClient("http://blobs/", "account", "a2V5")
+	require.NoError(t, err)
+	assert.Equal(t, "http://blobs", client.Endpoint)
+	assert.Equal(t, []byte("key"), client.Key)
+}
+
+func TestHTTPClientGetSize(t *testing.T) {
+	t.Setenv("BLOBS_BUCKET", "bucket")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		assert.NotEmpty(t, r.Header.Get("x-ms-date"))
+		assert.Equal(t, storageVersion, r.Header.Get("x-ms-version"))
+		if r.Header.Get("Authorization") != expectedAuthorization(r) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/devstoreaccount1/bucket/file.gpkg":
+			w.Header().Set("Content-Length", "1234")
+		case "/devstoreaccount1/bucket/error.gpkg":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client, err := NewHTTPClient(server.URL+"/devstoreaccount1/", "devstoreaccount1", "a2V5")
+	require.NoError(t, err)
+
+	size, err := client.GetSize(context.Background(), "${BLOBS_BUCKET}/file.gpkg")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1234), *size)
+
+	size, err = client.GetSize(context.Background(), "${BLOBS_BUCKET}/missing.gpkg")
+	require.NoError(t, err)
+	assert.Nil(t, size)
+
+	_, err = client.GetSize(context.Background(), "${BLOBS_BUCKET}/error.gpkg")
+	assert.Error(t, err)
+
+	client.Account = "other"
+	_, err = client.GetSize(context.Background(), "${BLOBS_BUCKET}/file.gpkg")
+	assert.Error(t, err)
+}
+
+// expectedAuthorization is the shared key authorization of the devstoreaccount1 account with key "key"
+func expectedAuthorization(r *http.Request) string {
+	stringToSign := "HEAD\n\n\n\n\n\n\n\n\n\n\n\n" +
+		"x-ms-date:" + r.Header.Get("x-ms-date") + "\n" +
+		"x-ms-version:" + r.Header.Get("x-ms-version") + "\n" +
+		"/devstoreaccount1" + r.URL.Path
+	mac := hmac.New(sha256.New, []byte("key"))
+	mac.Write([]byte(stringToSign))
+	return "SharedKey devstoreaccount1:" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -43,10 +43,14 @@ func GetInput[W pdoknlv3.WMSWFS](webservice W, ownerInfo *smoothoperatorv1.Owner
 		if WMS, ok := any(webservice).(*pdoknlv3.WMS); ok {
 			return createInputForWMS(WMS, ownerInfo)
 		}
+	case *pdoknlv3.WCS:
+		if WCS, ok := any(webservice).(*pdoknlv3.WCS); ok {
+			return createInputForWCS(WCS, ownerInfo)
+		}
 	default:
-		return "", fmt.Errorf("unexpected input, webservice should be of type WFS or WMS, webservice: %v", webservice)
+		return "", fmt.Errorf("unexpected input, webservice should be of type WFS, WMS or WCS, webservice: %v", webservice)
 	}
-	return "", fmt.Errorf("unexpected input, webservice should be of type WFS or WMS, webservice: %v", webservice)
+	return "", fmt.Errorf("unexpected input, webservice should be of type WFS, WMS or WCS, webservice: %v", webservice)
 }
 
 func createInputForWFS(wfs *pdoknlv3.WFS, ownerInfo *smoothoperatorv1.OwnerInfo) (config string, err error) {
@@ -74,3 +78,16 @@ func createInputForWMS(wms *pdoknlv3.WMS, ownerInfo *smoothoperatorv1.OwnerInfo)
 
 	return string(yamlInput), nil
 }
+
+func createInputForWCS(wcs *pdoknlv3.WCS, ownerInfo *smoothoperatorv1.OwnerInfo) (config string, err error) {
+	input, err := MapWCSToCapabilitiesGeneratorInput(wcs, ownerInfo)
+	if err != nil {
+		return "", err
+	}
+	yamlInput, err := yaml.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal the capabilities generator input to yaml: %w", err)
+	}
+
+	return string(yamlInput), nil
+}
@@ -1,10 +1,16 @@
 package capabilitiesgenerator
 
 import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+
 	"github.com/google/go-cmp/cmp"
 	"github.com/pdok/mapserver-operator/api/v2beta1"
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
 	capabilitiesgenerator "github.com/pdok/ogc-capabilities-generator/pkg/config"
+	"github.com/pdok/ogc-specifications/pkg/wms130"
 	smoothoperatorv1 "github.com/pdok/smooth-operator/api/v1"
 	smoothoperatormodel "github.com/pdok/smooth-operator/model"
 	"github.com/stretchr/testify/assert"
@@ -79,6 +85,20 @@ func TestGetInputForWFS(t *testing.T) {
 								"EPSG:4258",
 								"EPSG:4326",
 							},
+							OutputFormats: []string{
+								"application/gml+xml; version=3.2",
+								"application/json; subtype=geojson",
+								"text/csv",
+							},
+							StoredQueries: []pdoknlv3.StoredQuery{
+								{
+									ID:          "urn:ogc:def:query:OGC-WFS::GetFeaturetype1ByName",
+									Title:       "Get featuretype 1 by name",
+									FeatureType: "featuretype-1-name",
+									Parameters:  []pdoknlv3.StoredQueryParameter{{Name: "name"}},
+									Filter:      "<fes:Filter><fes:PropertyIsEqualTo><fes:ValueReference>name</fes:ValueReference><fes:Literal>${name}</fes:Literal></fes:PropertyIsEqualTo></fes:Filter>",
+								},
+							},
 							FeatureTypes: []pdoknlv3.FeatureType{
 								{
 									Name:     "featuretype-1-name",
@@ -104,6 +124,13 @@ func TestGetInputForWFS(t *testing.T) {
 									Title:    "featuretype-2-title",
 									Abstract: "feature \"2\" abstract",
 									Keywords: []string{"featuretype-2-keyword-1", "featuretype-2-keyword-2"},
+									OutputFormats: []string{
+										"application/gml+xml; version=3.2",
+									},
+									OtherCrs: []string{"EPSG:4258", "EPSG:4326"},
+									Bbox: &pdoknlv3.FeatureBbox{
+										DefaultCRS: &smoothoperatormodel.BBox{MinX: "100000", MinY: "400000", MaxX: "200000", MaxY: "500000"},
+									},
 									DatasetMetadataURL: &pdoknlv3.MetadataURL{
 										CSW: &pdoknlv3.Metadata{
 											MetadataIdentifier: "datadata-data-data-data-datadatadata",
@@ -218,3 +245,140 @@ func TestInputForWMS(t *testing.T) {
 	diff := cmp.Diff(wantMap, gotMap)
 	assert.Equal(t, diff, "", "%s", diff)
 }
+
+func TestGetLayerStylesSLD(t *testing.T) {
+	layer := pdoknlv3.Layer{
+		Name: smoothoperatorutils.Pointer("layer"),
+		Styles: []pdoknlv3.Style{
+			{Name: "mapfile", Visualization: smoothoperatorutils.Pointer("mapfile.style")},
+			{Name: "sld", Visualization: smoothoperatorutils.Pointer("style.sld")},
+		},
+	}
+
+	styles := getLayerStyles(layer, "http://localhost/path", nil)
+	assert.Len(t, styles, 2)
+	assert.Nil(t, styles[0].StyleSheetURL)
+	assert.NotNil(t, styles[1].StyleSheetURL)
+	assert.Equal(t, "application/vnd.ogc.sld+xml", styles[1].StyleSheetURL.Format)
+	assert.Equal(t, "http://localhost/path/legend/layer/sld.sld", *styles[1].StyleSheetURL.OnlineResource.Href)
+}
+
+func TestGetLayerStylesLegendFormat(t *testing.T) {
+	layer := pdoknlv3.Layer{
+		Name: smoothoperatorutils.Pointer("layer"),
+		Styles: []pdoknlv3.Style{
+			{Name: "generated"},
+			{Name: "svg", Legend: &pdoknlv3.Legend{Format: "image/svg+xml", Width: 100, BlobKey: "resources/key/legend.svg"}},
+			{Name: "jpeg", Legend: &pdoknlv3.Legend{Format: "image/jpeg", BlobKey: "resources/key/legend.jpeg"}},
+		},
+	}
+
+	styles := getLayerStyles(layer, "http://localhost/path", nil)
+	assert.Len(t, styles, 3)
+	assert.Equal(t, wms130.LegendURL{Width: 78, Height: 20, Format: "image/png", OnlineResource: styles[0].LegendURL.OnlineResource}, *styles[0].LegendURL)
+	assert.Equal(t, "http://localhost/path/legend/layer/generated.png", *styles[0].LegendURL.OnlineResource.Href)
+	assert.Equal(t, wms130.LegendURL{Width: 100, Height: 20, Format: "image/svg+xml", OnlineResource: styles[1].LegendURL.OnlineResource}, *styles[1].LegendURL)
+	assert.Equal(t, "http://localhost/path/legend/layer/svg.svg", *styles[1].LegendURL.OnlineResource.Href)
+	assert.Equal(t, "image/jpeg", styles[2].LegendURL.Format)
+	assert.Equal(t, "http://localhost/path/legend/layer/jpeg.jpg", *styles[2].LegendURL.OnlineResource.Href)
+}
+
+func TestMapLayerQueryableAndOpaque(t *testing.T) {
+	dataLayer := func(name string) pdoknlv3.Layer {
+		return pdoknlv3.Layer{
+			Name:    smoothoperatorutils.Pointer(name),
+			Visible: true,
+			Data:    &pdoknlv3.Data{BaseData: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{}}},
+		}
+	}
+	notQueryable := dataLayer("not-queryable")
+	notQueryable.Queryable = smoothoperatorutils.Pointer(false)
+	notQueryable.Opaque = true
+	disabled := dataLayer("disabled")
+	disabled.FeatureInfo = &pdoknlv3.FeatureInfo{Enabled: smoothoperatorutils.Pointer(false)}
+
+	layer := pdoknlv3.Layer{
+		Name:    smoothoperatorutils.Pointer("top"),
+		Visible: true,
+		Layers: []pdoknlv3.Layer{
+			dataLayer("queryable"),
+			{Name: smoothoperatorutils.Pointer("group"), Visible: true, Layers: []pdoknlv3.Layer{notQueryable, disabled}},
+		},
+	}
+
+	mapped, err := mapLayer(layer, "http://localhost/path", nil, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, *mapped.Queryable)
+	assert.Equal(t, 1, *mapped.Layer[0].Queryable)
+	assert.Nil(t, mapped.Layer[0].Opaque)
+	assert.Equal(t, 0, *mapped.Layer[1].Queryable)
+	assert.Equal(t, 0, *mapped.Layer[1].Layer[0].Queryable)
+	assert.Equal(t, "1", *mapped.Layer[1].Layer[0].Opaque)
+	assert.Equal(t, 0, *mapped.Layer[1].Layer[1].Queryable)
+}
+
+func TestMapWCSWithoutServiceProvider(t *testing.T) {
+	ownerInfo := &smoothoperatorv1.OwnerInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: "owner"},
+		Spec:       smoothoperatorv1.OwnerInfoSpec{NamespaceTemplate: smoothoperatorutils.Pointer("http://{{prefix}}.geonovum.nl")},
+	}
+	wcs := &pdoknlv3.WCS{Spec: pdoknlv3.WCSSpec{Service: pdoknlv3.WCSService{DefaultCrs: "EPSG:28992"}}}
+
+	_, err := MapWCSToCapabilitiesGeneratorInput(wcs, ownerInfo)
+	assert.EqualError(t, err, "spec.WFS missing in ownerInfo owner, it holds the service provider of the WCS")
+}
+
+// base/wfs200.yaml must match the base document of the capabilities-generator version in go.mod
+func TestWFS200BaseIsUpToDate(t *testing.T) {
+	dir, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", "github.com/pdok/ogc-capabilities-generator").Output()
+	if err != nil || len(bytes.TrimSpace(dir)) == 0 {
+		t.Skip("the ogc-capabilities-generator module isn't available")
+	}
+	expected, err := os.ReadFile(filepath.Join(string(bytes.TrimSpace(dir)), "base", "wfs200.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(wfs200BaseYAML))
+}
+
+func TestGetOperations(t *testing.T) {
+	wfs := &pdoknlv3.WFS{Spec: pdoknlv3.WFSSpec{Service: pdoknlv3.WFSService{
+		OutputFormats: []string{pdoknlv3.GML32OutputFormat, "text/csv"},
+		StoredQueries: []pdoknlv3.StoredQuery{{ID: "query"}},
+	}}}
+	operations := getOperations(wfs)
+
+	names := []string{}
+	for _, operation := range operations {
+		names = append(names, operation.Name)
+		switch operation.Name {
+		case "GetFeature":
+			assert.Equal(t, []string{pdoknlv3.GML32OutputFormat, "text/csv"}, operation.Parameter[0].AllowedValues.Value)
+		case "DescribeStoredQueries":
+			assert.Equal(t, []string{getFeatureByIDStoredQuery, "query"}, operation.Parameter[0].AllowedValues.Value)
+		}
+	}
+	assert.Equal(t, []string{"GetCapabilities", "DescribeFeatureType", "GetFeature", "ListStoredQueries", "DescribeStoredQueries"}, names)
+
+	// Without output formats those of the base document are offered, every call returns a copy of the base document
+	assert.Equal(t, []string{
+		pdoknlv3.GML32OutputFormat,
+		"text/xml; subtype=gml/3.2.1",
+		"text/xml; subtype=gml/3.1.1",
+		"application/json; subtype=geojson",
+		"application/json",
+	}, getOperations(&pdoknlv3.WFS{})[2].Parameter[0].AllowedValues.Value)
+}
+
+func TestWFS200BaseMatchesTheCapabilitiesGenerator(t *testing.T) {
+	// base/wfs200.yaml is a copy of the base document of the ogc-capabilities-generator version in go.mod
+	dir, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", "github.com/pdok/ogc-capabilities-generator").Output()
+	if err != nil {
+		t.Fatalf("unable to find the ogc-capabilities-generator module: %v", err)
+	}
+	base, err := os.ReadFile(filepath.Join(string(bytes.TrimSpace(dir)), "base", "wfs200.yaml"))
+	if err != nil {
+		t.Fatalf("unable to read the base document of the ogc-capabilities-generator: %v", err)
+	}
+	if diff := cmp.Diff(string(base), string(wfs200BaseYAML)); diff != "" {
+		t.Errorf("base/wfs200.yaml differs from the ogc-capabilities-generator in go.mod, copy it again: %s", diff)
+	}
+}
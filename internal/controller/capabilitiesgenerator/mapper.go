@@ -1,6 +1,7 @@
 package capabilitiesgenerator
 
 import (
+	_ "embed"
 	"fmt"
 	"slices"
 	"strconv"
@@ -13,11 +14,16 @@ import (
 	"github.com/cbroglie/mustache"
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
 	"github.com/pdok/mapserver-operator/internal/controller/mapperutils"
+	"github.com/pdok/mapserver-operator/internal/crs"
 	capabilitiesgenerator "github.com/pdok/ogc-capabilities-generator/pkg/config"
+	"github.com/pdok/ogc-specifications/pkg/wcs201"
 	"github.com/pdok/ogc-specifications/pkg/wfs200"
 	"github.com/pdok/ogc-specifications/pkg/wsc110"
+	"github.com/pdok/ogc-specifications/pkg/wsc200"
 	smoothoperatorv1 "github.com/pdok/smooth-operator/api/v1"
+	smoothoperatormodel "github.com/pdok/smooth-operator/model"
 	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
+	yamlv3 "sigs.k8s.io/yaml/goyaml.v3"
 )
 
 const (
@@ -25,10 +31,35 @@ const (
 	inspireSchemaLocationsWMS = "http://inspire.ec.europa.eu/schemas/inspire_dls/1.0 http://inspire.ec.europa.eu/schemas/inspire_dls/1.0/inspire_dls.xsd http://inspire.ec.europa.eu/schemas/common/1.0 http://inspire.ec.europa.eu/schemas/common/1.0/common.xsd"
 	wfsCapabilitiesFilename   = "/var/www/config/capabilities_wfs_200.xml"
 	wmsCapabilitiesFilename   = "/var/www/config/capabilities_wms_130.xml"
+	wcsCapabilitiesFilename   = "/var/www/config/capabilities_wcs_201.xml"
 	metadataMediaType         = "application/vnd.ogc.csw.GetRecordByIdResponse_xml"
+	sldMediaType              = "application/vnd.ogc.sld+xml"
+	getFeatureByIDStoredQuery = "urn:ogc:def:query:OGC-WFS::GetFeatureById"
 	XLinkURL                  = "http://www.w3.org/1999/xlink"
 )
 
+//go:embed base/wfs200.yaml
+var wfs200BaseYAML []byte
+
+// wfs200BaseOperationsMetadata is the operations metadata of the base document the capabilities-generator merges
+// the WFS 2.0.0 config into. base/wfs200.yaml is a copy of the one of the ogc-capabilities-generator in go.mod.
+var wfs200BaseOperationsMetadata wfs200.OperationsMetadata
+
+func init() {
+	base := wfs200.GetCapabilitiesResponse{}
+	if err := yamlv3.Unmarshal(wfs200BaseYAML, &base); err != nil {
+		panic(fmt.Errorf("invalid base/wfs200.yaml: %w", err))
+	}
+	wfs200BaseOperationsMetadata = *base.Capabilities.OperationsMetadata
+}
+
+// wcsInterpolations maps the resample kernel of a TIF to the WCS interpolation method
+var wcsInterpolations = map[string]string{
+	"NEAREST":  "http://www.opengis.net/def/interpolation/OGC/1/nearest-neighbor",
+	"BILINEAR": "http://www.opengis.net/def/interpolation/OGC/1/linear",
+	"AVERAGE":  "http://www.opengis.net/def/interpolation/OGC/1/average",
+}
+
 func MapWFSToCapabilitiesGeneratorInput(wfs *pdoknlv3.WFS, ownerInfo *smoothoperatorv1.OwnerInfo) (*capabilitiesgenerator.Config, error) {
 	featureTypeList, err := getFeatureTypeList(wfs, ownerInfo)
 	if err != nil {
@@ -106,98 +137,70 @@ func MapWFSToCapabilitiesGeneratorInput(wfs *pdoknlv3.WFS, ownerInfo *smoothoper
 		operationsMetadata.Constraint = getConstraints(strconv.Itoa(*wfs.Spec.Service.CountDefault))
 		config.Services.WFS200Config.Wfs200.OperationsMetadata = operationsMetadata
 	}
+	if len(wfs.Spec.Service.OutputFormats) > 0 || len(wfs.Spec.Service.StoredQueries) > 0 {
+		operationsMetadata := config.Services.WFS200Config.Wfs200.OperationsMetadata
+		if operationsMetadata == nil {
+			operationsMetadata = &wfs200.OperationsMetadata{}
+		}
+		operationsMetadata.Operation = getOperations(wfs)
+		config.Services.WFS200Config.Wfs200.OperationsMetadata = operationsMetadata
+	}
 
 	return &config, nil
 }
 
+// getOperations returns the operations of the base document with the GetFeature output formats and stored queries
+// of the WFS. The generator only takes the operations of the base when there are none, so all of them are needed.
+// Without output formats the WFS offers those of the base document.
+func getOperations(wfs *pdoknlv3.WFS) []wfs200.Operation {
+	outputFormats := wfs.Spec.Service.OutputFormats
+
+	storedQueryIDs := []string{getFeatureByIDStoredQuery}
+	for _, storedQuery := range wfs.Spec.Service.StoredQueries {
+		storedQueryIDs = append(storedQueryIDs, storedQuery.ID)
+	}
+
+	operations := slices.Clone(wfs200BaseOperationsMetadata.Operation)
+	for i := range operations {
+		switch operations[i].Name {
+		case "GetFeature":
+			if len(outputFormats) == 0 {
+				continue
+			}
+			operations[i].Parameter = []wfs200.Parameter{
+				{Name: "outputFormat", AllowedValues: &wfs200.AllowedValues{Value: outputFormats}},
+			}
+		case "DescribeStoredQueries":
+			operations[i].Parameter = []wfs200.Parameter{
+				{Name: "STOREDQUERY_ID", AllowedValues: &wfs200.AllowedValues{Value: storedQueryIDs}},
+			}
+		}
+	}
+	return operations
+}
+
+// getConstraints returns the constraints of the base document with the CountDefault of the WFS
 func getConstraints(countDefault string) []wfs200.Constraint {
-	return []wfs200.Constraint{
-		{
-			Name:         "ImplementsBasicWFS",
-			DefaultValue: ptr.To("TRUE"),
-		},
-		{
-			Name:         "ImplementsTransactionalWFS",
-			DefaultValue: ptr.To("FALSE"),
-		},
-		{
-			Name:         "ImplementsLockingWFS",
-			DefaultValue: ptr.To("FALSE"),
-		},
-		{
-			Name:         "KVPEncoding",
-			DefaultValue: ptr.To("TRUE"),
-		},
-		{
-			Name:         "XMLEncoding",
-			DefaultValue: ptr.To("TRUE"),
-		},
-		{
-			Name:         "SOAPEncoding",
-			DefaultValue: ptr.To("FALSE"),
-		},
-		{
-			Name:         "ImplementsInheritance",
-			DefaultValue: ptr.To("FALSE"),
-		},
-		{
-			Name:         "ImplementsRemoteResolve",
-			DefaultValue: ptr.To("FALSE"),
-		},
-		{
-			Name:         "ImplementsResultPaging",
-			DefaultValue: ptr.To("TRUE"),
-		},
-		{
-			Name:         "ImplementsStandardJoins",
-			DefaultValue: ptr.To("FALSE"),
-		},
-		{
-			Name:         "ImplementsSpatialJoins",
-			DefaultValue: ptr.To("FALSE"),
-		},
-		{
-			Name:         "ImplementsTemporalJoins",
-			DefaultValue: ptr.To("FALSE"),
-		},
-		{
-			Name:         "ImplementsFeatureVersioning",
-			DefaultValue: ptr.To("FALSE"),
-		},
-		{
-			Name:         "ManageStoredQueries",
-			DefaultValue: ptr.To("FALSE"),
-		},
-		{
-			Name:         "PagingIsTransactionSafe",
-			DefaultValue: ptr.To("FALSE"),
-		},
-		{
-			Name:         "CountDefault",
-			DefaultValue: &countDefault,
-		},
-		{
-			Name: "QueryExpressions",
-			AllowedValues: &wfs200.AllowedValues{Value: []string{
-				"wfs:Query",
-				"wfs:StoredQuery",
-			},
-			},
-		},
+	constraints := slices.Clone(wfs200BaseOperationsMetadata.Constraint)
+	for i := range constraints {
+		if constraints[i].Name == "CountDefault" {
+			constraints[i].DefaultValue = &countDefault
+		}
 	}
+	return constraints
 }
 
 func getFeatureTypeList(wfs *pdoknlv3.WFS, ownerInfo *smoothoperatorv1.OwnerInfo) (*wfs200.FeatureTypeList, error) {
 	typeList := wfs200.FeatureTypeList{}
 
 	for _, fType := range wfs.Spec.Service.FeatureTypes {
-		defaultCRS, err := createCRSFromEpsgString(wfs.Spec.Service.DefaultCrs)
+		defaultCRS, err := createCRSFromEpsgString(wfs.GetDefaultCrs(fType))
 		if err != nil {
 			return nil, err
 		}
 
 		var otherCRS []*wfs200.CRS
-		for _, epsgString := range wfs.Spec.Service.OtherCrs {
+		for _, epsgString := range wfs.GetOtherCrs(fType) {
 			CRS, err := createCRSFromEpsgString(epsgString)
 			if err != nil {
 				return nil, err
@@ -205,29 +208,9 @@ func getFeatureTypeList(wfs *pdoknlv3.WFS, ownerInfo *smoothoperatorv1.OwnerInfo
 			otherCRS = append(otherCRS, CRS)
 		}
 
-		var wgs84BoundingBox *wsc110.WGS84BoundingBox
-		if fType.Bbox != nil && fType.Bbox.WGS84 != nil {
-			minX, err := strconv.ParseFloat(fType.Bbox.WGS84.MinX, 64)
-			if err != nil {
-				return nil, err
-			}
-			maxX, err := strconv.ParseFloat(fType.Bbox.WGS84.MaxX, 64)
-			if err != nil {
-				return nil, err
-			}
-			minY, err := strconv.ParseFloat(fType.Bbox.WGS84.MinY, 64)
-			if err != nil {
-				return nil, err
-			}
-			maxY, err := strconv.ParseFloat(fType.Bbox.WGS84.MaxY, 64)
-			if err != nil {
-				return nil, err
-			}
-
-			wgs84BoundingBox = &wsc110.WGS84BoundingBox{
-				LowerCorner: wsc110.Position{minX, minY},
-				UpperCorner: wsc110.Position{maxX, maxY},
-			}
+		wgs84BoundingBox, err := getWGS84BoundingBox(wfs, fType)
+		if err != nil {
+			return nil, err
 		}
 
 		metadataURL, err := replaceMustacheTemplate(ownerInfo.Spec.MetadataUrls.CSW.HrefTemplate, fType.DatasetMetadataURL.CSW.MetadataIdentifier)
@@ -251,12 +234,55 @@ func getFeatureTypeList(wfs *pdoknlv3.WFS, ownerInfo *smoothoperatorv1.OwnerInfo
 			OtherCRS:         otherCRS,
 			WGS84BoundingBox: wgs84BoundingBox,
 		}
+		if outputFormats := wfs.GetOutputFormats(fType); len(outputFormats) > 0 {
+			featureType.OutputFormats = &wfs200.OutputFormats{Format: outputFormats}
+		}
 
 		typeList.FeatureType = append(typeList.FeatureType, featureType)
 	}
 	return &typeList, nil
 }
 
+// getWGS84BoundingBox returns the WGS84 bbox of the featureType, reprojected from the defaultCRS bbox if omitted
+func getWGS84BoundingBox(wfs *pdoknlv3.WFS, fType pdoknlv3.FeatureType) (*wsc110.WGS84BoundingBox, error) {
+	if fType.Bbox == nil {
+		return nil, nil
+	}
+	bbox := fType.Bbox.WGS84
+	if bbox == nil {
+		if fType.Bbox.DefaultCRS == nil {
+			return nil, nil
+		}
+		reprojected, err := crs.Transform(*fType.Bbox.DefaultCRS, wfs.GetDefaultCrs(fType), crs.WGS84)
+		if err != nil {
+			return nil, err
+		}
+		bbox = &reprojected
+	}
+
+	minX, err := strconv.ParseFloat(bbox.MinX, 64)
+	if err != nil {
+		return nil, err
+	}
+	maxX, err := strconv.ParseFloat(bbox.MaxX, 64)
+	if err != nil {
+		return nil, err
+	}
+	minY, err := strconv.ParseFloat(bbox.MinY, 64)
+	if err != nil {
+		return nil, err
+	}
+	maxY, err := strconv.ParseFloat(bbox.MaxY, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wsc110.WGS84BoundingBox{
+		LowerCorner: wsc110.Position{minX, minY},
+		UpperCorner: wsc110.Position{maxX, maxY},
+	}, nil
+}
+
 func createCRSFromEpsgString(epsgString string) (*wfs200.CRS, error) {
 	index := strings.LastIndex(epsgString, ":")
 	if index == -1 {
@@ -529,7 +555,7 @@ func mapLayer(layer pdoknlv3.Layer, canonicalURL string, authorityURL *wms130.Au
 	}
 
 	l := wms130.Layer{
-		Queryable:               smoothoperatorutils.Pointer(1),
+		Queryable:               smoothoperatorutils.Pointer(0),
 		Opaque:                  nil,
 		Name:                    layer.Name,
 		Title:                   smoothoperatorutils.PointerVal(layer.Title, ""),
@@ -548,6 +574,14 @@ func mapLayer(layer pdoknlv3.Layer, canonicalURL string, authorityURL *wms130.Au
 		Layer:                   []*wms130.Layer{},
 	}
 
+	if layer.IsQueryable() {
+		l.Queryable = smoothoperatorutils.Pointer(1)
+	}
+	if layer.Opaque {
+		l.Opaque = smoothoperatorutils.Pointer("1")
+	}
+	// The cascaded attribute is not offered, wms130.Layer of the capabilities-generator has no such field
+
 	if layer.MinScaleDenominator != nil {
 		float, err := strconv.ParseFloat(*layer.MinScaleDenominator, 64)
 		if err != nil {
@@ -660,9 +694,51 @@ func mapBBoxes(layerBBoxes []pdoknlv3.WMSBoundingBox, parentBBoxes []*wms130.Lay
 		}
 
 	}
+
+	// The EX_GeographicBoundingBox is mandatory, reproject it from the first bbox if there is no CRS:84 bbox
+	if exBbox == nil && len(bboxes) > 0 {
+		var err error
+		if exBbox, err = getEXGeographicBoundingBox(bboxes[0]); err != nil {
+			return nil, nil, nil, err
+		}
+	}
 	return crsses, exBbox, bboxes, nil
 }
 
+func getEXGeographicBoundingBox(bbox *wms130.LayerBoundingBox) (*wms130.EXGeographicBoundingBox, error) {
+	format := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	minX, minY, maxX, maxY := bbox.Minx, bbox.Miny, bbox.Maxx, bbox.Maxy
+	if crs.IsNorthEast(bbox.CRS) {
+		minX, minY, maxX, maxY = minY, minX, maxY, maxX
+	}
+	reprojected, err := crs.Transform(smoothoperatormodel.BBox{
+		MinX: format(minX),
+		MinY: format(minY),
+		MaxX: format(maxX),
+		MaxY: format(maxY),
+	}, bbox.CRS, crs.WGS84)
+	if err != nil {
+		return nil, err
+	}
+
+	values := [4]float64{}
+	for i, value := range []string{reprojected.MinX, reprojected.MinY, reprojected.MaxX, reprojected.MaxY} {
+		if values[i], err = strconv.ParseFloat(value, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	result := wms130.EXGeographicBoundingBox{
+		WestBoundLongitude: values[0],
+		EastBoundLongitude: values[2],
+		SouthBoundLatitude: values[1],
+		NorthBoundLatitude: values[3],
+	}
+	return &result, nil
+}
+
 func getLayerStyles(layer pdoknlv3.Layer, canonicalURL string, parentStyleNames []string) (styles []*wms130.Style) {
 	for _, style := range layer.Styles {
 		if slices.Contains(parentStyleNames, style.Name) {
@@ -673,19 +749,188 @@ func getLayerStyles(layer pdoknlv3.Layer, canonicalURL string, parentStyleNames
 			Name:     style.Name,
 			Title:    smoothoperatorutils.PointerVal(style.Title, ""),
 			Abstract: style.Abstract,
+			// Generated legends (without style.Legend) are the default format and size. The legend-generator writes no
+			// manifest, the format, size and extension are read from the spec just like the legend-generator does.
 			LegendURL: &wms130.LegendURL{
-				Width:  78,
-				Height: 20,
-				Format: "image/png",
+				Width:  int(style.Legend.GetWidth()),
+				Height: int(style.Legend.GetHeight()),
+				Format: style.Legend.GetFormat(),
 				OnlineResource: wms130.OnlineResource{
 					Xlink: smoothoperatorutils.Pointer(XLinkURL),
 					Type:  smoothoperatorutils.Pointer("simple"),
-					Href:  smoothoperatorutils.Pointer(canonicalURL + "/legend/" + *layer.Name + "/" + style.Name + ".png"),
+					Href:  smoothoperatorutils.Pointer(canonicalURL + "/legend/" + *layer.Name + "/" + style.Name + style.Legend.GetExtension()),
 				},
 			},
 			StyleSheetURL: nil,
 		}
+		if style.IsSLD() {
+			// The legend-generator publishes the SLD document next to the legend
+			newStyle.StyleSheetURL = &wms130.StyleSheetURL{
+				Format: sldMediaType,
+				OnlineResource: wms130.OnlineResource{
+					Xlink: smoothoperatorutils.Pointer(XLinkURL),
+					Type:  smoothoperatorutils.Pointer("simple"),
+					Href:  smoothoperatorutils.Pointer(canonicalURL + "/legend/" + *layer.Name + "/" + style.Name + pdoknlv3.SLDExtension),
+				},
+			}
+		}
 		styles = append(styles, &newStyle)
 	}
 	return
 }
+
+func MapWCSToCapabilitiesGeneratorInput(wcs *pdoknlv3.WCS, ownerInfo *smoothoperatorv1.OwnerInfo) (*capabilitiesgenerator.Config, error) {
+	service := wcs.Spec.Service
+
+	provider, err := getWCSServiceProvider(ownerInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	crsSupported := []string{}
+	for _, epsgString := range append([]string{service.DefaultCrs}, service.OtherCrs...) {
+		crs, err := createCRSFromEpsgString(epsgString)
+		if err != nil {
+			return nil, err
+		}
+		crsSupported = append(crsSupported, fmt.Sprintf("http://www.opengis.net/def/crs/EPSG/0/%d", crs.Code))
+	}
+
+	interpolationSupported := []string{}
+	coverageSummaries := []wcs201.CoverageSummary{}
+	for _, coverage := range service.Coverages {
+		interpolation := wcsInterpolations[coverage.Data.TIF.Resample]
+		if interpolation != "" && !slices.Contains(interpolationSupported, interpolation) {
+			interpolationSupported = append(interpolationSupported, interpolation)
+		}
+		coverageSummaries = append(coverageSummaries, wcs201.CoverageSummary{
+			CoverageID:      coverage.Name,
+			CoverageSubtype: "RectifiedGridCoverage",
+		})
+	}
+
+	config := capabilitiesgenerator.Config{
+		Global: capabilitiesgenerator.Global{
+			Namespace:         mapperutils.GetNamespaceURI(service.Prefix, ownerInfo),
+			Prefix:            service.Prefix,
+			Onlineresourceurl: wcs.URL().Scheme + "://" + wcs.URL().Host,
+			Path:              wcs.URL().Path,
+		},
+		Services: capabilitiesgenerator.Services{
+			WCS201Config: &capabilitiesgenerator.WCS201Config{
+				Filename: wcsCapabilitiesFilename,
+				Wcs201: wcs201.GetCapabilitiesResponse{
+					ServiceProvider: mapWCSServiceProvider(provider, ownerInfo.Spec.ProviderSite),
+					ServiceIdentification: wcs201.ServiceIdentification{
+						Title:             service.Title,
+						Abstract:          service.Abstract,
+						AccessConstraints: service.AccessConstraints.String(),
+						Keywords: &wsc200.Keywords{
+							Keyword: service.KeywordsIncludingInspireKeyword(),
+						},
+						Fees: smoothoperatorutils.PointerVal(service.Fees, ""),
+					},
+					ServiceMetadata: wcs201.ServiceMetadata{
+						FormatSupported: wcs.GetOutputFormats(),
+						Extension: wcs201.Extension{
+							InterpolationMetadata: wcs201.InterpolationMetadata{
+								InterpolationSupported: interpolationSupported,
+							},
+							CrsMetadata: wcs201.CrsMetadata{
+								CrsSupported: crsSupported,
+							},
+						},
+					},
+					Contents: wcs201.Contents{
+						CoverageSummary: coverageSummaries,
+					},
+				},
+			},
+		},
+	}
+
+	if service.Inspire != nil {
+		var metadataURL wcs201.MetadataURL
+		if service.Inspire.ServiceMetadataURL.CSW != nil {
+			url, err := replaceMustacheTemplate(ownerInfo.Spec.MetadataUrls.CSW.HrefTemplate, service.Inspire.ServiceMetadataURL.CSW.MetadataIdentifier)
+			if err != nil {
+				return nil, err
+			}
+			metadataURL.URL = url
+			metadataURL.MediaType = metadataMediaType
+		}
+
+		if service.Inspire.ServiceMetadataURL.Custom != nil {
+			metadataURL.URL = service.Inspire.ServiceMetadataURL.Custom.Href.String()
+			metadataURL.MediaType = service.Inspire.ServiceMetadataURL.Custom.Type
+		}
+
+		wcs201Config := config.Services.WCS201Config
+		wcs201Config.Wcs201.XmlnsInspireCommon = "http://inspire.ec.europa.eu/schemas/common/1.0"
+		wcs201Config.Wcs201.XmlnsInspireDls = "http://inspire.ec.europa.eu/schemas/inspire_dls/1.0"
+		wcs201Config.Wcs201.OperationsMetadata.ExtendedCapabilities = &wcs201.ExtendedCapabilities{
+			ExtendedCapabilities: wcs201.NestedExtendedCapabilities{
+				MetadataURL:      metadataURL,
+				ResponseLanguage: wcs201.Language{Language: service.Inspire.Language},
+				SpatialDataSetIdentifier: wcs201.SpatialDataSetIdentifier{
+					Code: service.Inspire.SpatialDatasetIdentifier,
+				},
+			},
+		}
+	}
+
+	return &config, nil
+}
+
+// getWCSServiceProvider returns the OWS service provider for the WCS capabilities.
+// The OwnerInfo has no wcs section, WCS 2.0.1 and WFS 2.0.0 share the OWS ServiceProvider so the wfs section is used,
+// the webhook requires it for a WCS.
+func getWCSServiceProvider(ownerInfo *smoothoperatorv1.OwnerInfo) (*smoothoperatorv1.ServiceProvider, error) {
+	if ownerInfo.Spec.WFS == nil {
+		return nil, fmt.Errorf("spec.WFS missing in ownerInfo %s, it holds the service provider of the WCS", ownerInfo.Name)
+	}
+	return &ownerInfo.Spec.WFS.ServiceProvider, nil
+}
+
+func mapWCSServiceProvider(provider *smoothoperatorv1.ServiceProvider, providerSite *smoothoperatorv1.ProviderSite) (serviceProvider wcs201.ServiceProvider) {
+	serviceProvider.ProviderName = smoothoperatorutils.PointerVal(provider.ProviderName, "")
+
+	if providerSite != nil {
+		serviceProvider.ProviderSite = wcs201.ProviderSite{
+			Type: providerSite.Type,
+			Href: providerSite.Href,
+		}
+	}
+
+	if contact := provider.ServiceContact; contact != nil {
+		serviceProvider.ServiceContact = wcs201.ServiceContact{
+			IndividualName: smoothoperatorutils.PointerVal(contact.IndividualName, ""),
+			PositionName:   smoothoperatorutils.PointerVal(contact.PositionName, ""),
+			Role:           smoothoperatorutils.PointerVal(contact.Role, ""),
+		}
+		if contactInfo := contact.ContactInfo; contactInfo != nil {
+			serviceContactInfo := &serviceProvider.ServiceContact.ContactInfo
+			serviceContactInfo.HoursOfService = smoothoperatorutils.PointerVal(contactInfo.HoursOfService, "")
+			serviceContactInfo.ContactInstructions = smoothoperatorutils.PointerVal(contactInfo.ContactInstructions, "")
+			if contactInfo.Phone != nil {
+				serviceContactInfo.Phone = wcs201.Phone{
+					Voice:     smoothoperatorutils.PointerVal(contactInfo.Phone.Voice, ""),
+					Facsimile: smoothoperatorutils.PointerVal(contactInfo.Phone.Facsimile, ""),
+				}
+			}
+			if address := contactInfo.Address; address != nil {
+				serviceContactInfo.Address = wcs201.Address{
+					DeliveryPoint:         smoothoperatorutils.PointerVal(address.DeliveryPoint, ""),
+					City:                  smoothoperatorutils.PointerVal(address.City, ""),
+					AdministrativeArea:    smoothoperatorutils.PointerVal(address.AdministrativeArea, ""),
+					PostalCode:            smoothoperatorutils.PointerVal(address.PostalCode, ""),
+					Country:               smoothoperatorutils.PointerVal(address.Country, ""),
+					ElectronicMailAddress: smoothoperatorutils.PointerVal(address.ElectronicMailAddress, ""),
+				}
+			}
+			// The OnlineResource is filled with the service url by the capabilities generator
+		}
+	}
+
+	return serviceProvider
+}
@@ -0,0 +1,98 @@
+package cog
+
+import (
+	_ "embed"
+	"path"
+	"slices"
+	"strings"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/constants"
+	"github.com/pdok/mapserver-operator/internal/controller/prefetch"
+	"github.com/pdok/mapserver-operator/internal/controller/types"
+	"github.com/pdok/mapserver-operator/internal/controller/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// tifsContainer is the container of the ${BLOBS_TIF_BUCKET} variable
+const tifsContainer = "tifs"
+
+//go:embed cog_validation.sh
+var Script string
+
+// env tunes GDAL for range requests on streamed TIFFs, every variable can be overridden through the podSpecPatch.
+// The caches are per mapserver process.
+var env = []corev1.EnvVar{
+	// Reads the ranges of a request in parallel
+	{Name: "GDAL_HTTP_MULTIRANGE", Value: "YES"},
+	// Caches the blocks that were read, e.g. the headers and overviews of the TIFFs
+	{Name: "VSI_CACHE", Value: "TRUE"},
+	// In MB
+	{Name: "GDAL_CACHEMAX", Value: "64"},
+	// In bytes, 64MB
+	{Name: "CPL_VSIL_CURL_CACHE_SIZE", Value: "67108864"},
+}
+
+// GetStreamedBlobKeys returns the sorted blobKeys of the TIFFs that mapserver streams from blob storage
+func GetStreamedBlobKeys[O pdoknlv3.WMSWFS](obj O) []string {
+	var tiffBlobKeys []string
+	switch webservice := any(obj).(type) {
+	case *pdoknlv3.WMS:
+		tiffBlobKeys = webservice.GetUniqueTiffBlobKeys()
+	case *pdoknlv3.WCS:
+		tiffBlobKeys = webservice.GetUniqueTiffBlobKeys()
+	}
+	streamed := prefetch.GetStreamedBlobKeys(obj)
+	return slices.DeleteFunc(tiffBlobKeys, func(blobKey string) bool { return !slices.Contains(streamed, blobKey) })
+}
+
+// UseStreaming returns whether one or more TIFFs are streamed from blob storage
+func UseStreaming[O pdoknlv3.WMSWFS](obj O) bool {
+	return len(GetStreamedBlobKeys(obj)) > 0
+}
+
+// GetStreamPath returns the GDAL path that streams the blob from blob storage
+func GetStreamPath(blobKey string) string {
+	return path.Join("/vsiaz", utils.ReplaceBucketVariable(blobKey, tifsContainer))
+}
+
+// GetEnv returns the GDAL configuration of the mapserver container for streamed TIFFs
+func GetEnv() []corev1.EnvVar {
+	return slices.Clone(env)
+}
+
+// GetSources returns the input of the cog-validation script, one path per line
+func GetSources[O pdoknlv3.WMSWFS](obj O) string {
+	lines := []string{}
+	for _, blobKey := range GetStreamedBlobKeys(obj) {
+		lines = append(lines, GetStreamPath(blobKey))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// GetCOGValidationInitContainer returns the init container that validates the streamed TIFFs before mapserver starts.
+// It uses the mapserver image, so it reads the blobs with the same GDAL and environment as mapserver.
+func GetCOGValidationInitContainer[O pdoknlv3.WMSWFS](obj O, images types.Images) *corev1.Container {
+	return &corev1.Container{
+		Name:            constants.COGValidationName,
+		Image:           images.MapserverImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"bash", "-c", Script},
+		Env: []corev1.EnvVar{
+			{Name: "COG_VALIDATION_SOURCES", Value: GetSources(obj)},
+		},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("0.5"),
+				corev1.ResourceMemory: resource.MustParse("256M"),
+			},
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("0.1"),
+			},
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package cog
+
+import (
+	"strings"
+	"testing"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/constants"
+	"github.com/pdok/mapserver-operator/internal/controller/types"
+	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func getWMS(prefetchData bool) *pdoknlv3.WMS {
+	tifLayer := func(name, blobKey string, prefetch *string) pdoknlv3.Layer {
+		return pdoknlv3.Layer{
+			Name: smoothoperatorutils.Pointer(name),
+			Data: &pdoknlv3.Data{TIF: &pdoknlv3.TIF{BlobKey: blobKey, Prefetch: prefetch}},
+		}
+	}
+	return &pdoknlv3.WMS{
+		Spec: pdoknlv3.WMSSpec{
+			Options: &pdoknlv3.Options{BaseOptions: pdoknlv3.BaseOptions{PrefetchData: prefetchData}},
+			Service: pdoknlv3.WMSService{
+				Layer: pdoknlv3.Layer{
+					Name: smoothoperatorutils.Pointer("top"),
+					Layers: []pdoknlv3.Layer{
+						tifLayer("default", "tifs-bucket/key/default.tif", nil),
+						tifLayer("mosaic", "tifs-bucket/key/mosaic.vrt", smoothoperatorutils.Pointer(pdoknlv3.PrefetchNever)),
+						tifLayer("small", "tifs-bucket/key/small.tif", smoothoperatorutils.Pointer(pdoknlv3.PrefetchAlways)),
+						// The blob is prefetched for the other layer
+						tifLayer("shared", "tifs-bucket/key/small.tif", smoothoperatorutils.Pointer(pdoknlv3.PrefetchNever)),
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGetStreamedBlobKeys(t *testing.T) {
+	assert.Equal(t, []string{"tifs-bucket/key/mosaic.vrt"}, GetStreamedBlobKeys(getWMS(true)))
+	assert.Equal(t, []string{"tifs-bucket/key/default.tif", "tifs-bucket/key/mosaic.vrt"}, GetStreamedBlobKeys(getWMS(false)))
+
+	wcs := &pdoknlv3.WCS{
+		Spec: pdoknlv3.WCSSpec{
+			Options: &pdoknlv3.BaseOptions{PrefetchData: true},
+			Service: pdoknlv3.WCSService{
+				Coverages: []pdoknlv3.Coverage{
+					{Name: "prefetched", Data: pdoknlv3.CoverageData{TIF: pdoknlv3.TIF{BlobKey: "tifs-bucket/key/prefetched.tif"}}},
+					{Name: "streamed", Data: pdoknlv3.CoverageData{TIF: pdoknlv3.TIF{BlobKey: "tifs-bucket/key/streamed.tif", Prefetch: smoothoperatorutils.Pointer(pdoknlv3.PrefetchNever)}}},
+				},
+			},
+		},
+	}
+	assert.Equal(t, []string{"tifs-bucket/key/streamed.tif"}, GetStreamedBlobKeys(wcs))
+	assert.False(t, UseStreaming(&pdoknlv3.WFS{}))
+}
+
+func TestGetStreamPath(t *testing.T) {
+	assert.Equal(t, "/vsiaz/tifs-bucket/key/file.tif", GetStreamPath("tifs-bucket/key/file.tif"))
+	assert.Equal(t, "/vsiaz/tifs/key/file.tif", GetStreamPath("${BLOBS_TIF_BUCKET}/key/file.tif"))
+	assert.Equal(t, "/vsiaz/tifs-bucket/${BLOBS_TIF_BUCKET}/file.tif", GetStreamPath("tifs-bucket/${BLOBS_TIF_BUCKET}/file.tif"))
+}
+
+func TestGetSources(t *testing.T) {
+	assert.Equal(t, "/vsiaz/tifs-bucket/key/mosaic.vrt\n", GetSources(getWMS(true)))
+	wms := getWMS(false)
+	wms.Spec.Service.Layer.Layers = nil
+	assert.Empty(t, GetSources(wms))
+}
+
+func TestGetCOGValidationInitContainer(t *testing.T) {
+	container := GetCOGValidationInitContainer(getWMS(false), types.Images{MapserverImage: "mapserver"})
+	assert.Equal(t, constants.COGValidationName, container.Name)
+	assert.Equal(t, "mapserver", container.Image)
+	assert.Equal(t, "/vsiaz/tifs-bucket/key/default.tif\n/vsiaz/tifs-bucket/key/mosaic.vrt\n", container.Env[0].Value)
+	assert.True(t, strings.HasPrefix(container.Command[2], "#!/usr/bin/env bash"))
+}
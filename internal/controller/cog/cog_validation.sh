@@ -0,0 +1,43 @@
+#!/usr/bin/env bash
+
+# Validates the TIFFs that mapserver streams from blob storage, one path per line in COG_VALIDATION_SOURCES.
+# Every streamed path has to be readable. A TIFF should be a cloud optimized GeoTIFF, otherwise every request reads
+# far more of the blob than it needs. Only GDAL's COG driver writes the LAYOUT=COG metadata, so a TIFF without it
+# can still be a valid COG and only gets a warning. A VRT isn't a COG itself.
+
+set -uo pipefail
+
+function log() {
+    echo msg=\""$1"\" "${@:2}"
+}
+
+function validate() {
+    local source=$1
+
+    local info
+    if ! info=$(gdalinfo "$source"); then
+        log "Unable to read streamed data" source=\""$source"\"
+        return 1
+    fi
+    case $source in
+        *.vrt)
+            ;;
+        *)
+            if ! echo "$info" | grep -q "LAYOUT=COG"; then
+                log "Streamed TIFF has no COG layout, make sure it is a cloud optimized GeoTIFF or prefetch it" source=\""$source"\" level=warning
+            fi
+            ;;
+    esac
+    log "Validated streamed data" source=\""$source"\"
+}
+
+function main() {
+    local failed=0
+    while read -r source; do
+        [ -n "$source" ] || continue
+        validate "$source" || failed=1
+    done <<< "$COG_VALIDATION_SOURCES"
+    exit $failed
+}
+
+main
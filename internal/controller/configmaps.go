@@ -2,11 +2,16 @@ package controller
 
 import (
 	"fmt"
+	"maps"
+	"strconv"
 	"strings"
 
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/autoextent"
 	"github.com/pdok/mapserver-operator/internal/controller/blobdownload"
 	"github.com/pdok/mapserver-operator/internal/controller/capabilitiesgenerator"
+	"github.com/pdok/mapserver-operator/internal/controller/constants"
+	"github.com/pdok/mapserver-operator/internal/controller/datarefresh"
 	"github.com/pdok/mapserver-operator/internal/controller/mapfilegenerator"
 	"github.com/pdok/mapserver-operator/internal/controller/static"
 	smoothoperatorv1 "github.com/pdok/smooth-operator/api/v1"
@@ -31,7 +36,8 @@ func mutateConfigMapCapabilitiesGenerator[R Reconciler, O pdoknlv3.WMSWFS](r R,
 	}
 
 	if len(configMap.Data) == 0 {
-		input, err := capabilitiesgenerator.GetInput(obj, ownerInfo)
+		// The extents read from the data replace the omitted bboxes
+		input, err := capabilitiesgenerator.GetInput(autoextent.WithExtents(obj), ownerInfo)
 		if err != nil {
 			return err
 		}
@@ -57,11 +63,14 @@ func mutateConfigMapMapfileGenerator[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O
 	}
 
 	if len(configMap.Data) == 0 {
-		mapfileGeneratorConfig, err := mapfilegenerator.GetConfig(obj, ownerInfo)
+		mapfileGeneratorConfig, err := mapfilegenerator.GetConfig(autoextent.WithExtents(obj), ownerInfo)
 		if err != nil {
 			return err
 		}
 		configMap.Data = map[string]string{mapfileGeneratorInput: mapfileGeneratorConfig}
+		if wms, ok := any(obj).(*pdoknlv3.WMS); ok {
+			maps.Copy(configMap.Data, mapfilegenerator.GetColormapStyles(wms))
+		}
 	}
 	configMap.Immutable = smoothoperatorutils.Pointer(true)
 
@@ -85,6 +94,9 @@ func mutateConfigMapBlobDownload[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O, co
 	if len(configMap.Data) == 0 {
 		downloadScript := blobdownload.GetScript()
 		configMap.Data = map[string]string{downloadScriptName: downloadScript}
+		if datarefresh.UseDataRefresh(obj) {
+			configMap.Data[datarefresh.ScriptName] = datarefresh.Script
+		}
 	}
 	configMap.Immutable = smoothoperatorutils.Pointer(true)
 
@@ -97,6 +109,24 @@ func mutateConfigMapBlobDownload[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O, co
 	return smoothoperatorutils.AddHashSuffix(configMap)
 }
 
+// mutateConfigMapDataManifest sets the geopackages for the data-refresh sidecar. Unlike the other ConfigMaps
+// this one is mutable and not hashed, so a new blobKey doesn't change the pod template.
+func mutateConfigMapDataManifest[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O, configMap *corev1.ConfigMap) error {
+	reconcilerClient := getReconcilerClient(r)
+
+	labels := addCommonLabels(obj, smoothoperatorutils.CloneOrEmptyMap(obj.GetLabels()))
+	if err := smoothoperatorutils.SetImmutableLabels(reconcilerClient, configMap, labels); err != nil {
+		return err
+	}
+
+	configMap.Data = map[string]string{datarefresh.ManifestKey: datarefresh.GetManifest(obj)}
+
+	if err := smoothoperatorutils.EnsureSetGVK(reconcilerClient, configMap, configMap); err != nil {
+		return err
+	}
+	return ctrl.SetControllerReference(obj, configMap, getReconcilerScheme(r))
+}
+
 func getBareConfigMap[O pdoknlv3.WMSWFS](obj O, name string) *corev1.ConfigMap {
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -137,10 +167,16 @@ func updateConfigMapWithStaticFiles[O pdoknlv3.WMSWFS](configMap *corev1.ConfigM
 			rewriteRules := make([]string, 0)
 			for _, ingressRouteURL := range ingressRouteUrls {
 				rewriteRules = append(rewriteRules, fmt.Sprintf("  \"%s/legend(.*)\" => \"/legend$1\"", ingressRouteURL.URL.Path))
+				if useOgcAPIFeatures(obj) {
+					rewriteRules = append(rewriteRules, fmt.Sprintf("  \"%s%s(.*)\" => \"%s$1\"", ingressRouteURL.URL.Path, constants.OgcAPIPath, constants.MapserverOgcAPIPath))
+				}
 				rewriteRules = append(rewriteRules, fmt.Sprintf("  \"%s(.*)\" => \"/mapserver$1\"", ingressRouteURL.URL.Path))
 			}
 
-			content = []byte(strings.ReplaceAll(string(content), "{{ rewrite_rules }}", strings.Join(rewriteRules, ",\n")))
+			content = []byte(strings.NewReplacer(
+				"{{ port }}", strconv.Itoa(int(constants.MapserverContainerPortNr)),
+				"{{ rewrite_rules }}", strings.Join(rewriteRules, ",\n"),
+			).Replace(string(content)))
 		}
 		configMap.Data[name] = string(content)
 	}
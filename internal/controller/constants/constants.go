@@ -8,11 +8,18 @@ const (
 	BlobDownloadName          = "blob-download"
 	InitScriptsName           = "init-scripts"
 	LegendGeneratorName       = "legend-generator"
-	LegendFixerName           = "legend-fixer"
 	FeatureinfoGeneratorName  = "featureinfo-generator"
+	FeatureInfoTemplatesName  = "featureinfo-templates"
+	DataManifestName          = "data-manifest"
+	DataRefreshName           = "data-refresh"
+	AutoExtentName            = "auto-extent"
+	COGValidationName         = "cog-validation"
 
 	BaseVolumeName = "base"
 	DataVolumeName = "data"
+	TmpVolumeName  = "tmp"
+	// VarTmpVolumeName is the writable /var/tmp of mapserver, where lighttpd buffers request bodies
+	VarTmpVolumeName = "var-tmp"
 
 	configSuffix                             = "-config"
 	ConfigMapMapfileGeneratorVolumeName      = MapfileGeneratorName + configSuffix
@@ -22,8 +29,21 @@ const (
 	ConfigMapLegendGeneratorVolumeName       = LegendGeneratorName + configSuffix
 	ConfigMapFeatureinfoGeneratorVolumeName  = FeatureinfoGeneratorName + configSuffix
 	ConfigMapCustomMapfileVolumeName         = "mapfile"
+	ConfigMapFeatureInfoTemplatesVolumeName  = FeatureInfoTemplatesName
 
-	HTMLTemplatesPath       = "/srv/data/config/templates"
-	MapserverPortNr   int32 = 80
-	ApachePortNr      int32 = 9117
+	// OgcAPIPath is the path of the OGC API Features, relative to the service URL
+	OgcAPIPath = "/ogc"
+	// MapserverOgcAPIPath is where mapserver serves the OGC API, MAP is the key of the mapfile in default_mapserver.conf
+	MapserverOgcAPIPath = "/mapserver/MAP/ogcapi"
+
+	HTMLTemplatesPath = "/srv/data/config/templates"
+	// SLDPath is where the SLD documents on blob storage are downloaded to
+	SLDPath = "/srv/data/config/sld"
+	// MapserverPortNr is the port of the Service, it targets MapserverContainerPortNr
+	MapserverPortNr int32 = 80
+	// MapserverContainerPortNr is the unprivileged port lighttpd listens on, the containers run without NET_BIND_SERVICE
+	MapserverContainerPortNr int32 = 8080
+	ApachePortNr             int32 = 9117
+	// RunAsUserID is the UID/GID the scripts chown their output to
+	RunAsUserID int64 = 999
 )
@@ -0,0 +1,340 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/constants"
+	"github.com/pdok/mapserver-operator/internal/controller/datacache"
+	"github.com/pdok/mapserver-operator/internal/controller/types"
+	"github.com/pdok/mapserver-operator/internal/controller/utils"
+	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	dataCacheConfigVolumeName = "data-cache-config"
+	dataCacheConfigPath       = "/srv/data-cache-config"
+)
+
+// dataCacheVolumeCapacity is nominal, a hostPath PersistentVolume doesn't enforce its capacity
+var dataCacheVolumeCapacity = resource.MustParse("1Gi")
+
+func SetDataCacheConfig(config types.DataCacheConfig) {
+	datacache.SetConfig(config)
+}
+
+func getDataCacheLabels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       datacache.Name,
+		"app.kubernetes.io/managed-by": "mapserver-operator",
+	}
+}
+
+func getBareDataCacheConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      datacache.Name,
+			Namespace: datacache.GetConfig().Namespace,
+		},
+	}
+}
+
+func mutateDataCacheConfigMap(c client.Client, configMap *corev1.ConfigMap, blobKeys []string) error {
+	if err := smoothoperatorutils.SetImmutableLabels(c, configMap, getDataCacheLabels()); err != nil {
+		return err
+	}
+	configMap.Data = map[string]string{
+		datacache.ManifestKey: datacache.GetManifest(blobKeys),
+		datacache.ScriptKey:   datacache.Script,
+	}
+	return smoothoperatorutils.EnsureSetGVK(c, configMap, configMap)
+}
+
+func getBareDataCacheDaemonSet() *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      datacache.Name,
+			Namespace: datacache.GetConfig().Namespace,
+		},
+	}
+}
+
+func mutateDataCacheDaemonSet(c client.Client, daemonSet *appsv1.DaemonSet, images types.Images) error {
+	config := datacache.GetConfig()
+	labels := getDataCacheLabels()
+	if err := smoothoperatorutils.SetImmutableLabels(c, daemonSet, labels); err != nil {
+		return err
+	}
+
+	containers := []corev1.Container{{
+		Name:            datacache.VolumeName,
+		Image:           images.MultitoolImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"bash", dataCacheConfigPath + "/" + datacache.ScriptKey},
+		Env: []corev1.EnvVar{
+			{Name: "DATA_CACHE_PATH", Value: datacache.MountPath},
+			{Name: "DATA_CACHE_MANIFEST", Value: dataCacheConfigPath + "/" + datacache.ManifestKey},
+			{Name: "DATA_CACHE_REFRESH_INTERVAL", Value: strconv.Itoa(int(config.RefreshIntervalSecs))},
+			{Name: "RCLONE_CONFIG", Value: "/tmp/rclone.conf"},
+		},
+		EnvFrom: []corev1.EnvFromSource{
+			utils.NewEnvFromSource(utils.EnvFromSourceTypeConfigMap, config.BlobsConfigMapName),
+			utils.NewEnvFromSource(utils.EnvFromSourceTypeSecret, config.BlobsSecretName),
+		},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("0.1"),
+				corev1.ResourceMemory: resource.MustParse("64M"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("1"),
+				corev1.ResourceMemory: resource.MustParse("256M"),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: datacache.VolumeName, MountPath: datacache.MountPath},
+			{Name: dataCacheConfigVolumeName, MountPath: dataCacheConfigPath, ReadOnly: true},
+		},
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+	}}
+	// Writing to the hostPath requires root, everything else is locked down
+	setSecurityContext(containers)
+	containers[0].SecurityContext.RunAsNonRoot = smoothoperatorutils.Pointer(false)
+	containers[0].SecurityContext.RunAsUser = smoothoperatorutils.Pointer(int64(0))
+
+	daemonSet.Spec = appsv1.DaemonSetSpec{
+		Selector: &metav1.LabelSelector{
+			MatchLabels: smoothoperatorutils.CloneOrEmptyMap(labels),
+		},
+		UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType},
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: smoothoperatorutils.CloneOrEmptyMap(labels),
+			},
+			Spec: corev1.PodSpec{
+				Containers: containers,
+				Volumes: []corev1.Volume{
+					datacache.GetHostPathVolume(),
+					getConfigMapVolume(dataCacheConfigVolumeName, datacache.Name),
+					{Name: constants.TmpVolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+				},
+				Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			},
+		},
+	}
+
+	return smoothoperatorutils.EnsureSetGVK(c, daemonSet, daemonSet)
+}
+
+// getBareDataCachePersistentVolume returns the cluster-scoped PersistentVolume that binds the data cache to the claim of the webservice
+func getBareDataCachePersistentVolume[O pdoknlv3.WMSWFS](obj O) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: obj.GetNamespace() + "-" + datacache.GetClaimName(obj),
+		},
+	}
+}
+
+func mutateDataCachePersistentVolume[O pdoknlv3.WMSWFS](c client.Client, obj O, persistentVolume *corev1.PersistentVolume) error {
+	if err := smoothoperatorutils.SetImmutableLabels(c, persistentVolume, getDataCacheLabels()); err != nil {
+		return err
+	}
+
+	// Keep the uid of a bound claim, a released volume is reset so it binds to a new claim with the same name
+	claimRef := persistentVolume.Spec.ClaimRef
+	if claimRef == nil || claimRef.Namespace != obj.GetNamespace() || claimRef.Name != datacache.GetClaimName(obj) ||
+		persistentVolume.Status.Phase == corev1.VolumeReleased {
+		claimRef = &corev1.ObjectReference{
+			Kind:       "PersistentVolumeClaim",
+			APIVersion: "v1",
+			Namespace:  obj.GetNamespace(),
+			Name:       datacache.GetClaimName(obj),
+		}
+	}
+
+	persistentVolume.Spec = corev1.PersistentVolumeSpec{
+		Capacity: corev1.ResourceList{corev1.ResourceStorage: dataCacheVolumeCapacity},
+		PersistentVolumeSource: corev1.PersistentVolumeSource{
+			HostPath: datacache.GetHostPathVolumeSource(),
+		},
+		AccessModes:                   []corev1.PersistentVolumeAccessMode{corev1.ReadOnlyMany},
+		ClaimRef:                      claimRef,
+		PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+		StorageClassName:              "",
+	}
+	return smoothoperatorutils.EnsureSetGVK(c, persistentVolume, persistentVolume)
+}
+
+func getBareDataCachePersistentVolumeClaim[O pdoknlv3.WMSWFS](obj O) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      datacache.GetClaimName(obj),
+			Namespace: obj.GetNamespace(),
+		},
+	}
+}
+
+func mutateDataCachePersistentVolumeClaim[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O, persistentVolumeClaim *corev1.PersistentVolumeClaim) error {
+	reconcilerClient := getReconcilerClient(r)
+
+	labels := addCommonLabels(obj, smoothoperatorutils.CloneOrEmptyMap(obj.GetLabels()))
+	if err := smoothoperatorutils.SetImmutableLabels(reconcilerClient, persistentVolumeClaim, labels); err != nil {
+		return err
+	}
+
+	// The spec of a claim is immutable once it is bound
+	if persistentVolumeClaim.CreationTimestamp.IsZero() {
+		persistentVolumeClaim.Spec = corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadOnlyMany},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: dataCacheVolumeCapacity},
+			},
+			StorageClassName: smoothoperatorutils.Pointer(""),
+			VolumeName:       getBareDataCachePersistentVolume(obj).Name,
+		}
+	}
+
+	if err := smoothoperatorutils.EnsureSetGVK(reconcilerClient, persistentVolumeClaim, persistentVolumeClaim); err != nil {
+		return err
+	}
+	return ctrl.SetControllerReference(obj, persistentVolumeClaim, getReconcilerScheme(r))
+}
+
+// createOrUpdateOrDeleteDataCacheVolume binds the data cache to the pods of a webservice that uses it.
+// The PersistentVolume can't be owned by the webservice, it is released with the claim and pruned by the DataCacheReconciler.
+func createOrUpdateOrDeleteDataCacheVolume[O pdoknlv3.WMSWFS, R Reconciler](ctx context.Context, reconciler R, obj O, operationResults map[string]controllerutil.OperationResult) (err error) {
+	reconcilerClient := getReconcilerClient(reconciler)
+	persistentVolumeClaim := getBareDataCachePersistentVolumeClaim(obj)
+	if !datacache.UseDataCache(obj) {
+		err = reconcilerClient.Delete(ctx, persistentVolumeClaim)
+		if err == nil {
+			operationResults[smoothoperatorutils.GetObjectFullName(reconcilerClient, persistentVolumeClaim)] = "deleted"
+		}
+		if client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("unable to delete resource %s: %w", smoothoperatorutils.GetObjectFullName(reconcilerClient, persistentVolumeClaim), err)
+		}
+		return nil
+	}
+
+	persistentVolume := getBareDataCachePersistentVolume(obj)
+	operationResults[smoothoperatorutils.GetObjectFullName(reconcilerClient, persistentVolume)], err = controllerutil.CreateOrUpdate(ctx, reconcilerClient, persistentVolume, func() error {
+		return mutateDataCachePersistentVolume(reconcilerClient, obj, persistentVolume)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create/update resource %s: %w", smoothoperatorutils.GetObjectFullName(reconcilerClient, persistentVolume), err)
+	}
+
+	operationResults[smoothoperatorutils.GetObjectFullName(reconcilerClient, persistentVolumeClaim)], err = controllerutil.CreateOrUpdate(ctx, reconcilerClient, persistentVolumeClaim, func() error {
+		return mutateDataCachePersistentVolumeClaim(reconciler, obj, persistentVolumeClaim)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create/update resource %s: %w", smoothoperatorutils.GetObjectFullName(reconcilerClient, persistentVolumeClaim), err)
+	}
+	return nil
+}
+
+// pruneDataCachePersistentVolumes deletes the PersistentVolumes of the data cache whose claim is gone,
+// this only removes the binding, the data cache itself is cleaned up by the DaemonSet
+func pruneDataCachePersistentVolumes(ctx context.Context, c client.Client) error {
+	persistentVolumes := &corev1.PersistentVolumeList{}
+	if err := c.List(ctx, persistentVolumes, client.MatchingLabels(getDataCacheLabels())); err != nil {
+		return err
+	}
+	for i := range persistentVolumes.Items {
+		if persistentVolume := &persistentVolumes.Items[i]; persistentVolume.Status.Phase == corev1.VolumeReleased {
+			if err := c.Delete(ctx, persistentVolume); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("unable to delete resource %s: %w", smoothoperatorutils.GetObjectFullName(c, persistentVolume), err)
+			}
+		}
+	}
+	return nil
+}
+
+// getDataCacheBlobKeys collects the blobs of all webservices in the cluster that use the data cache
+func getDataCacheBlobKeys(ctx context.Context, c client.Client) ([]string, error) {
+	blobKeys := []string{}
+
+	wmsList := &pdoknlv3.WMSList{}
+	if err := c.List(ctx, wmsList); err != nil {
+		return nil, err
+	}
+	for i := range wmsList.Items {
+		if wms := &wmsList.Items[i]; datacache.UseDataCache(wms) && wms.GetDeletionTimestamp() == nil {
+			blobKeys = append(blobKeys, datacache.GetBlobKeys(wms)...)
+		}
+	}
+
+	wfsList := &pdoknlv3.WFSList{}
+	if err := c.List(ctx, wfsList); err != nil {
+		return nil, err
+	}
+	for i := range wfsList.Items {
+		if wfs := &wfsList.Items[i]; datacache.UseDataCache(wfs) && wfs.GetDeletionTimestamp() == nil {
+			blobKeys = append(blobKeys, datacache.GetBlobKeys(wfs)...)
+		}
+	}
+
+	wcsList := &pdoknlv3.WCSList{}
+	if err := c.List(ctx, wcsList); err != nil {
+		return nil, err
+	}
+	for i := range wcsList.Items {
+		if wcs := &wcsList.Items[i]; datacache.UseDataCache(wcs) && wcs.GetDeletionTimestamp() == nil {
+			blobKeys = append(blobKeys, datacache.GetBlobKeys(wcs)...)
+		}
+	}
+
+	return blobKeys, nil
+}
+
+// createOrUpdateOrDeleteDataCache keeps the manifest and DaemonSet of the node-local data cache in sync with all
+// webservices and deletes them when no webservice uses the data cache anymore. The manifest is the only thing that
+// changes when blobs are added or removed, so the DaemonSet is not restarted.
+func createOrUpdateOrDeleteDataCache(ctx context.Context, c client.Client, images types.Images) error {
+	if err := pruneDataCachePersistentVolumes(ctx, c); err != nil {
+		return fmt.Errorf("unable to prune the persistent volumes of the data cache: %w", err)
+	}
+	if !datacache.GetConfig().Enabled {
+		return nil
+	}
+
+	blobKeys, err := getDataCacheBlobKeys(ctx, c)
+	if err != nil {
+		return fmt.Errorf("unable to list the blobs for the data cache: %w", err)
+	}
+
+	configMap := getBareDataCacheConfigMap()
+	daemonSet := getBareDataCacheDaemonSet()
+	if len(blobKeys) == 0 {
+		for _, obj := range []client.Object{daemonSet, configMap} {
+			if err = c.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("unable to delete resource %s: %w", smoothoperatorutils.GetObjectFullName(c, obj), err)
+			}
+		}
+		return nil
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, c, configMap, func() error {
+		return mutateDataCacheConfigMap(c, configMap, blobKeys)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create/update resource %s: %w", smoothoperatorutils.GetObjectFullName(c, configMap), err)
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, c, daemonSet, func() error {
+		return mutateDataCacheDaemonSet(c, daemonSet, images)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create/update resource %s: %w", smoothoperatorutils.GetObjectFullName(c, daemonSet), err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+#!/usr/bin/env bash
+
+# Keeps the node-local data cache in sync with the manifest maintained by the mapserver-operator.
+# Every blob is stored as $DATA_CACHE_PATH/<cacheKey>/<version>/<file>, where the version is derived from the
+# checksum of the blob. The "current" symlink is switched atomically once a new version is complete, the
+# previous version is kept for pods that still have it open.
+
+set -euo pipefail
+
+function log() {
+    echo msg=\""$1"\" "${@:2}"
+}
+
+# Expands ${VAR} references in a blobKey, e.g. ${BLOBS_GEOPACKAGES_BUCKET}/key/file.gpkg
+function expand() {
+    local value=$1
+    while [[ $value =~ \$\{([A-Za-z0-9_]+)\} ]]; do
+        local name=${BASH_REMATCH[1]}
+        value=${value//\$\{$name\}/${!name:-}}
+    done
+    echo "$value"
+}
+
+function get_version() {
+    local blob=$1
+
+    # Azure doesn't return a md5sum for large blobs, fall back to the size and modification time
+    local version
+    version=$(rclone md5sum "blobs:/$blob" | awk '{ print $1 }') || return 1
+    if [[ ! $version =~ ^[a-f0-9]{32}$ ]]; then
+        version=$(rclone lsf --format st "blobs:/$blob" | md5sum | awk '{ print $1 }') || return 1
+    fi
+    echo "$version"
+}
+
+# Downloads a version of the blob, it is only moved into place when the download succeeded
+function download_version() {
+    local blob=$1
+    local dir=$2
+    local version=$3
+    local file
+    file=$(basename "$blob")
+
+    log "Downloading blob" blob=\""$blob"\" version=\""$version"\"
+    rm -rf "$dir/$version.tmp" || return 1
+    mkdir -p "$dir/$version.tmp" || return 1
+    rclone copyto "blobs:/$blob" "$dir/$version.tmp/$file" || return 1
+    touch "$dir/$version.tmp/.complete" || return 1
+    chmod -R a+rX,a-w "$dir/$version.tmp" || return 1
+    mv -T "$dir/$version.tmp" "$dir/$version" || return 1
+}
+
+# The callers run this with || so set -e doesn't apply here, every step is checked explicitly
+function sync_blob() {
+    local key=$1
+    local blob
+    blob=$(expand "$2")
+    local dir=$DATA_CACHE_PATH/$key
+
+    local version
+    version=$(get_version "$blob") || return 1
+    if [ ! -f "$dir/$version/.complete" ]; then
+        download_version "$blob" "$dir" "$version" || return 1
+    fi
+
+    # Also when the version is already complete, e.g. after a rollback to the previous version
+    local current
+    current=$(readlink "$dir/current" || true)
+    if [ "$current" == "$version" ]; then
+        return 0
+    fi
+    if [ -n "$current" ]; then
+        ln -sfn "$current" "$dir/previous" || return 1
+    fi
+    ln -sfn "$version" "$dir/current.tmp" || return 1
+    mv -T "$dir/current.tmp" "$dir/current" || return 1
+    log "Switched blob to new version" blob=\""$blob"\" version=\""$version"\"
+
+    # Remove versions that are neither current nor previous
+    for old in "$dir"/*/; do
+        old=$(basename "$old")
+        if [ -L "$dir/$old" ]; then
+            continue
+        fi
+        if [ "$old" != "$version" ] && [ "$old" != "$current" ]; then
+            chmod -R u+w "${dir:?}/$old" || return 1
+            rm -rf "${dir:?}/$old" || return 1
+        fi
+    done
+}
+
+function prune() {
+    # Remove blobs that are no longer needed by any webservice
+    for dir in "$DATA_CACHE_PATH"/*/; do
+        [ -d "$dir" ] || continue
+        local key
+        key=$(basename "$dir")
+        if ! grep -q "^$key " "$DATA_CACHE_MANIFEST"; then
+            log "Removing blob from data cache" key=\""$key"\"
+            chmod -R u+w "$dir"
+            rm -rf "$dir"
+        fi
+    done
+}
+
+# The functions are sourced by the tests
+if [[ "${BASH_SOURCE[0]}" != "$0" ]]; then
+    return
+fi
+
+rclone config create --non-interactive --obscure blobs azureblob endpoint "$BLOBS_ENDPOINT" account "$BLOBS_ACCOUNT" key "$BLOBS_KEY" use_emulator true > /dev/null
+mkdir -p "$DATA_CACHE_PATH"
+
+while true; do
+    while read -r key blob_key; do
+        [ -n "$key" ] || continue
+        sync_blob "$key" "$blob_key" || log "Failed to sync blob" key=\""$key"\"
+    done < "$DATA_CACHE_MANIFEST"
+    prune
+    sleep "$DATA_CACHE_REFRESH_INTERVAL"
+done
@@ -0,0 +1,133 @@
+package datacache
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/prefetch"
+	"github.com/pdok/mapserver-operator/internal/controller/types"
+	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// Name of the DaemonSet and ConfigMap of the data cache
+	Name        = "mapserver-data-cache"
+	VolumeName  = "data-cache"
+	MountPath   = "/srv/data-cache"
+	ManifestKey = "blobs"
+	ScriptKey   = "data_cache.sh"
+
+	cacheKeyLength = 16
+	currentVersion = "current"
+)
+
+//go:embed data_cache.sh
+var Script string
+
+var config = types.DataCacheConfig{}
+
+func SetConfig(dataCacheConfig types.DataCacheConfig) {
+	config = dataCacheConfig
+}
+
+func GetConfig() types.DataCacheConfig {
+	return config
+}
+
+// UseDataCache returns whether the geopackages and TIFFs of the webservice are read from the node-local data cache
+func UseDataCache[O pdoknlv3.WMSWFS](obj O) bool {
+	return config.Enabled && obj.Options().PrefetchData && obj.Options().UseDataCache
+}
+
+// GetCacheKey returns the name of the directory in the data cache that holds all versions of the blob
+func GetCacheKey(blobKey string) string {
+	sum := sha256.Sum256([]byte(blobKey))
+	return hex.EncodeToString(sum[:])[:cacheKeyLength]
+}
+
+// GetCachedFilePath returns the path of the current version of the blob as seen from the pods
+func GetCachedFilePath(blobKey string) string {
+	return path.Join(MountPath, GetCacheKey(blobKey), currentVersion, path.Base(blobKey))
+}
+
+// GetBlobKeys returns the sorted unique blobKeys of the geopackages and TIFFs of the webservice,
+// streamed blobs are read from blob storage and aren't cached
+func GetBlobKeys[O pdoknlv3.WMSWFS](obj O) []string {
+	blobKeys := []string{}
+	for _, gpkg := range obj.GeoPackages() {
+		blobKeys = append(blobKeys, gpkg.BlobKey)
+	}
+	switch webservice := any(obj).(type) {
+	case *pdoknlv3.WMS:
+		blobKeys = append(blobKeys, webservice.GetUniqueTiffBlobKeys()...)
+	case *pdoknlv3.WCS:
+		blobKeys = append(blobKeys, webservice.GetUniqueTiffBlobKeys()...)
+	}
+	streamed := prefetch.GetStreamedBlobKeys(obj)
+	blobKeys = slices.DeleteFunc(blobKeys, func(blobKey string) bool { return slices.Contains(streamed, blobKey) })
+	slices.Sort(blobKeys)
+	return slices.Compact(blobKeys)
+}
+
+// GetManifest returns the list of blobs the data cache must hold, one "<cacheKey> <blobKey>" per line
+func GetManifest(blobKeys []string) string {
+	lines := []string{}
+	for _, blobKey := range blobKeys {
+		lines = append(lines, fmt.Sprintf("%s %s", GetCacheKey(blobKey), blobKey))
+	}
+	slices.Sort(lines)
+	lines = slices.Compact(lines)
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// GetHostPathVolume returns the hostPath volume of the data cache for the DaemonSet, which creates the directory if needed
+func GetHostPathVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: VolumeName,
+		VolumeSource: corev1.VolumeSource{
+			HostPath: GetHostPathVolumeSource(),
+		},
+	}
+}
+
+// GetHostPathVolumeSource returns the hostPath of the data cache on the nodes
+func GetHostPathVolumeSource() *corev1.HostPathVolumeSource {
+	return &corev1.HostPathVolumeSource{
+		Path: config.HostPath,
+		Type: smoothoperatorutils.Pointer(corev1.HostPathDirectoryOrCreate),
+	}
+}
+
+// GetClaimName returns the name of the PersistentVolumeClaim through which the pods of a webservice read the data cache
+func GetClaimName[O pdoknlv3.WMSWFS](obj O) string {
+	return obj.TypedName() + "-" + VolumeName
+}
+
+// GetVolume returns the volume of the data cache for the pods of a webservice.
+// A hostPath volume isn't allowed by the restricted Pod Security Standard, so the hostPath is bound through a PersistentVolume.
+func GetVolume[O pdoknlv3.WMSWFS](obj O) corev1.Volume {
+	return corev1.Volume{
+		Name: VolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: GetClaimName(obj),
+				ReadOnly:  true,
+			},
+		},
+	}
+}
+
+// GetVolumeMount returns the mount of the data cache for the pods of a webservice, these only read from the cache
+func GetVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{Name: VolumeName, MountPath: MountPath, ReadOnly: true}
+}
@@ -0,0 +1,168 @@
+package datacache
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/types"
+	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestWMS() *pdoknlv3.WMS {
+	gpkgLayer := func(name, blobKey string) pdoknlv3.Layer {
+		return pdoknlv3.Layer{
+			Name: smoothoperatorutils.Pointer(name),
+			Data: &pdoknlv3.Data{BaseData: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: blobKey}}},
+		}
+	}
+	return &pdoknlv3.WMS{
+		Spec: pdoknlv3.WMSSpec{
+			Options: &pdoknlv3.Options{BaseOptions: pdoknlv3.BaseOptions{PrefetchData: true, UseDataCache: true}},
+			Service: pdoknlv3.WMSService{
+				Layer: pdoknlv3.Layer{
+					Name: smoothoperatorutils.Pointer("top"),
+					Layers: []pdoknlv3.Layer{
+						gpkgLayer("gpkg-1", "geopackages-bucket/key/file.gpkg"),
+						gpkgLayer("gpkg-2", "geopackages-bucket/key/file.gpkg"),
+						{
+							Name: smoothoperatorutils.Pointer("tif"),
+							Data: &pdoknlv3.Data{TIF: &pdoknlv3.TIF{BlobKey: "tifs-bucket/key/file.tif"}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGetCacheKey(t *testing.T) {
+	assert.Equal(t, "b44e145399e65c8c", GetCacheKey("geopackages-bucket/key/file.gpkg"))
+	assert.Equal(t, "8cfe840965678cb5", GetCacheKey("${BLOBS_GEOPACKAGES_BUCKET}/key/file-1.gpkg"))
+}
+
+func TestGetCachedFilePath(t *testing.T) {
+	assert.Equal(t, "/srv/data-cache/b44e145399e65c8c/current/file.gpkg", GetCachedFilePath("geopackages-bucket/key/file.gpkg"))
+}
+
+func TestGetBlobKeys(t *testing.T) {
+	assert.Equal(t, []string{"geopackages-bucket/key/file.gpkg", "tifs-bucket/key/file.tif"}, GetBlobKeys(getTestWMS()))
+
+	// A streamed TIFF isn't cached
+	wms := getTestWMS()
+	wms.Spec.Service.Layer.Layers[2].Data.TIF.Prefetch = smoothoperatorutils.Pointer(pdoknlv3.PrefetchNever)
+	assert.Equal(t, []string{"geopackages-bucket/key/file.gpkg"}, GetBlobKeys(wms))
+}
+
+func TestGetManifest(t *testing.T) {
+	manifest := GetManifest([]string{"tifs-bucket/key/file.tif", "geopackages-bucket/key/file.gpkg", "tifs-bucket/key/file.tif"})
+	assert.Equal(t, "84e2bb4e21060ea4 tifs-bucket/key/file.tif\nb44e145399e65c8c geopackages-bucket/key/file.gpkg\n", manifest)
+	assert.Empty(t, GetManifest(nil))
+}
+
+func TestUseDataCache(t *testing.T) {
+	t.Cleanup(func() { SetConfig(types.DataCacheConfig{}) })
+	wms := getTestWMS()
+
+	assert.False(t, UseDataCache(wms), "disabled on the operator")
+
+	SetConfig(types.DataCacheConfig{Enabled: true})
+	assert.True(t, UseDataCache(wms))
+
+	wms.Spec.Options.PrefetchData = false
+	assert.False(t, UseDataCache(wms), "requires prefetchData")
+}
+
+// fakeRclone serves the blobs from $BLOBS_DIR, a copy writes a partial file and fails when $FAIL_COPY is set
+const fakeRclone = `#!/usr/bin/env bash
+case $1 in
+  md5sum) md5sum "$BLOBS_DIR/${2#blobs:/}" ;;
+  copyto)
+    if [ -n "${FAIL_COPY:-}" ]; then
+      echo partial > "$3"
+      exit 1
+    fi
+    cp "$BLOBS_DIR/${2#blobs:/}" "$3" ;;
+  *) exit 1 ;;
+esac
+`
+
+type scriptEnv struct {
+	t        *testing.T
+	blobsDir string
+	cacheDir string
+	binDir   string
+	script   string
+}
+
+func newScriptEnv(t *testing.T) *scriptEnv {
+	env := &scriptEnv{t: t, blobsDir: t.TempDir(), cacheDir: t.TempDir(), binDir: t.TempDir(), script: filepath.Join(t.TempDir(), ScriptKey)}
+	require.NoError(t, os.WriteFile(filepath.Join(env.binDir, "rclone"), []byte(fakeRclone), 0o755))
+	require.NoError(t, os.WriteFile(env.script, []byte(Script), 0o644))
+	return env
+}
+
+func (env *scriptEnv) writeBlob(content string) {
+	require.NoError(env.t, os.WriteFile(filepath.Join(env.blobsDir, "file.gpkg"), []byte(content), 0o644))
+}
+
+func (env *scriptEnv) syncBlob(failCopy bool) error {
+	cmd := exec.Command("bash", "-c", `source "$SCRIPT" && sync_blob key '${BLOBS_BUCKET}/file.gpkg'`)
+	cmd.Env = append(os.Environ(),
+		"PATH="+env.binDir+":"+os.Getenv("PATH"),
+		"SCRIPT="+env.script,
+		"BLOBS_DIR="+filepath.Dir(env.blobsDir),
+		"BLOBS_BUCKET="+filepath.Base(env.blobsDir),
+		"DATA_CACHE_PATH="+env.cacheDir,
+	)
+	if failCopy {
+		cmd.Env = append(cmd.Env, "FAIL_COPY=true")
+	}
+	out, err := cmd.CombinedOutput()
+	env.t.Log(string(out))
+	return err
+}
+
+func (env *scriptEnv) readCurrent() string {
+	data, err := os.ReadFile(filepath.Join(env.cacheDir, "key", "current", "file.gpkg"))
+	require.NoError(env.t, err)
+	return string(data)
+}
+
+func (env *scriptEnv) readLink(name string) string {
+	target, err := os.Readlink(filepath.Join(env.cacheDir, "key", name))
+	require.NoError(env.t, err)
+	return target
+}
+
+func TestScriptSyncBlob(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+	env := newScriptEnv(t)
+
+	env.writeBlob("v1")
+	require.NoError(t, env.syncBlob(false))
+	assert.Equal(t, "v1", env.readCurrent())
+	v1 := env.readLink("current")
+
+	// A failed download keeps the current version
+	env.writeBlob("v2")
+	assert.Error(t, env.syncBlob(true))
+	assert.Equal(t, "v1", env.readCurrent())
+
+	require.NoError(t, env.syncBlob(false))
+	assert.Equal(t, "v2", env.readCurrent())
+	assert.Equal(t, v1, env.readLink("previous"))
+	v2 := env.readLink("current")
+
+	// A rollback switches back to the version that is still cached
+	env.writeBlob("v1")
+	require.NoError(t, env.syncBlob(true))
+	assert.Equal(t, "v1", env.readCurrent())
+	assert.Equal(t, v2, env.readLink("previous"))
+}
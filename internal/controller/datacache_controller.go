@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/pdok/mapserver-operator/internal/controller/datacache"
+	"github.com/pdok/mapserver-operator/internal/controller/types"
+	"github.com/pdok/mapserver-operator/internal/tracing"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// DataCacheReconciler keeps the shared manifest and DaemonSet of the node-local data cache in sync with all
+// webservices. Every change is reconciled as the same request, so the webservices don't race on the shared resources.
+type DataCacheReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Images types.Images
+}
+
+// +kubebuilder:rbac:groups=pdok.nl,resources=wms;wfs;wcs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;list;watch;delete
+
+// Reconcile creates or updates the data cache while a webservice uses it and deletes it after the last one stopped.
+func (r *DataCacheReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	lgr := log.FromContext(ctx)
+	lgr.Info("Starting reconcile for the data cache", "name", req.NamespacedName)
+
+	ctx, span := tracing.StartSpanForRequest(ctx, "DataCacheReconciler.Reconcile", req.NamespacedName, "DataCache")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return result, createOrUpdateOrDeleteDataCache(ctx, r.Client, r.Images)
+}
+
+// getDataCacheRequest returns the single request of the data cache
+func getDataCacheRequest() reconcile.Request {
+	return reconcile.Request{NamespacedName: k8stypes.NamespacedName{
+		Namespace: datacache.GetConfig().Namespace,
+		Name:      datacache.Name,
+	}}
+}
+
+func isDataCacheObject(obj client.Object) bool {
+	return obj.GetNamespace() == datacache.GetConfig().Namespace && obj.GetName() == datacache.Name
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DataCacheReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	toDataCache := handler.EnqueueRequestsFromMapFunc(func(_ context.Context, _ client.Object) []reconcile.Request {
+		return []reconcile.Request{getDataCacheRequest()}
+	})
+	dataCacheObject := builder.WithPredicates(predicate.NewPredicateFuncs(isDataCacheObject))
+	dataCacheVolume := builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()["app.kubernetes.io/name"] == datacache.Name
+	}))
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("datacache").
+		Watches(&pdoknlv3.WMS{}, toDataCache, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&pdoknlv3.WFS{}, toDataCache, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&pdoknlv3.WCS{}, toDataCache, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.ConfigMap{}, toDataCache, dataCacheObject).
+		Watches(&appsv1.DaemonSet{}, toDataCache, dataCacheObject).
+		Watches(&corev1.PersistentVolume{}, toDataCache, dataCacheVolume).
+		Complete(r)
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/datacache"
+	"github.com/pdok/mapserver-operator/internal/controller/types"
+	smoothoperatormodel "github.com/pdok/smooth-operator/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMutateDataCachePersistentVolume(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+	wfs := &pdoknlv3.WFS{ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "namespace"}}
+
+	persistentVolume := getBareDataCachePersistentVolume(wfs)
+	assert.Equal(t, "namespace-name-wfs-data-cache", persistentVolume.Name)
+	require.NoError(t, mutateDataCachePersistentVolume(c, wfs, persistentVolume))
+	assert.Equal(t, "name-wfs-data-cache", persistentVolume.Spec.ClaimRef.Name)
+	assert.Equal(t, []corev1.PersistentVolumeAccessMode{corev1.ReadOnlyMany}, persistentVolume.Spec.AccessModes)
+
+	// The uid of the bound claim is kept
+	persistentVolume.Spec.ClaimRef.UID = "uid"
+	require.NoError(t, mutateDataCachePersistentVolume(c, wfs, persistentVolume))
+	assert.Equal(t, k8stypes.UID("uid"), persistentVolume.Spec.ClaimRef.UID)
+
+	// A released volume binds to the next claim
+	persistentVolume.Status.Phase = corev1.VolumeReleased
+	require.NoError(t, mutateDataCachePersistentVolume(c, wfs, persistentVolume))
+	assert.Empty(t, persistentVolume.Spec.ClaimRef.UID)
+}
+
+func TestCreateOrUpdateOrDeleteDataCache(t *testing.T) {
+	defer datacache.SetConfig(datacache.GetConfig())
+	datacache.SetConfig(types.DataCacheConfig{Enabled: true, Namespace: "data-cache", HostPath: "/var/data-cache"})
+
+	serviceURL, err := url.Parse("https://service.pdok.nl/wfs")
+	require.NoError(t, err)
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, pdoknlv3.AddToScheme(scheme))
+	wfs := &pdoknlv3.WFS{
+		ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "namespace"},
+		Spec: pdoknlv3.WFSSpec{
+			Options: &pdoknlv3.WFSOptions{BaseOptions: pdoknlv3.BaseOptions{PrefetchData: true, UseDataCache: true}},
+			Service: pdoknlv3.WFSService{
+				BaseService: pdoknlv3.BaseService{URL: smoothoperatormodel.URL{URL: serviceURL}, AccessConstraints: smoothoperatormodel.URL{URL: serviceURL}},
+				FeatureTypes: []pdoknlv3.FeatureType{
+					{Name: "a", Data: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: "geopackages-bucket/key/a.gpkg"}}},
+				},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(wfs).Build()
+	ctx := context.Background()
+
+	require.NoError(t, createOrUpdateOrDeleteDataCache(ctx, c, types.Images{MultitoolImage: "multitool"}))
+	configMap := getBareDataCacheConfigMap()
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(configMap), configMap))
+	assert.Contains(t, configMap.Data[datacache.ManifestKey], "geopackages-bucket/key/a.gpkg")
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(getBareDataCacheDaemonSet()), &appsv1.DaemonSet{}))
+
+	// The last webservice that uses the data cache is deleted
+	require.NoError(t, c.Delete(ctx, wfs))
+	require.NoError(t, createOrUpdateOrDeleteDataCache(ctx, c, types.Images{MultitoolImage: "multitool"}))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(configMap), &corev1.ConfigMap{})))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(getBareDataCacheDaemonSet()), &appsv1.DaemonSet{})))
+
+	// Nothing to delete
+	require.NoError(t, createOrUpdateOrDeleteDataCache(ctx, c, types.Images{MultitoolImage: "multitool"}))
+}
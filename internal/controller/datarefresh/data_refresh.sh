@@ -0,0 +1,131 @@
+#!/usr/bin/env bash
+
+# Downloads the geopackages in the data manifest and points the symlinks that the mapfile uses to them.
+# With "once" it runs as part of the blob-download init container. Without arguments it runs as the
+# data-refresh sidecar, which downloads a changed geopackage next to the old one, validates it, switches the
+# symlink and reloads mapserver, so a new blobKey doesn't require a new rollout.
+
+set -euo pipefail
+
+BLOBS_PATH=$(dirname "$DATA_REFRESH_LINKS_PATH")/blobs
+changed=false
+
+function log() {
+    echo msg=\""$1"\" "${@:2}"
+}
+
+# Expands ${VAR} references in a blobKey, e.g. ${BLOBS_GEOPACKAGES_BUCKET}/key/file.gpkg
+function expand() {
+    local value=$1
+    while [[ $value =~ \$\{([A-Za-z0-9_]+)\} ]]; do
+        local name=${BASH_REMATCH[1]}
+        value=${value//\$\{$name\}/${!name:-}}
+    done
+    echo "$value"
+}
+
+function download() {
+    local blob=$1
+    local dir=$2
+    local file
+    file=$(basename "$blob")
+
+    if [ -f "$dir/$file" ]; then
+        return
+    fi
+
+    # Callers check the result, which disables set -e in here, so every step is checked
+    log "Starting download" blob=\""$blob"\"
+    rm -rf "$dir.tmp"
+    mkdir -p "$dir.tmp" || return 1
+    if ! rclone copyto "blobs:/$blob" "$dir.tmp/$file"; then
+        log "Download failed" blob=\""$blob"\"
+        rm -rf "$dir.tmp"
+        return 1
+    fi
+
+    if ! ogrinfo -so "$dir.tmp/$file" > /dev/null; then
+        log "Validation with ogrinfo failed" blob=\""$blob"\"
+        rm -rf "$dir.tmp"
+        return 1
+    fi
+    # Azure doesn't return a md5sum for large blobs, only check it when there is one
+    local hash
+    hash=$(rclone md5sum "blobs:/$blob" | awk '{ print $1 }') || hash=""
+    if [[ $hash =~ ^[a-f0-9]{32}$ ]] && ! echo "$hash  $dir.tmp/$file" | md5sum --check --status; then
+        log "MD5 hash mismatch" blob=\""$blob"\"
+        rm -rf "$dir.tmp"
+        return 1
+    fi
+
+    chmod a-w "$dir.tmp/$file" || return 1
+    mv -T "$dir.tmp" "$dir" || return 1
+    log "Download complete" blob=\""$blob"\"
+}
+
+function refresh() {
+    mkdir -p "$DATA_REFRESH_LINKS_PATH" "$BLOBS_PATH" || return 1
+    while read -r name blob_key; do
+        [ -n "$name" ] || continue
+
+        local blob dir
+        blob=$(expand "$blob_key")
+        dir=$BLOBS_PATH/$(echo -n "$blob" | sha256sum | cut -c1-16)
+        # Keep serving the old geopackage if the new one can't be downloaded
+        download "$blob" "$dir" || return 1
+
+        local link=$DATA_REFRESH_LINKS_PATH/$name.gpkg
+        local target
+        target=$dir/$(basename "$blob")
+        if [ "$(readlink "$link" || true)" != "$target" ]; then
+            ln -sfn "$target" "$link.tmp" || return 1
+            mv -T "$link.tmp" "$link" || return 1
+            log "Switched geopackage" name=\""$name"\" blob=\""$blob"\"
+            changed=true
+        fi
+    done < "$DATA_REFRESH_MANIFEST"
+}
+
+function cleanup() {
+    # Remove links of layers that are no longer in the manifest and geopackages that are no longer linked
+    for link in "$DATA_REFRESH_LINKS_PATH"/*.gpkg; do
+        [ -L "$link" ] || continue
+        if ! grep -q "^$(basename "$link" .gpkg) " "$DATA_REFRESH_MANIFEST"; then
+            rm "$link"
+        fi
+    done
+    for dir in "$BLOBS_PATH"/*/; do
+        [ -d "$dir" ] || continue
+        if ! find "$DATA_REFRESH_LINKS_PATH" -type l -lname "${dir%/}/*" | grep -q .; then
+            log "Removing unused geopackage" dir=\""$dir"\"
+            rm -rf "$dir"
+        fi
+    done
+}
+
+# The functions are sourced by the tests
+if [[ "${BASH_SOURCE[0]}" != "$0" ]]; then
+    return
+fi
+
+rclone config create --non-interactive --obscure blobs azureblob endpoint "$BLOBS_ENDPOINT" account "$BLOBS_ACCOUNT" key "$BLOBS_KEY" use_emulator true > /dev/null
+
+if [ "${1:-}" = "once" ]; then
+    refresh
+    cleanup
+    exit 0
+fi
+
+while true; do
+    sleep "$DATA_REFRESH_INTERVAL"
+    changed=false
+    if ! refresh; then
+        log "Refresh failed, retrying after the interval"
+        continue
+    fi
+    if [ "$changed" = true ]; then
+        log "Reloading mapserver"
+        $DATA_REFRESH_RELOAD_COMMAND || log "Reload failed"
+    fi
+    cleanup
+done
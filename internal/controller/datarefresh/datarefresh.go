@@ -0,0 +1,106 @@
+package datarefresh
+
+import (
+	_ "embed"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/constants"
+	"github.com/pdok/mapserver-operator/internal/controller/prefetch"
+	"github.com/pdok/mapserver-operator/internal/controller/types"
+	"github.com/pdok/mapserver-operator/internal/controller/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	ScriptName   = "data_refresh.sh"
+	ManifestKey  = "manifest"
+	ManifestPath = "/srv/data-manifest"
+
+	// linksPath holds a symlink per layer/featureType that points to the current version of its geopackage
+	linksPath = "/srv/data/gpkg/current"
+	// reloadCommand gracefully restarts lighttpd, so the mapserver processes reopen the geopackages
+	reloadCommand   = "pkill -USR1 -o lighttpd"
+	refreshInterval = "60"
+)
+
+//go:embed data_refresh.sh
+var Script string
+
+// UseDataRefresh returns whether changed geopackages are swapped by the data-refresh sidecar instead of a rollout
+func UseDataRefresh[O pdoknlv3.WMSWFS](obj O) bool {
+	return obj.Options().PrefetchData && obj.Options().DataRefresh
+}
+
+// GetLinkPath returns the path the mapfile uses for the geopackage of a layer or featureType.
+// The path doesn't depend on the blobKey, so a new blobKey doesn't change the mapfile.
+func GetLinkPath(name string) string {
+	return path.Join(linksPath, name+".gpkg")
+}
+
+// GetManifest returns the prefetched geopackages of the webservice, one "<layer or featureType name> <blobKey>" per line
+func GetManifest[O pdoknlv3.WMSWFS](obj O) string {
+	lines := []string{}
+	streamed := prefetch.GetStreamedBlobKeys(obj)
+	switch webservice := any(obj).(type) {
+	case *pdoknlv3.WFS:
+		for _, featureType := range webservice.Spec.Service.FeatureTypes {
+			if featureType.Data.Gpkg != nil && !slices.Contains(streamed, featureType.Data.Gpkg.BlobKey) {
+				lines = append(lines, fmt.Sprintf("%s %s", featureType.Name, featureType.Data.Gpkg.BlobKey))
+			}
+		}
+	case *pdoknlv3.WMS:
+		for _, layer := range webservice.Spec.Service.GetAnnotatedLayers() {
+			if layer.Name != nil && layer.Data != nil && layer.Data.Gpkg != nil && !slices.Contains(streamed, layer.Data.Gpkg.BlobKey) {
+				lines = append(lines, fmt.Sprintf("%s %s", *layer.Name, layer.Data.Gpkg.BlobKey))
+			}
+		}
+	}
+	slices.Sort(lines)
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func GetManifestVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{Name: constants.DataManifestName, MountPath: ManifestPath, ReadOnly: true}
+}
+
+func GetDataRefreshContainer(images types.Images) corev1.Container {
+	return corev1.Container{
+		Name:            constants.DataRefreshName,
+		Image:           images.MultitoolImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"bash", "/srv/scripts/" + ScriptName},
+		Env:             append(GetEnv(), corev1.EnvVar{Name: "RCLONE_CONFIG", Value: "/tmp/rclone.conf"}),
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("0.5"),
+				corev1.ResourceMemory: resource.MustParse("128M"),
+			},
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("0.01"),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			utils.GetBaseVolumeMount(),
+			{Name: constants.InitScriptsName, MountPath: "/srv/scripts", ReadOnly: true},
+			GetManifestVolumeMount(),
+		},
+	}
+}
+
+// GetEnv returns the environment of the data refresh script, used for both the initial download and the sidecar
+func GetEnv() []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{Name: "DATA_REFRESH_MANIFEST", Value: path.Join(ManifestPath, ManifestKey)},
+		{Name: "DATA_REFRESH_LINKS_PATH", Value: linksPath},
+		{Name: "DATA_REFRESH_INTERVAL", Value: refreshInterval},
+		{Name: "DATA_REFRESH_RELOAD_COMMAND", Value: reloadCommand},
+	}
+}
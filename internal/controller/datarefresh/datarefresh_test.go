@@ -0,0 +1,179 @@
+package datarefresh
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetManifest(t *testing.T) {
+	wms := &pdoknlv3.WMS{
+		Spec: pdoknlv3.WMSSpec{
+			Service: pdoknlv3.WMSService{
+				Layer: pdoknlv3.Layer{
+					Name: smoothoperatorutils.Pointer("top"),
+					Layers: []pdoknlv3.Layer{
+						{
+							Name: smoothoperatorutils.Pointer("layer-b"),
+							Data: &pdoknlv3.Data{BaseData: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: "bucket/key/file.gpkg"}}},
+						},
+						{
+							Name: smoothoperatorutils.Pointer("layer-a"),
+							Data: &pdoknlv3.Data{BaseData: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: "bucket/key/file.gpkg"}}},
+						},
+						{
+							Name: smoothoperatorutils.Pointer("layer-tif"),
+							Data: &pdoknlv3.Data{TIF: &pdoknlv3.TIF{BlobKey: "bucket/key/file.tif"}},
+						},
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, "layer-a bucket/key/file.gpkg\nlayer-b bucket/key/file.gpkg\n", GetManifest(wms))
+	assert.Empty(t, GetManifest(&pdoknlv3.WFS{}))
+}
+
+func TestGetLinkPath(t *testing.T) {
+	assert.Equal(t, "/srv/data/gpkg/current/layer-a.gpkg", GetLinkPath("layer-a"))
+}
+
+func TestUseDataRefresh(t *testing.T) {
+	wfs := &pdoknlv3.WFS{Spec: pdoknlv3.WFSSpec{Options: &pdoknlv3.WFSOptions{BaseOptions: pdoknlv3.BaseOptions{PrefetchData: true, DataRefresh: true}}}}
+	assert.True(t, UseDataRefresh(wfs))
+
+	wfs.Spec.Options.PrefetchData = false
+	assert.False(t, UseDataRefresh(wfs))
+}
+
+// fakeRclone serves the blobs from $BLOBS_DIR, a copy fails when $FAIL_COPY is set and
+// $NO_MD5 mimics a large blob, for which Azure doesn't return a md5sum
+const fakeRclone = `#!/usr/bin/env bash
+case $1 in
+  md5sum) [ -n "${NO_MD5:-}" ] || md5sum "$BLOBS_DIR/${2#blobs:/}" ;;
+  copyto)
+    if [ -n "${FAIL_COPY:-}" ]; then
+      echo partial > "$3"
+      exit 1
+    fi
+    cp "$BLOBS_DIR/${2#blobs:/}" "$3" ;;
+  *) exit 1 ;;
+esac
+`
+
+// fakeOgrinfo fails the validation when $FAIL_OGRINFO is set
+const fakeOgrinfo = `#!/usr/bin/env bash
+[ -z "${FAIL_OGRINFO:-}" ]
+`
+
+type scriptEnv struct {
+	t         *testing.T
+	blobsDir  string
+	linksPath string
+	binDir    string
+	manifest  string
+	script    string
+}
+
+func newScriptEnv(t *testing.T) *scriptEnv {
+	dataDir := t.TempDir()
+	env := &scriptEnv{
+		t:         t,
+		blobsDir:  t.TempDir(),
+		linksPath: filepath.Join(dataDir, "current"),
+		binDir:    t.TempDir(),
+		manifest:  filepath.Join(t.TempDir(), ManifestKey),
+		script:    filepath.Join(t.TempDir(), "data_refresh.sh"),
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(env.binDir, "rclone"), []byte(fakeRclone), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(env.binDir, "ogrinfo"), []byte(fakeOgrinfo), 0o755))
+	require.NoError(t, os.WriteFile(env.script, []byte(Script), 0o644))
+	return env
+}
+
+func (env *scriptEnv) writeBlob(name, content string) {
+	require.NoError(env.t, os.WriteFile(filepath.Join(env.blobsDir, name), []byte(content), 0o644))
+}
+
+func (env *scriptEnv) writeManifest(manifest string) {
+	require.NoError(env.t, os.WriteFile(env.manifest, []byte(manifest), 0o644))
+}
+
+// run runs refresh and cleanup like the blob-download init container and returns whether a link changed
+func (env *scriptEnv) run(extraEnv ...string) (bool, error) {
+	cmd := exec.Command("bash", "-c", `source "$SCRIPT" && refresh && cleanup && echo "changed=$changed"`)
+	cmd.Env = append(os.Environ(),
+		"PATH="+env.binDir+":"+os.Getenv("PATH"),
+		"SCRIPT="+env.script,
+		"BLOBS_DIR="+filepath.Dir(env.blobsDir),
+		"BLOBS_BUCKET="+filepath.Base(env.blobsDir),
+		"DATA_REFRESH_LINKS_PATH="+env.linksPath,
+		"DATA_REFRESH_MANIFEST="+env.manifest,
+	)
+	cmd.Env = append(cmd.Env, extraEnv...)
+	out, err := cmd.CombinedOutput()
+	env.t.Log(string(out))
+	return strings.Contains(string(out), "changed=true"), err
+}
+
+func (env *scriptEnv) readLayer(name string) string {
+	data, err := os.ReadFile(filepath.Join(env.linksPath, name+".gpkg"))
+	require.NoError(env.t, err)
+	return string(data)
+}
+
+func (env *scriptEnv) countBlobDirs() int {
+	entries, err := os.ReadDir(filepath.Join(filepath.Dir(env.linksPath), "blobs"))
+	require.NoError(env.t, err)
+	return len(entries)
+}
+
+func TestScript(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+	env := newScriptEnv(t)
+	env.writeBlob("a.gpkg", "a")
+	env.writeBlob("b.gpkg", "b")
+
+	env.writeManifest("layer-a ${BLOBS_BUCKET}/a.gpkg\nlayer-b ${BLOBS_BUCKET}/a.gpkg\n")
+	changed, err := env.run()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "a", env.readLayer("layer-a"))
+	assert.Equal(t, "a", env.readLayer("layer-b"))
+
+	// Nothing changes without a new blobKey
+	changed, err = env.run()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// A failed download or validation keeps serving the old geopackage
+	env.writeManifest("layer-a ${BLOBS_BUCKET}/b.gpkg\nlayer-b ${BLOBS_BUCKET}/a.gpkg\n")
+	for _, failure := range [][]string{{"FAIL_COPY=true", "NO_MD5=true"}, {"FAIL_OGRINFO=true"}} {
+		_, err = env.run(failure...)
+		assert.Error(t, err, failure)
+		assert.Equal(t, "a", env.readLayer("layer-a"), failure)
+		assert.Equal(t, 1, env.countBlobDirs(), failure)
+	}
+
+	changed, err = env.run()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "b", env.readLayer("layer-a"))
+	assert.Equal(t, "a", env.readLayer("layer-b"))
+
+	// The link of a removed layer and the geopackage that is no longer linked are cleaned up
+	env.writeManifest("layer-a ${BLOBS_BUCKET}/b.gpkg\n")
+	_, err = env.run()
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(env.linksPath, "layer-b.gpkg"))
+	assert.Equal(t, 1, env.countBlobDirs())
+}
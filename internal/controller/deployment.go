@@ -1,19 +1,26 @@
 package controller
 
 import (
+	"slices"
 	"strconv"
 
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/autoextent"
 	"github.com/pdok/mapserver-operator/internal/controller/blobdownload"
 	"github.com/pdok/mapserver-operator/internal/controller/capabilitiesgenerator"
+	"github.com/pdok/mapserver-operator/internal/controller/cog"
 	"github.com/pdok/mapserver-operator/internal/controller/constants"
+	"github.com/pdok/mapserver-operator/internal/controller/datacache"
+	"github.com/pdok/mapserver-operator/internal/controller/datarefresh"
 	"github.com/pdok/mapserver-operator/internal/controller/featureinfogenerator"
 	"github.com/pdok/mapserver-operator/internal/controller/legendgenerator"
 	"github.com/pdok/mapserver-operator/internal/controller/mapfilegenerator"
 	"github.com/pdok/mapserver-operator/internal/controller/mapperutils"
 	"github.com/pdok/mapserver-operator/internal/controller/mapserver"
 	"github.com/pdok/mapserver-operator/internal/controller/ogcwebserviceproxy"
+	"github.com/pdok/mapserver-operator/internal/controller/prefetch"
 	"github.com/pdok/mapserver-operator/internal/controller/types"
+	"github.com/pdok/mapserver-operator/internal/controller/utils"
 	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -62,6 +69,7 @@ func mutateDeployment[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O, deployment *a
 		return err
 	}
 	setTerminationMessage(initContainers)
+	setSecurityContext(initContainers)
 
 	images := getReconcilerImages(r)
 	containers, err := getContainers(obj, images)
@@ -69,6 +77,7 @@ func mutateDeployment[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O, deployment *a
 		return err
 	}
 	setTerminationMessage(containers)
+	setSecurityContext(containers)
 
 	volumes := getVolumes(obj, configMapNames)
 
@@ -81,12 +90,15 @@ func mutateDeployment[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O, deployment *a
 			RestartPolicy:                 corev1.RestartPolicyAlways,
 			DNSPolicy:                     corev1.DNSClusterFirst,
 			TerminationGracePeriodSeconds: smoothoperatorutils.Pointer(int64(60)),
+			SecurityContext:               getPodSecurityContext(),
+			TopologySpreadConstraints:     getTopologySpreadConstraints(obj, labels),
 			InitContainers:                initContainers,
 			Containers:                    containers,
 			Volumes:                       volumes,
 		},
 	}
 
+	// The security context above can be relaxed through the podSpecPatch
 	podPatch := obj.PodSpecPatch()
 	patchedSpec, err := smoothoperatorutils.StrategicMergePatch(&podTemplateSpec.Spec, &podPatch)
 	if err != nil {
@@ -94,12 +106,29 @@ func mutateDeployment[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O, deployment *a
 	}
 	podTemplateSpec.Spec = *patchedSpec
 
+	if datarefresh.UseDataRefresh(obj) {
+		// The sidecar signals mapserver after a swap and needs the same blob configuration as the blob-download
+		podTemplateSpec.Spec.ShareProcessNamespace = smoothoperatorutils.Pointer(true)
+		setDataRefreshBlobsEnv(&podTemplateSpec.Spec)
+	}
+
+	if autoextent.UseAutoExtent(obj) {
+		// The auto-extent reads the data with the blob and PostGIS configuration of mapserver
+		setMapserverEnv(&podTemplateSpec.Spec, constants.AutoExtentName)
+	}
+
+	if cog.UseStreaming(obj) {
+		// The cog-validation reads the streamed TIFFs with the blob and GDAL configuration of mapserver
+		setMapserverEnv(&podTemplateSpec.Spec, constants.COGValidationName)
+	}
+
 	if use, _ := mapperutils.UseEphemeralVolume(obj); !use {
+		if size := mapperutils.GetAutoEphemeralStorage(obj); size != nil {
+			setEphemeralStorage(&podTemplateSpec.Spec.Containers[0], *size)
+		}
 		ephStorage := podTemplateSpec.Spec.Containers[0].Resources.Limits[corev1.ResourceEphemeralStorage]
-		threshold := resource.MustParse("200M")
-
-		if ephStorage.Value() < threshold.Value() {
-			podTemplateSpec.Spec.Containers[0].Resources.Limits[corev1.ResourceEphemeralStorage] = threshold
+		if ephStorage.Value() < mapperutils.MinEphemeralStorage.Value() {
+			podTemplateSpec.Spec.Containers[0].Resources.Limits[corev1.ResourceEphemeralStorage] = mapperutils.MinEphemeralStorage.DeepCopy()
 		}
 	} else {
 		delete(podTemplateSpec.Spec.Containers[0].Resources.Limits, corev1.ResourceEphemeralStorage)
@@ -114,6 +143,18 @@ func mutateDeployment[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O, deployment *a
 	return ctrl.SetControllerReference(obj, deployment, getReconcilerScheme(r))
 }
 
+// setEphemeralStorage sets the ephemeral storage request and limit of the container to the computed size
+func setEphemeralStorage(container *corev1.Container, size resource.Quantity) {
+	if container.Resources.Requests == nil {
+		container.Resources.Requests = corev1.ResourceList{}
+	}
+	if container.Resources.Limits == nil {
+		container.Resources.Limits = corev1.ResourceList{}
+	}
+	container.Resources.Requests[corev1.ResourceEphemeralStorage] = size.DeepCopy()
+	container.Resources.Limits[corev1.ResourceEphemeralStorage] = size.DeepCopy()
+}
+
 func getPodAnnotations(deployment *appsv1.Deployment) map[string]string {
 	annotations := smoothoperatorutils.CloneOrEmptyMap(deployment.Spec.Template.GetAnnotations())
 	annotations["cluster-autoscaler.kubernetes.io/safe-to-evict"] = "true"
@@ -138,10 +179,14 @@ func getInitContainerForDeployment[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O)
 		return nil, err
 	}
 
-	initContainers := []corev1.Container{
-		*blobDownloadInitContainer,
-		*capabilitiesGeneratorInitContainer,
+	initContainers := []corev1.Container{*blobDownloadInitContainer}
+	if cog.UseStreaming(obj) {
+		initContainers = append(initContainers, *cog.GetCOGValidationInitContainer(obj, *images))
+	}
+	if autoextent.UseAutoExtent(obj) {
+		initContainers = append(initContainers, *autoextent.GetAutoExtentInitContainer(obj, *images))
 	}
+	initContainers = append(initContainers, *capabilitiesGeneratorInitContainer)
 
 	if obj.Mapfile() == nil {
 		mapfileGeneratorInitContainer, err := mapfilegenerator.GetMapfileGeneratorInitContainer(obj, *images)
@@ -152,23 +197,20 @@ func getInitContainerForDeployment[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O)
 	}
 
 	if wms, ok := any(obj).(*pdoknlv3.WMS); ok {
-		featureInfoInitContainer, err := featureinfogenerator.GetFeatureinfoGeneratorInitContainer(*images)
+		featureInfoInitContainer, err := featureinfogenerator.GetFeatureinfoGeneratorInitContainer(wms, *images)
 		if err != nil {
 			return nil, err
 		}
 		initContainers = append(initContainers, *featureInfoInitContainer)
+		if len(wms.Spec.Service.FeatureInfoTemplates) > 0 {
+			initContainers = append(initContainers, *featureinfogenerator.GetFeatureInfoTemplatesInitContainer(wms, *images))
+		}
 
 		legendGeneratorInitContainer, err := legendgenerator.GetLegendGeneratorInitContainer(wms, *images)
 		if err != nil {
 			return nil, err
 		}
 		initContainers = append(initContainers, *legendGeneratorInitContainer)
-
-		if wms.Options().RewriteGroupToDataLayers {
-			legendFixerInitContainer := legendgenerator.GetLegendFixerInitContainer(*images)
-			initContainers = append(initContainers, *legendFixerInitContainer)
-		}
-
 	}
 	return initContainers, nil
 }
@@ -189,6 +231,9 @@ func getContainers[O pdoknlv3.WMSWFS](obj O, images *types.Images) ([]corev1.Con
 		}
 		containers = append(containers, *ogcProxy)
 	}
+	if datarefresh.UseDataRefresh(obj) {
+		containers = append(containers, datarefresh.GetDataRefreshContainer(*images))
+	}
 	return containers, nil
 }
 
@@ -198,7 +243,7 @@ func getApacheContainer(images types.Images) corev1.Container {
 		Image:           images.ApacheExporterImage,
 		ImagePullPolicy: corev1.PullIfNotPresent,
 		Ports:           []corev1.ContainerPort{{ContainerPort: constants.ApachePortNr, Protocol: corev1.ProtocolTCP}},
-		Args:            []string{"--scrape_uri=http://localhost/server-status?auto"},
+		Args:            []string{"--scrape_uri=http://localhost:" + strconv.Itoa(int(constants.MapserverContainerPortNr)) + "/server-status?auto"},
 		Resources: corev1.ResourceRequirements{
 			Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("48M")},
 			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("0.02")},
@@ -206,6 +251,47 @@ func getApacheContainer(images types.Images) corev1.Container {
 	}
 }
 
+// setDataRefreshBlobsEnv copies the blob configuration that is patched onto the blob-download init container
+func setDataRefreshBlobsEnv(podSpec *corev1.PodSpec) {
+	var envFrom []corev1.EnvFromSource
+	for _, initContainer := range podSpec.InitContainers {
+		if initContainer.Name == constants.BlobDownloadName {
+			envFrom = initContainer.EnvFrom
+		}
+	}
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == constants.DataRefreshName && len(podSpec.Containers[i].EnvFrom) == 0 {
+			podSpec.Containers[i].EnvFrom = envFrom
+		}
+	}
+}
+
+// setMapserverEnv copies the environment of the mapserver container, including the patched environment,
+// onto the init container
+func setMapserverEnv(podSpec *corev1.PodSpec, initContainerName string) {
+	var env []corev1.EnvVar
+	var envFrom []corev1.EnvFromSource
+	for _, container := range podSpec.Containers {
+		if container.Name == constants.MapserverName {
+			env, envFrom = container.Env, container.EnvFrom
+		}
+	}
+	for i := range podSpec.InitContainers {
+		initContainer := &podSpec.InitContainers[i]
+		if initContainer.Name != initContainerName {
+			continue
+		}
+		for _, envVar := range env {
+			if !slices.ContainsFunc(initContainer.Env, func(e corev1.EnvVar) bool { return e.Name == envVar.Name }) {
+				initContainer.Env = append(initContainer.Env, envVar)
+			}
+		}
+		if len(initContainer.EnvFrom) == 0 {
+			initContainer.EnvFrom = envFrom
+		}
+	}
+}
+
 func setTerminationMessage(c []corev1.Container) {
 	for i := range c {
 		c[i].TerminationMessagePolicy = "File"
@@ -213,6 +299,61 @@ func setTerminationMessage(c []corev1.Container) {
 	}
 }
 
+// getTopologySpreadConstraints spreads the pods over zones and nodes, so a single zone outage or node drain
+// doesn't take the whole service offline
+func getTopologySpreadConstraints[O pdoknlv3.WMSWFS](obj O, labels map[string]string) []corev1.TopologySpreadConstraint {
+	topologySpread := obj.Options().TopologySpread
+	if topologySpread == nil {
+		topologySpread = pdoknlv3.GetDefaultTopologySpread()
+	}
+	if !topologySpread.Enabled {
+		return nil
+	}
+
+	constraints := []corev1.TopologySpreadConstraint{}
+	for _, spread := range []struct {
+		topologyKey       string
+		whenUnsatisfiable corev1.UnsatisfiableConstraintAction
+	}{
+		{corev1.LabelTopologyZone, topologySpread.ZoneWhenUnsatisfiable},
+		{corev1.LabelHostname, topologySpread.HostnameWhenUnsatisfiable},
+	} {
+		constraints = append(constraints, corev1.TopologySpreadConstraint{
+			MaxSkew:           topologySpread.MaxSkew,
+			TopologyKey:       spread.topologyKey,
+			WhenUnsatisfiable: spread.whenUnsatisfiable,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: smoothoperatorutils.CloneOrEmptyMap(labels)},
+			MatchLabelKeys:    []string{appsv1.DefaultDeploymentUniqueLabelKey},
+		})
+	}
+	return constraints
+}
+
+func getPodSecurityContext() *corev1.PodSecurityContext {
+	return &corev1.PodSecurityContext{
+		RunAsNonRoot:   smoothoperatorutils.Pointer(true),
+		RunAsUser:      smoothoperatorutils.Pointer(constants.RunAsUserID),
+		RunAsGroup:     smoothoperatorutils.Pointer(constants.RunAsUserID),
+		FSGroup:        smoothoperatorutils.Pointer(constants.RunAsUserID),
+		SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+}
+
+// setSecurityContext makes the containers comply with the "restricted" Pod Security Standard.
+// Because of the read-only root filesystem every container gets a writable /tmp.
+func setSecurityContext(c []corev1.Container) {
+	for i := range c {
+		c[i].SecurityContext = &corev1.SecurityContext{
+			RunAsNonRoot:             smoothoperatorutils.Pointer(true),
+			AllowPrivilegeEscalation: smoothoperatorutils.Pointer(false),
+			ReadOnlyRootFilesystem:   smoothoperatorutils.Pointer(true),
+			Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+			SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		}
+		c[i].VolumeMounts = append(c[i].VolumeMounts, utils.GetTmpVolumeMount())
+	}
+}
+
 func getVolumes[O pdoknlv3.WMSWFS](obj O, configMapNames types.HashedConfigMapNames) []corev1.Volume {
 	baseVolume := corev1.Volume{Name: constants.BaseVolumeName}
 	if use, size := mapperutils.UseEphemeralVolume(obj); use {
@@ -236,6 +377,8 @@ func getVolumes[O pdoknlv3.WMSWFS](obj O, configMapNames types.HashedConfigMapNa
 	volumes := []corev1.Volume{
 		baseVolume,
 		{Name: constants.DataVolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		{Name: constants.TmpVolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		{Name: constants.VarTmpVolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
 		getConfigMapVolume(constants.MapserverName, configMapNames.Mapserver),
 	}
 
@@ -247,12 +390,20 @@ func getVolumes[O pdoknlv3.WMSWFS](obj O, configMapNames types.HashedConfigMapNa
 		volumes = append(volumes, getConfigMapVolume(constants.ConfigMapOgcWebserviceProxyVolumeName, configMapNames.OgcWebserviceProxy))
 	}
 
-	if obj.Options().PrefetchData {
+	if prefetch.MayPrefetch(obj) {
 		vol := getConfigMapVolume(constants.InitScriptsName, configMapNames.InitScripts)
 		vol.ConfigMap.DefaultMode = smoothoperatorutils.Pointer(int32(0777))
 		volumes = append(volumes, vol)
 	}
 
+	if datacache.UseDataCache(obj) {
+		volumes = append(volumes, datacache.GetVolume(obj))
+	}
+
+	if datarefresh.UseDataRefresh(obj) {
+		volumes = append(volumes, getConfigMapVolume(constants.DataManifestName, getSuffixedName(obj, constants.DataManifestName)))
+	}
+
 	volumes = append(volumes, getConfigMapVolume(constants.ConfigMapCapabilitiesGeneratorVolumeName, configMapNames.CapabilitiesGenerator))
 
 	if obj.Mapfile() == nil {
@@ -286,6 +437,10 @@ func getVolumes[O pdoknlv3.WMSWFS](obj O, configMapNames types.HashedConfigMapNa
 			getConfigMapVolume(constants.ConfigMapFeatureinfoGeneratorVolumeName, configMapNames.FeatureInfoGenerator),
 			getConfigMapVolume(constants.ConfigMapLegendGeneratorVolumeName, configMapNames.LegendGenerator),
 		)
+
+		if wms, _ := any(obj).(*pdoknlv3.WMS); len(wms.Spec.Service.FeatureInfoTemplates) > 0 {
+			volumes = append(volumes, featureinfogenerator.GetFeatureInfoTemplatesVolume(wms))
+		}
 	}
 
 	return volumes
@@ -3,6 +3,7 @@ package featureinfogenerator
 import (
 	"encoding/json"
 	"fmt"
+	"path"
 
 	"github.com/pdok/mapserver-operator/internal/controller/constants"
 
@@ -12,7 +13,10 @@ import (
 	corev1 "k8s.io/api/core/v1"
 )
 
-func GetFeatureinfoGeneratorInitContainer(images types.Images) (*corev1.Container, error) {
+// customTemplatesPath is where the featureInfoTemplates ConfigMaps are mounted in the featureinfo-generator
+const customTemplatesPath = "/templates"
+
+func GetFeatureinfoGeneratorInitContainer(wms *pdoknlv3.WMS, images types.Images) (*corev1.Container, error) {
 	initContainer := corev1.Container{
 		Name:            constants.FeatureinfoGeneratorName,
 		Image:           images.FeatureinfoGeneratorImage,
@@ -35,6 +39,47 @@ func GetFeatureinfoGeneratorInitContainer(images types.Images) (*corev1.Containe
 	return &initContainer, nil
 }
 
+// GetFeatureInfoTemplatesInitContainer returns the init container that copies the custom templates over the generated ones.
+// The featureinfo-generator image has no shell, so this runs after it in the mapserver image. The keys are the file names,
+// so cp gets them as arguments without a shell to expand a glob.
+func GetFeatureInfoTemplatesInitContainer(wms *pdoknlv3.WMS, images types.Images) *corev1.Container {
+	command := []string{"cp", "-L"}
+	for _, key := range wms.Spec.Service.GetFeatureInfoTemplateKeys() {
+		command = append(command, path.Join(customTemplatesPath, key))
+	}
+	command = append(command, constants.HTMLTemplatesPath+"/")
+
+	return &corev1.Container{
+		Name:            constants.FeatureInfoTemplatesName,
+		Image:           images.MapserverImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         command,
+		VolumeMounts: []corev1.VolumeMount{
+			utils.GetBaseVolumeMount(),
+			{Name: constants.ConfigMapFeatureInfoTemplatesVolumeName, MountPath: customTemplatesPath, ReadOnly: true},
+		},
+	}
+}
+
+// GetFeatureInfoTemplatesVolume returns a volume with the keys of the featureInfoTemplates ConfigMaps
+func GetFeatureInfoTemplatesVolume(wms *pdoknlv3.WMS) corev1.Volume {
+	volumeProjections := []corev1.VolumeProjection{}
+	for _, ref := range wms.Spec.Service.FeatureInfoTemplates {
+		items := []corev1.KeyToPath{}
+		for _, key := range ref.Keys {
+			items = append(items, corev1.KeyToPath{Key: key, Path: key})
+		}
+		volumeProjections = append(volumeProjections, corev1.VolumeProjection{
+			ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name}, Items: items},
+		})
+	}
+
+	return corev1.Volume{
+		Name:         constants.ConfigMapFeatureInfoTemplatesVolumeName,
+		VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: volumeProjections}},
+	}
+}
+
 func GetInput(wms *pdoknlv3.WMS) (string, error) {
 	input, err := MapWMSToFeatureinfoGeneratorInput(wms)
 	if err != nil {
@@ -3,8 +3,12 @@ package featureinfogenerator
 import (
 	"testing"
 
+	featureinfo "github.com/pdok/featureinfo-generator/pkg/types"
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/types"
 	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 )
 
 const (
@@ -57,6 +61,24 @@ const (
         }
     ]
 }`
+
+	featureInfoGeneratorInputWithFeatureInfo = `{
+    "automaticCasing": true,
+    "version": 2,
+    "layers": [
+        {
+            "name": "gpkg-layer-name",
+            "properties": [
+                {
+                    "name": "fuuid"
+                },
+                {
+                    "name": "column-2"
+                }
+            ]
+        }
+    ]
+}`
 )
 
 func TestGetInput(t *testing.T) {
@@ -125,6 +147,54 @@ func TestGetInput(t *testing.T) {
 			want:    featureInfoGeneratorInput,
 			wantErr: false,
 		},
+		{
+			name: "GetInput for featureinfoGenerator with featureInfo settings",
+			args: args{
+				wms: &pdoknlv3.WMS{
+					Spec: pdoknlv3.WMSSpec{
+						Service: pdoknlv3.WMSService{
+							Layer: pdoknlv3.Layer{
+								Name: smoothoperatorutils.Pointer("top-layer-name"),
+								Layers: []pdoknlv3.Layer{
+									{
+										Name: smoothoperatorutils.Pointer("gpkg-layer-name"),
+										FeatureInfo: &pdoknlv3.FeatureInfo{
+											Columns: []string{"column-2"},
+										},
+										Data: &pdoknlv3.Data{BaseData: pdoknlv3.BaseData{
+											Gpkg: &pdoknlv3.Gpkg{
+												Columns: []pdoknlv3.Column{
+													{Name: "column-1"},
+													{Name: "column-2"},
+												},
+											}},
+										},
+									},
+									{
+										Name:      smoothoperatorutils.Pointer("not-queryable-layer-name"),
+										Queryable: smoothoperatorutils.Pointer(false),
+										Data: &pdoknlv3.Data{BaseData: pdoknlv3.BaseData{
+											Gpkg: &pdoknlv3.Gpkg{},
+										}},
+									},
+									{
+										Name: smoothoperatorutils.Pointer("disabled-layer-name"),
+										FeatureInfo: &pdoknlv3.FeatureInfo{
+											Enabled: smoothoperatorutils.Pointer(false),
+										},
+										Data: &pdoknlv3.Data{BaseData: pdoknlv3.BaseData{
+											Gpkg: &pdoknlv3.Gpkg{},
+										}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want:    featureInfoGeneratorInputWithFeatureInfo,
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -140,3 +210,48 @@ func TestGetInput(t *testing.T) {
 		})
 	}
 }
+
+func TestGetFeatureinfoGeneratorInitContainerWithTemplates(t *testing.T) {
+	wms := &pdoknlv3.WMS{Spec: pdoknlv3.WMSSpec{Service: pdoknlv3.WMSService{
+		FeatureInfoTemplates: []pdoknlv3.FeatureInfoTemplatesRef{
+			{Name: "templates", Keys: []string{"layer.html", "feature-info.html"}},
+			{Name: "other-templates", Keys: []string{"other.html"}},
+		},
+	}}}
+
+	// The featureinfo-generator image has no shell
+	container, err := GetFeatureinfoGeneratorInitContainer(wms, types.Images{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"featureinfo-generator"}, container.Command)
+	assert.Len(t, container.VolumeMounts, 2)
+
+	container = GetFeatureInfoTemplatesInitContainer(wms, types.Images{MapserverImage: "mapserver"})
+	assert.Equal(t, "mapserver", container.Image)
+	assert.Equal(t, []string{"cp", "-L", "/templates/layer.html", "/templates/feature-info.html", "/templates/other.html", "/srv/data/config/templates/"}, container.Command)
+	assert.Empty(t, container.Args)
+	assert.Equal(t, corev1.VolumeMount{Name: "featureinfo-templates", MountPath: "/templates", ReadOnly: true}, container.VolumeMounts[1])
+
+	volume := GetFeatureInfoTemplatesVolume(wms)
+	assert.Equal(t, "featureinfo-templates", volume.Name)
+	assert.Equal(t, []corev1.KeyToPath{{Key: "layer.html", Path: "layer.html"}, {Key: "feature-info.html", Path: "feature-info.html"}},
+		volume.Projected.Sources[0].ConfigMap.Items)
+}
+
+func TestGetPropertiesForVectorWithPrimaryKey(t *testing.T) {
+	layer := &pdoknlv3.Layer{
+		Name: smoothoperatorutils.Pointer("layer"),
+		Data: &pdoknlv3.Data{BaseData: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{
+			PrimaryKey: smoothoperatorutils.Pointer("id"),
+			Columns: []pdoknlv3.Column{
+				{Name: "name", Alias: smoothoperatorutils.Pointer("Name")},
+				{Name: "geom", Type: smoothoperatorutils.Pointer(pdoknlv3.GeometryColumnType)},
+				{Name: "id"},
+			},
+		}}},
+	}
+
+	assert.Equal(t, []featureinfo.Property{{Name: "id"}, {Name: "name", Alias: "Name"}}, getProperties(layer))
+
+	layer.Data.Gpkg.PrimaryKey = nil
+	assert.Equal(t, []featureinfo.Property{{Name: "fuuid"}, {Name: "name", Alias: "Name"}, {Name: "id"}}, getProperties(layer))
+}
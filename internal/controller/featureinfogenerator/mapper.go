@@ -19,7 +19,8 @@ func MapWMSToFeatureinfoGeneratorInput(wms *pdoknlv3.WMS) (*featureinfo.Scheme,
 	}
 
 	for _, layer := range wms.Spec.Service.GetAnnotatedLayers() {
-		if !layer.IsDataLayer {
+		// Layers that aren't queryable have no GetFeatureInfo template
+		if !layer.IsDataLayer || !layer.IsQueryable() {
 			continue
 		}
 		l := featureinfo.Layer{
@@ -39,23 +40,39 @@ func MapWMSToFeatureinfoGeneratorInput(wms *pdoknlv3.WMS) (*featureinfo.Scheme,
 func getProperties(layer *pdoknlv3.Layer) (properties []featureinfo.Property) {
 	switch {
 	case layer.Data.Gpkg != nil:
-		properties = getPropertiesForVector(layer.Data.Gpkg.Columns)
+		properties = getPropertiesForVector(layer, layer.Data.Gpkg.Columns)
 	case layer.Data.Postgis != nil:
-		properties = getPropertiesForVector(layer.Data.Postgis.Columns)
+		properties = getPropertiesForVector(layer, layer.Data.Postgis.Columns)
 	case layer.Data.TIF != nil:
 		properties = getPropertiesForRaster(&layer.Data.TIF.GetFeatureInfoIncludesClass)
 	}
 	return
 }
 
-func getPropertiesForVector(columns []pdoknlv3.Column) (properties []featureinfo.Property) {
-	properties = append(properties, featureinfo.Property{Name: "fuuid"})
+// getPropertiesForVector returns the feature id and the featureinfo columns, like the columns of the mapfile
+func getPropertiesForVector(layer *pdoknlv3.Layer, columns []pdoknlv3.Column) (properties []featureinfo.Property) {
+	primaryKey := layer.Data.GetPrimaryKey()
+	if primaryKey == nil {
+		properties = append(properties, featureinfo.Property{Name: "fuuid"})
+	}
 	for _, column := range columns {
+		if column.Type != nil && *column.Type == pdoknlv3.GeometryColumnType {
+			continue
+		}
+		isPrimaryKey := primaryKey != nil && column.Name == *primaryKey
+		if !isPrimaryKey && !layer.IncludesFeatureInfoColumn(column.Name) {
+			continue
+		}
 		prop := featureinfo.Property{Name: column.Name}
 		if column.Alias != nil {
 			prop.Alias = *column.Alias
 		}
-		properties = append(properties, prop)
+		// The feature id comes first
+		if isPrimaryKey {
+			properties = append([]featureinfo.Property{prop}, properties...)
+		} else {
+			properties = append(properties, prop)
+		}
 	}
 	return
 }
@@ -43,7 +43,7 @@ func mutateHorizontalPodAutoscaler[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O,
 	}}
 
 	var behaviourStabilizationWindowSeconds int32
-	if obj.Type() == pdoknlv3.ServiceTypeWFS {
+	if obj.Type() == pdoknlv3.ServiceTypeWFS || obj.Type() == pdoknlv3.ServiceTypeWCS {
 		behaviourStabilizationWindowSeconds = 300
 	}
 
@@ -100,8 +100,11 @@ func mutateIngressRoute[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O, ingressRout
 				ingressRoute.Spec.Routes = append(ingressRoute.Spec.Routes, makeRoute(getMatchRule(ingressRouteURL.URL), mapserverService, middlewareRef))
 			}
 		}
-	} else { // WFS
+	} else { // WFS and WCS
 		for _, ingressRouteURL := range obj.IngressRouteURLs(true) {
+			if useOgcAPIFeatures(obj) {
+				ingressRoute.Spec.Routes = append(ingressRoute.Spec.Routes, makeRoute(getOgcAPIMatchRule(ingressRouteURL.URL), mapserverService, middlewareRef))
+			}
 			ingressRoute.Spec.Routes = append(ingressRoute.Spec.Routes, makeRoute(getMatchRule(ingressRouteURL.URL), mapserverService, middlewareRef))
 		}
 	}
@@ -113,7 +116,7 @@ func mutateIngressRoute[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O, ingressRout
 }
 
 // getUptimeName transforms the CR name into a uptime.pdok.nl/name value
-// owner-dataset-v1-0 -> OWNER dataset v1_0 [INSPIRE] [WMS|WFS]
+// owner-dataset-v1-0 -> OWNER dataset v1_0 [INSPIRE] [WMS|WFS|WCS]
 func getUptimeName[O pdoknlv3.WMSWFS](obj O) string {
 	// Extract the version from the CR name, owner-dataset-v1-0 -> owner-dataset + v1-0
 	versionMatcher := regexp.MustCompile("^(.*)(?:-(v?[1-9](?:-[0-9])?))?$")
@@ -135,6 +138,12 @@ func getUptimeName[O pdoknlv3.WMSWFS](obj O) string {
 	return strings.Join(append(nameParts, string(obj.Type())), " ")
 }
 
+// useOgcAPIFeatures returns whether obj is a WFS that also serves the OGC API Features
+func useOgcAPIFeatures[O pdoknlv3.WMSWFS](obj O) bool {
+	wfs, ok := any(obj).(*pdoknlv3.WFS)
+	return ok && wfs.OgcAPIFeaturesEnabled()
+}
+
 func getMatchRule(url smoothoperatormodel.URL) string {
 	host := url.Hostname()
 	if strings.Contains(host, "localhost") {
@@ -144,6 +153,15 @@ func getMatchRule(url smoothoperatormodel.URL) string {
 	return "(Host(`localhost`) || Host(`" + host + "`)) && Path(`" + url.Path + "`)"
 }
 
+func getOgcAPIMatchRule(url smoothoperatormodel.URL) string {
+	host := url.Hostname()
+	if strings.Contains(host, "localhost") {
+		return "Host(`localhost`) && PathPrefix(`" + url.Path + constants.OgcAPIPath + "`)"
+	}
+
+	return "(Host(`localhost`) || Host(`" + host + "`)) && PathPrefix(`" + url.Path + constants.OgcAPIPath + "`)"
+}
+
 func getLegendMatchRule(url smoothoperatormodel.URL) string {
 	host := url.Hostname()
 	if strings.Contains(host, "localhost") {
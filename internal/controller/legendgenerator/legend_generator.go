@@ -9,10 +9,17 @@ import (
 	corev1 "k8s.io/api/core/v1"
 )
 
+const (
+	inputFileName = "input.yaml"
+	legendPath    = "/var/www/legend"
+)
+
+// GetLegendGeneratorInitContainer returns the init container that generates the legends with the legend-generator
+// (cmd/legend-generator). The image is the mapserver image with the legend-generator added.
 func GetLegendGeneratorInitContainer(wms *pdoknlv3.WMS, images types.Images) (*corev1.Container, error) {
 	initContainer := corev1.Container{
 		Name:            constants.LegendGeneratorName,
-		Image:           images.MapserverImage,
+		Image:           images.LegendGeneratorImage,
 		ImagePullPolicy: corev1.PullIfNotPresent,
 		Env: []corev1.EnvVar{
 			{
@@ -22,22 +29,11 @@ func GetLegendGeneratorInitContainer(wms *pdoknlv3.WMS, images types.Images) (*c
 			mapserver.GetMapfileEnvVar(wms),
 		},
 		Command: []string{
-			"bash",
-			"-c",
-			`set -eu;
-exit_code=0;
-cat /input/input | xargs -n 2 echo | while read layer style; do
-echo Generating legend for layer: $layer, style: $style;
-mkdir -p /var/www/legend/$layer;
-mapserv -nh 'QUERY_STRING=SERVICE=WMS&language=dut&version=1.3.0&service=WMS&request=GetLegendGraphic&sld_version=1.1.0&layer='$layer'&format=image/png&STYLE='$style'' > /var/www/legend/$layer/${style}.png;
-magic_bytes=$(head -c 4 /var/www/legend/$layer/${style}.png | tail -c 3);
-if [[ $magic_bytes != 'PNG' ]]; then
-echo [4T2O9] file /var/www/legend/$layer/${style}.png appears to not be a png file;
-exit_code=1;
-fi;
-done;
-exit $exit_code;
-`,
+			"legend-generator",
+			"-input",
+			"/input/" + inputFileName,
+			"-output",
+			legendPath,
 		},
 		VolumeMounts: []corev1.VolumeMount{
 			utils.GetBaseVolumeMount(),
@@ -53,35 +49,14 @@ exit $exit_code;
 	// Adding config volumemount here to get the same order as in the old ansible operator
 	initContainer.VolumeMounts = append(initContainer.VolumeMounts, utils.GetConfigVolumeMount(constants.ConfigMapLegendGeneratorVolumeName))
 	// For mounting the possible stylingfiles that are included
-	initContainer.VolumeMounts = append(initContainer.VolumeMounts, corev1.VolumeMount{Name: constants.ConfigMapStylingFilesVolumeName, MountPath: "/srv/data/config/styles"})
+	initContainer.VolumeMounts = append(initContainer.VolumeMounts, corev1.VolumeMount{Name: constants.ConfigMapStylingFilesVolumeName, MountPath: stylingFilesPath})
 
 	return &initContainer, nil
 }
 
-func GetLegendFixerInitContainer(images types.Images) *corev1.Container {
-	return &corev1.Container{
-		Name:            constants.LegendFixerName,
-		Image:           images.MultitoolImage,
-		ImagePullPolicy: corev1.PullIfNotPresent,
-		Command: []string{
-			"/bin/bash",
-			"/input/legend-fixer.sh",
-		},
-		VolumeMounts: []corev1.VolumeMount{
-			utils.GetDataVolumeMount(),
-			utils.GetConfigVolumeMount(constants.ConfigMapLegendGeneratorVolumeName),
-		},
-	}
-}
-
 func GetConfigMapData(wms *pdoknlv3.WMS) map[string]string {
-	data := map[string]string{
+	return map[string]string{
 		"default_mapserver.conf": defaultMapserverConf,
+		inputFileName:            getInputData(wms),
 	}
-
-	addLayerInput(wms, data)
-	if wms.Options().RewriteGroupToDataLayers {
-		addLegendFixerConfig(wms, data)
-	}
-	return data
 }
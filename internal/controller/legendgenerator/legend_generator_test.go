@@ -8,6 +8,8 @@ import (
 
 	"github.com/pdok/mapserver-operator/api/v2beta1"
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/legendtool"
+	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
 	"github.com/stretchr/testify/assert"
 	"sigs.k8s.io/yaml"
 )
@@ -40,3 +42,38 @@ func TestGetConfigMapDataNoLegendFix(t *testing.T) {
 func TestGetConfigMapDataLegendFix(t *testing.T) {
 	test(t, "legend-fix")
 }
+
+func TestGetConfigMapDataSLD(t *testing.T) {
+	wms := pdoknlv3.WMS{
+		Spec: pdoknlv3.WMSSpec{
+			Options: &pdoknlv3.Options{},
+			Service: pdoknlv3.WMSService{
+				StylingAssets: &pdoknlv3.StylingAssets{
+					BlobKeys:      []string{"resources/key/blob.sld"},
+					ConfigMapRefs: []pdoknlv3.ConfigMapRef{{Name: "styling", Keys: []string{"configmap.sld", "mapfile.style"}}},
+				},
+				Layer: pdoknlv3.Layer{
+					Name:    smoothoperatorutils.Pointer("top"),
+					Visible: true,
+					Layers: []pdoknlv3.Layer{{
+						Name:    smoothoperatorutils.Pointer("layer"),
+						Visible: true,
+						Styles: []pdoknlv3.Style{
+							{Name: "configmap", Visualization: smoothoperatorutils.Pointer("configmap.sld")},
+							{Name: "blob", Visualization: smoothoperatorutils.Pointer("blob.sld")},
+							{Name: "mapfile", Visualization: smoothoperatorutils.Pointer("mapfile.style")},
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, []legendtool.SLDReference{
+		{Layer: "layer", Style: "configmap", Path: "/srv/data/config/styles/configmap.sld"},
+		{Layer: "layer", Style: "blob", Path: "/srv/data/config/sld/blob.sld"},
+	}, getInput(&wms).SLDs)
+
+	wms.Spec.Service.Layer.Layers[0].Styles = wms.Spec.Service.Layer.Layers[0].Styles[2:]
+	assert.Empty(t, getInput(&wms).SLDs)
+}
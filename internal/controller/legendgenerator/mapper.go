@@ -1,12 +1,9 @@
 package legendgenerator
 
 import (
-	"fmt"
-
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
-
-	_ "embed"
-	"strings"
+	"github.com/pdok/mapserver-operator/internal/controller/mapfilegenerator"
+	"github.com/pdok/mapserver-operator/internal/legendtool"
 
 	"sigs.k8s.io/yaml"
 )
@@ -21,49 +18,41 @@ END
 `
 )
 
-//go:embed legend-fixer.sh
-var legendFixerScript string
-
-type LegendReference struct {
-	Layer string `yaml:"layer" json:"layer"`
-	Style string `yaml:"style" json:"style"`
-}
-
-type OgcWebserviceProxyConfig struct {
-	GroupLayers map[string][]string `yaml:"grouplayers" json:"grouplayers"`
-}
+// stylingFilesPath is where the styling ConfigMaps are mounted in the legend-generator
+const stylingFilesPath = "/srv/data/config/styles"
 
-func addLayerInput(wms *pdoknlv3.WMS, data map[string]string) {
-	legendReferences := make([]LegendReference, 0)
+func getInput(wms *pdoknlv3.WMS) legendtool.Input {
+	input := legendtool.Input{
+		Legends: make([]legendtool.LegendReference, 0),
+		SLDs:    getSLDReferences(wms),
+	}
 
-	processLayer(&wms.Spec.Service.Layer, &legendReferences)
+	processLayer(&wms.Spec.Service.Layer, &input.Legends)
 
-	sb := strings.Builder{}
-	for _, reference := range legendReferences {
-		sb.WriteString(fmt.Sprintf("\"%s\" \"%s\"\n", reference.Layer, reference.Style))
+	if wms.Options().RewriteGroupToDataLayers {
+		addLegendFixerConfig(wms, &input)
 	}
 
-	data["input"] = sb.String()
-
-	// TODO - current config is hard to read and process down the line
-	// We should alter the legend generation script so it can handle yaml input and pass the data as follows
-	// referencesYaml, err := yaml.Marshal(legendReferences)
-	// if err == nil {
-	// 	data["input2"] = string(referencesYaml)
-	// }
+	return input
 }
 
-func processLayer(layer *pdoknlv3.Layer, legendReferences *[]LegendReference) {
+func processLayer(layer *pdoknlv3.Layer, legendReferences *[]legendtool.LegendReference) {
 	if !layer.Visible {
 		return
 	}
 	for _, style := range layer.Styles {
-		if style.Legend == nil {
-			*legendReferences = append(*legendReferences, LegendReference{
-				Layer: *layer.Name,
-				Style: style.Name,
-			})
+		reference := legendtool.LegendReference{
+			Layer: *layer.Name,
+			Style: style.Name,
 		}
+		// Legends on blob storage are downloaded by the blob-download, they are only validated
+		if style.Legend != nil {
+			reference.Format = style.Legend.Format
+			reference.Width = style.Legend.Width
+			reference.Height = style.Legend.Height
+			reference.Static = true
+		}
+		*legendReferences = append(*legendReferences, reference)
 	}
 
 	if layer.Layers != nil {
@@ -73,12 +62,29 @@ func processLayer(layer *pdoknlv3.Layer, legendReferences *[]LegendReference) {
 	}
 }
 
-func addLegendFixerConfig(wms *pdoknlv3.WMS, data map[string]string) {
-	data["legend-fixer.sh"] = legendFixerScript
+// getSLDReferences returns the SLD documents that are published next to the legends
+func getSLDReferences(wms *pdoknlv3.WMS) []legendtool.SLDReference {
+	var references []legendtool.SLDReference
+	for _, layer := range wms.Spec.Service.GetAnnotatedLayers() {
+		if !layer.Visible || layer.Name == nil {
+			continue
+		}
+		for _, style := range layer.Styles {
+			if style.IsSLD() {
+				references = append(references, legendtool.SLDReference{
+					Layer: *layer.Name,
+					Style: style.Name,
+					Path:  mapfilegenerator.GetStylePath(wms, style, stylingFilesPath),
+				})
+			}
+		}
+	}
+	return references
+}
 
+func addLegendFixerConfig(wms *pdoknlv3.WMS, input *legendtool.Input) {
 	topLayer := wms.Spec.Service.Layer
 
-	legendReferences := make([]LegendReference, 0)
 	topLevelStyleNames := make(map[string]bool)
 
 	for _, style := range topLayer.Styles {
@@ -90,7 +96,7 @@ func addLegendFixerConfig(wms *pdoknlv3.WMS, data map[string]string) {
 		for _, layer := range wms.Spec.Service.Layer.Layers {
 			for _, style := range layer.Styles {
 				if topLevelStyleNames[style.Name] && style.Legend == nil {
-					legendReferences = append(legendReferences, LegendReference{
+					input.Remove = append(input.Remove, legendtool.LegendReference{
 						Layer: *layer.Name,
 						Style: style.Name,
 					})
@@ -99,34 +105,19 @@ func addLegendFixerConfig(wms *pdoknlv3.WMS, data map[string]string) {
 		}
 	}
 
-	sb := strings.Builder{}
-	for _, reference := range legendReferences {
-		sb.WriteString(fmt.Sprintf("\"%s\" \"%s\"\n", reference.Layer, reference.Style))
-	}
-
-	data["remove"] = sb.String()
-
 	groupLayers := make(map[string][]string)
 
 	if topLayer.IsGroupLayer() && topLayer.Name != nil {
-		layerName := topLayer.Name
-		targetArray := make([]string, 0)
-		getAllNestedNonGroupLayerNames(&topLayer, &targetArray)
-		groupLayers[*layerName] = targetArray
-
-		for _, subLayer := range topLayer.Layers {
-			if subLayer.IsGroupLayer() {
-				layerName = subLayer.Name
-				targetArray = make([]string, 0)
-				getAllNestedNonGroupLayerNames(&subLayer, &targetArray)
-				groupLayers[*layerName] = targetArray
+		for _, layer := range wms.Spec.Service.GetAnnotatedLayers() {
+			if layer.IsGroupLayer && layer.Name != nil {
+				targetArray := make([]string, 0)
+				getAllNestedNonGroupLayerNames(&layer.Layer, &targetArray)
+				groupLayers[*layer.Name] = targetArray
 			}
 		}
 	}
 
-	ogcWebServiceProxyConfig := OgcWebserviceProxyConfig{GroupLayers: groupLayers}
-	proxyConfigData, _ := yaml.Marshal(ogcWebServiceProxyConfig)
-	data["ogc-webservice-proxy-config.yaml"] = string(proxyConfigData)
+	input.GroupLayers = groupLayers
 }
 
 func getAllNestedNonGroupLayerNames(layer *pdoknlv3.Layer, target *[]string) {
@@ -138,3 +129,8 @@ func getAllNestedNonGroupLayerNames(layer *pdoknlv3.Layer, target *[]string) {
 		}
 	}
 }
+
+func getInputData(wms *pdoknlv3.WMS) string {
+	data, _ := yaml.Marshal(getInput(wms))
+	return string(data)
+}
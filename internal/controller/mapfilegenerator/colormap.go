@@ -0,0 +1,87 @@
+package mapfilegenerator
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+)
+
+// colormapStylesPath is where the mapfile-generator ConfigMap, that holds the colormap styles, is mounted
+const colormapStylesPath = "/input"
+
+var invalidConfigMapKeyCharacters = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+// GetColormapStyles returns the mapfile code of the styles with a colormap, by their key in the mapfile-generator ConfigMap
+func GetColormapStyles(wms *pdoknlv3.WMS) map[string]string {
+	styles := map[string]string{}
+	for _, layer := range wms.Spec.Service.GetAnnotatedLayers() {
+		if !layer.IsDataLayer {
+			continue
+		}
+		for i, style := range layer.Styles {
+			if style.Colormap != nil {
+				styles[getColormapStyleKey(*layer.Name, i)] = getColormapCode(style.Colormap)
+			}
+		}
+	}
+	return styles
+}
+
+func getColormapStyleKey(layerName string, styleIndex int) string {
+	return fmt.Sprintf("colormap-%s-%d.style", invalidConfigMapKeyCharacters.ReplaceAllString(layerName, "_"), styleIndex)
+}
+
+// getColormapCode renders the colormap as mapfile classes, a ramp gets a class per pair of consecutive entries
+func getColormapCode(colormap *pdoknlv3.Colormap) string {
+	sb := strings.Builder{}
+	entries := colormap.Entries
+	for i, entry := range entries {
+		if colormap.Type == pdoknlv3.ColormapTypeRamp && i < len(entries)-1 {
+			next := entries[i+1]
+			writeClass(&sb, entry.Label, fmt.Sprintf("([pixel] >= %s AND [pixel] < %s)", entry.Value, next.Value),
+				fmt.Sprintf("COLORRANGE %s %s", strconv.Quote(entry.Color), strconv.Quote(next.Color)),
+				fmt.Sprintf("DATARANGE %s %s", entry.Value, next.Value))
+		} else {
+			// The last entry of a ramp includes its own value
+			writeClass(&sb, entry.Label, fmt.Sprintf("([pixel] = %s)", entry.Value),
+				"COLOR "+strconv.Quote(entry.Color))
+		}
+	}
+	return sb.String()
+}
+
+func writeClass(sb *strings.Builder, label *string, expression string, styleLines ...string) {
+	sb.WriteString("CLASS\n")
+	// Classes without a name are left out of the legend
+	if label != nil {
+		sb.WriteString("  NAME " + strconv.Quote(*label) + "\n")
+	}
+	sb.WriteString("  EXPRESSION " + expression + "\n")
+	sb.WriteString("  STYLE\n")
+	for _, line := range styleLines {
+		sb.WriteString("    " + line + "\n")
+	}
+	sb.WriteString("  END\nEND\n")
+}
+
+// getRasterProcessing returns the PROCESSING directives of a TIF layer
+func getRasterProcessing(tif *pdoknlv3.TIF) []string {
+	var processing []string
+	if len(tif.Bands) > 0 {
+		bands := []string{}
+		for _, band := range tif.Bands {
+			bands = append(bands, strconv.Itoa(int(band)))
+		}
+		processing = append(processing, "BANDS="+strings.Join(bands, ","))
+	}
+	if tif.NoData != nil {
+		processing = append(processing, "NODATA="+*tif.NoData)
+	}
+	if tif.Scale != nil {
+		processing = append(processing, "SCALE="+*tif.Scale)
+	}
+	return processing
+}
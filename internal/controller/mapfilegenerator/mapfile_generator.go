@@ -51,10 +51,14 @@ func GetConfig[W pdoknlv3.WMSWFS](webservice W, ownerInfo *smoothoperatorv1.Owne
 		if WMS, ok := any(webservice).(*pdoknlv3.WMS); ok {
 			return createConfigForWMS(WMS, ownerInfo)
 		}
+	case *pdoknlv3.WCS:
+		if WCS, ok := any(webservice).(*pdoknlv3.WCS); ok {
+			return createConfigForWCS(WCS, ownerInfo)
+		}
 	default:
-		return "", fmt.Errorf("unexpected input, webservice should be of type WFS or WMS, webservice: %v", webservice)
+		return "", fmt.Errorf("unexpected input, webservice should be of type WFS, WMS or WCS, webservice: %v", webservice)
 	}
-	return "", fmt.Errorf("unexpected input, webservice should be of type WFS or WMS, webservice: %v", webservice)
+	return "", fmt.Errorf("unexpected input, webservice should be of type WFS, WMS or WCS, webservice: %v", webservice)
 }
 
 func createConfigForWFS(wfs *pdoknlv3.WFS, ownerInfo *smoothoperatorv1.OwnerInfo) (config string, err error) {
@@ -82,3 +86,16 @@ func createConfigForWMS(wms *pdoknlv3.WMS, ownerInfo *smoothoperatorv1.OwnerInfo
 	}
 	return string(jsonConfig), nil
 }
+
+func createConfigForWCS(wcs *pdoknlv3.WCS, ownerInfo *smoothoperatorv1.OwnerInfo) (config string, err error) {
+	input, err := MapWCSToMapfileGeneratorInput(wcs, ownerInfo)
+	if err != nil {
+		return "", err
+	}
+
+	jsonConfig, err := json.MarshalIndent(input, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(jsonConfig), nil
+}
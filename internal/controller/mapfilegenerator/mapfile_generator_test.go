@@ -47,6 +47,10 @@ func TestGetConfigForWMSWithNoGroupLayers(t *testing.T) {
 	testWMS(t, "wms_groupless")
 }
 
+func TestGetConfigForWMSWithNoGroupLayersAndNamedTopLayer(t *testing.T) {
+	testWMS(t, "wms_groupless_named_toplayer")
+}
+
 func TestGetConfigForWMSWithGroupLayers(t *testing.T) {
 	testWMS(t, "wms_group")
 }
@@ -55,6 +59,11 @@ func TestGetConfigForWMSWithGroupLayersAndTopGroupLayer(t *testing.T) {
 	testWMS(t, "wms_group_and_toplayer")
 }
 
+// TestGetConfigForWMSWithFeatureInfo pins the queryable, opaque and template keys of the mapfile-generator input
+func TestGetConfigForWMSWithFeatureInfo(t *testing.T) {
+	testWMS(t, "wms_featureinfo")
+}
+
 func TestGetConfigForTifWMS(t *testing.T) {
 	testWMS(t, "wms_tif")
 }
@@ -108,3 +117,149 @@ func readExpectedWFS(filename string) (WFSInput, error) {
 
 	return expected, err
 }
+
+func TestGetConfigForWCS(t *testing.T) {
+	pdoknlv3.SetHost("https://service.pdok.nl")
+	ownerInfo := &smoothoperatorv1.OwnerInfo{
+		Spec: smoothoperatorv1.OwnerInfoSpec{
+			NamespaceTemplate: smoothoperatorutils.Pointer("http://{{prefix}}.geonovum.nl"),
+		},
+	}
+
+	input, err := os.ReadFile("test_data/input/wcs.yaml")
+	assert.NoError(t, err)
+	var wcs pdoknlv3.WCS
+	err = yaml.Unmarshal(input, &wcs)
+	assert.NoError(t, err)
+
+	inputStruct, err := MapWCSToMapfileGeneratorInput(&wcs, ownerInfo)
+	assert.NoError(t, err)
+	expected, err := readExpectedWCS("wcs.json")
+	assert.NoError(t, err)
+
+	diff := cmp.Diff(expected, inputStruct)
+	assert.Equal(t, diff, "", "%s", diff)
+}
+
+func TestGetConfigForWCSWithCustomServiceMetadataURL(t *testing.T) {
+	pdoknlv3.SetHost("https://service.pdok.nl")
+	ownerInfo := &smoothoperatorv1.OwnerInfo{
+		Spec: smoothoperatorv1.OwnerInfoSpec{
+			NamespaceTemplate: smoothoperatorutils.Pointer("http://{{prefix}}.geonovum.nl"),
+		},
+	}
+
+	input, err := os.ReadFile("test_data/input/wcs.yaml")
+	assert.NoError(t, err)
+	var wcs pdoknlv3.WCS
+	err = yaml.Unmarshal(input, &wcs)
+	assert.NoError(t, err)
+	wcs.Spec.Service.Inspire.ServiceMetadataURL = pdoknlv3.MetadataURL{Custom: &pdoknlv3.Custom{Type: "text/html"}}
+
+	inputStruct, err := MapWCSToMapfileGeneratorInput(&wcs, ownerInfo)
+	assert.NoError(t, err)
+	assert.Empty(t, inputStruct.MetadataID)
+}
+
+func readExpectedWCS(filename string) (WCSInput, error) {
+	bytes, err := os.ReadFile("test_data/expected/" + filename)
+	if err != nil {
+		return WCSInput{}, err
+	}
+
+	expected := WCSInput{}
+	err = json.Unmarshal(bytes, &expected)
+
+	return expected, err
+}
+
+func TestGetWMSLayerWithFeatureInfo(t *testing.T) {
+	layer := pdoknlv3.Layer{
+		Name:      smoothoperatorutils.Pointer("layer"),
+		Visible:   true,
+		Queryable: smoothoperatorutils.Pointer(false),
+		Opaque:    true,
+		FeatureInfo: &pdoknlv3.FeatureInfo{
+			Columns:  []string{"public"},
+			Template: smoothoperatorutils.Pointer("layer.html"),
+		},
+		Data: &pdoknlv3.Data{BaseData: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{
+			Columns: []pdoknlv3.Column{{Name: "public"}, {Name: "private"}},
+		}}},
+	}
+	wms := &pdoknlv3.WMS{Spec: pdoknlv3.WMSSpec{Service: pdoknlv3.WMSService{Layer: pdoknlv3.Layer{Layers: []pdoknlv3.Layer{layer}}}}}
+
+	wmsLayer := getWMSLayer(layer, "", wms)
+	assert.Equal(t, []Column{{Name: "fuuid"}, {Name: "public"}}, wmsLayer.Columns)
+	assert.Equal(t, smoothoperatorutils.Pointer(false), wmsLayer.Queryable)
+	assert.True(t, wmsLayer.Opaque)
+	assert.Equal(t, "/srv/data/config/templates/layer.html", wmsLayer.Template)
+}
+
+func TestGetColormapStyles(t *testing.T) {
+	layer := pdoknlv3.Layer{
+		Name:    smoothoperatorutils.Pointer("height:model"),
+		Visible: true,
+		Styles: []pdoknlv3.Style{
+			{Name: "mapfile", Visualization: smoothoperatorutils.Pointer("height.style")},
+			{Name: "ramp", Colormap: &pdoknlv3.Colormap{
+				Type: pdoknlv3.ColormapTypeRamp,
+				Entries: []pdoknlv3.ColormapEntry{
+					{Value: "-10", Color: "#0000FF", Label: smoothoperatorutils.Pointer("Low")},
+					{Value: "0", Color: "#00FF00"},
+					{Value: "100.5", Color: "#FF0000", Label: smoothoperatorutils.Pointer(`"High"`)},
+				},
+			}},
+			{Name: "classified", Colormap: &pdoknlv3.Colormap{
+				Type:    pdoknlv3.ColormapTypeClassified,
+				Entries: []pdoknlv3.ColormapEntry{{Value: "1", Color: "#FFFFFF", Label: smoothoperatorutils.Pointer("Water")}},
+			}},
+		},
+		Data: &pdoknlv3.Data{TIF: &pdoknlv3.TIF{
+			BlobKey: "container/key/height.tif",
+			Bands:   []int32{1},
+			NoData:  smoothoperatorutils.Pointer("-9999"),
+			Scale:   smoothoperatorutils.Pointer("AUTO"),
+		}},
+	}
+	wms := &pdoknlv3.WMS{Spec: pdoknlv3.WMSSpec{Service: pdoknlv3.WMSService{Layer: pdoknlv3.Layer{Layers: []pdoknlv3.Layer{layer}}}}}
+
+	assert.Equal(t, map[string]string{
+		"colormap-height_model-1.style": `CLASS
+  NAME "Low"
+  EXPRESSION ([pixel] >= -10 AND [pixel] < 0)
+  STYLE
+    COLORRANGE "#0000FF" "#00FF00"
+    DATARANGE -10 0
+  END
+END
+CLASS
+  EXPRESSION ([pixel] >= 0 AND [pixel] < 100.5)
+  STYLE
+    COLORRANGE "#00FF00" "#FF0000"
+    DATARANGE 0 100.5
+  END
+END
+CLASS
+  NAME "\"High\""
+  EXPRESSION ([pixel] = 100.5)
+  STYLE
+    COLOR "#FF0000"
+  END
+END
+`,
+		"colormap-height_model-2.style": `CLASS
+  NAME "Water"
+  EXPRESSION ([pixel] = 1)
+  STYLE
+    COLOR "#FFFFFF"
+  END
+END
+`,
+	}, GetColormapStyles(wms))
+
+	wmsLayer := getWMSLayer(layer, "", wms)
+	assert.Equal(t, "/styling/height.style", wmsLayer.Styles[0].Path)
+	assert.Equal(t, "/input/colormap-height_model-1.style", wmsLayer.Styles[1].Path)
+	assert.Equal(t, []string{"BANDS=1", "NODATA=-9999", "SCALE=AUTO"}, wmsLayer.Processing)
+}
@@ -8,7 +8,11 @@ import (
 	"github.com/pdok/mapserver-operator/internal/controller/constants"
 
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/datacache"
+	"github.com/pdok/mapserver-operator/internal/controller/datarefresh"
 	"github.com/pdok/mapserver-operator/internal/controller/mapperutils"
+	"github.com/pdok/mapserver-operator/internal/controller/prefetch"
+	"github.com/pdok/mapserver-operator/internal/crs"
 	smoothoperatorv1 "github.com/pdok/smooth-operator/api/v1"
 	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
 )
@@ -18,6 +22,9 @@ const (
 	tifPath            = "/srv/data/tif"
 	geopackagePath     = "/srv/data/gpkg"
 	defaultExtent      = "-25000 250000 280000 860000"
+
+	defaultStoredQueryParameterType = "xs:string"
+	defaultIDColumn                 = "fuuid"
 )
 
 var mapserverDebugLevel = 0
@@ -36,7 +43,7 @@ func MapWFSToMapfileGeneratorInput(wfs *pdoknlv3.WFS, ownerInfo *smoothoperatorv
 		metadataID = wfs.Spec.Service.Inspire.ServiceMetadataURL.CSW.MetadataIdentifier
 	}
 
-	extent := defaultExtent
+	extent := getDefaultExtent(wfs.Spec.Service.DefaultCrs)
 	if wfs.Spec.Service.Bbox != nil {
 		extent = wfs.Spec.Service.Bbox.DefaultCRS.ToExtent()
 	}
@@ -58,14 +65,21 @@ func MapWFSToMapfileGeneratorInput(wfs *pdoknlv3.WFS, ownerInfo *smoothoperatorv
 			DebugLevel:        mapserverDebugLevel,
 			AccessConstraints: wfs.Spec.Service.AccessConstraints.String(),
 		},
-		MaxFeatures: strconv.Itoa(smoothoperatorutils.PointerVal(wfs.Spec.Service.CountDefault, defaultMaxFeatures)),
-		Layers:      getWFSLayers(wfs.Spec.Service),
+		MaxFeatures:   strconv.Itoa(smoothoperatorutils.PointerVal(wfs.Spec.Service.CountDefault, defaultMaxFeatures)),
+		OutputFormats: wfs.Spec.Service.OutputFormats,
+		StoredQueries: getStoredQueries(wfs),
+		Layers:        getWFSLayers(wfs),
+	}
+
+	if wfs.OgcAPIFeaturesEnabled() {
+		input.OgcAPIOnlineResource = smoothoperatorutils.Pointer(wfs.URL().String() + constants.OgcAPIPath)
 	}
 
 	return input, nil
 }
 
-func getWFSLayers(service pdoknlv3.WFSService) (layers []WFSLayer) {
+func getWFSLayers(wfs *pdoknlv3.WFS) (layers []WFSLayer) {
+	service := wfs.Spec.Service
 	for _, featureType := range service.FeatureTypes {
 		metadataID := ""
 		if featureType.DatasetMetadataURL != nil && featureType.DatasetMetadataURL.CSW != nil {
@@ -77,17 +91,30 @@ func getWFSLayers(service pdoknlv3.WFSService) (layers []WFSLayer) {
 				Title:          featureType.Title,
 				Abstract:       featureType.Abstract,
 				Keywords:       strings.Join(featureType.Keywords, ","),
-				Extent:         getWFSExtent(featureType, service),
+				Extent:         getWFSExtent(wfs, featureType),
 				MetadataID:     metadataID,
 				Columns:        getColumns(featureType.Data),
 				TableName:      featureType.Data.GetTableName(),
+				Filter:         featureType.Data.GetFilter(),
+				SQLView:        featureType.Data.GetSQLView(),
+				GeometryColumn: getGeometryColumn(featureType.Data),
+				PrimaryKey:     featureType.Data.GetPrimaryKey(),
+				SortBy:         getSortBy(featureType.Data),
 				GeometryType:   featureType.Data.GetGeometryType(),
-				GeopackagePath: getGeopackagePath(featureType.Data.Gpkg),
+				GeopackagePath: GetFeatureTypeGeopackagePath(wfs, featureType),
 			},
+			OutputFormats: featureType.OutputFormats,
 		}
 		if featureType.Data.Postgis != nil {
 			layer.Postgis = smoothoperatorutils.Pointer(true)
 		}
+		if featureType.DefaultCrs != nil || len(featureType.OtherCrs) > 0 {
+			layer.DataEPSG = smoothoperatorutils.Pointer(wfs.GetDefaultCrs(featureType))
+			layer.EPSGList = append([]string{*layer.DataEPSG}, wfs.GetOtherCrs(featureType)...)
+		}
+		if featureType.CountDefault != nil {
+			layer.MaxFeatures = smoothoperatorutils.Pointer(strconv.Itoa(*featureType.CountDefault))
+		}
 
 		layers = append(layers, layer)
 	}
@@ -95,12 +122,49 @@ func getWFSLayers(service pdoknlv3.WFSService) (layers []WFSLayer) {
 	return
 }
 
-func getWFSExtent(featureType pdoknlv3.FeatureType, service pdoknlv3.WFSService) string {
+func getStoredQueries(wfs *pdoknlv3.WFS) (storedQueries []StoredQuery) {
+	for _, storedQuery := range wfs.Spec.Service.StoredQueries {
+		parameters := []StoredQueryParameter{}
+		for _, parameter := range storedQuery.Parameters {
+			parameterType := parameter.Type
+			if parameterType == "" {
+				parameterType = defaultStoredQueryParameterType
+			}
+			parameters = append(parameters, StoredQueryParameter{
+				Name:  parameter.Name,
+				Title: parameter.Title,
+				Type:  parameterType,
+			})
+		}
+
+		storedQueries = append(storedQueries, StoredQuery{
+			ID:          storedQuery.ID,
+			Title:       storedQuery.Title,
+			Abstract:    storedQuery.Abstract,
+			FeatureType: storedQuery.FeatureType,
+			Parameters:  parameters,
+			Filter:      storedQuery.Filter,
+		})
+	}
+
+	return
+}
+
+func getWFSExtent(wfs *pdoknlv3.WFS, featureType pdoknlv3.FeatureType) string {
 	if featureType.Bbox != nil && featureType.Bbox.DefaultCRS != nil {
 		return featureType.Bbox.DefaultCRS.ToExtent()
 	}
-	if service.Bbox != nil {
-		return service.Bbox.DefaultCRS.ToExtent()
+	defaultCrs := wfs.GetDefaultCrs(featureType)
+	if wfs.Spec.Service.Bbox != nil && defaultCrs == wfs.Spec.Service.DefaultCrs {
+		return wfs.Spec.Service.Bbox.DefaultCRS.ToExtent()
+	}
+	return getDefaultExtent(defaultCrs)
+}
+
+// getDefaultExtent returns the default bbox of the CRS from the CRS registry
+func getDefaultExtent(code string) string {
+	if bbox, ok := crs.DefaultBBox(code); ok {
+		return bbox.ToExtent()
 	}
 	return defaultExtent
 }
@@ -116,26 +180,170 @@ func getWMSExtent(serviceLayer pdoknlv3.Layer, serviceExtent string) string {
 }
 
 func getColumns(data pdoknlv3.BaseData) []Column {
-	columns := []Column{{Name: "fuuid"}}
-	if data.GetColumns() != nil {
-		for _, column := range *data.GetColumns() {
-			columns = append(columns, Column{Name: column.Name, Alias: column.Alias})
-		}
-	} else {
+	if data.GetColumns() == nil {
 		return nil
 	}
+
+	columns := []Column{}
+	primaryKey := data.GetPrimaryKey()
+	if primaryKey == nil {
+		columns = append(columns, Column{Name: defaultIDColumn})
+	}
+	for _, column := range *data.GetColumns() {
+		if column.Type != nil && *column.Type == pdoknlv3.GeometryColumnType {
+			continue
+		}
+		mapped := Column{Name: column.Name, Alias: column.Alias, Type: getGMLType(column.Type), Nullable: column.Nullable}
+		// The feature id comes first
+		if primaryKey != nil && column.Name == *primaryKey {
+			columns = append([]Column{mapped}, columns...)
+		} else {
+			columns = append(columns, mapped)
+		}
+	}
 	return columns
 }
 
-func getGeopackagePath(gpkg *pdoknlv3.Gpkg) *string {
+func getGMLType(columnType *string) *string {
+	if columnType == nil {
+		return nil
+	}
+	switch *columnType {
+	case "int":
+		return smoothoperatorutils.Pointer("Integer")
+	case "double":
+		return smoothoperatorutils.Pointer("Real")
+	case "date":
+		return smoothoperatorutils.Pointer("Date")
+	default:
+		return smoothoperatorutils.Pointer("Character")
+	}
+}
+
+func getGeometryColumn(data pdoknlv3.BaseData) *string {
+	if data.GetColumns() == nil {
+		return nil
+	}
+	for _, column := range *data.GetColumns() {
+		if column.Type != nil && *column.Type == pdoknlv3.GeometryColumnType {
+			return &column.Name
+		}
+	}
+	return nil
+}
+
+// getSortBy orders the features on the defaultSort or else the primaryKey, needed for stable paging
+func getSortBy(data pdoknlv3.BaseData) *string {
+	if defaultSort := data.GetDefaultSort(); defaultSort != nil {
+		order := defaultSort.Order
+		if order == "" {
+			order = "ASC"
+		}
+		return smoothoperatorutils.Pointer(defaultSort.Column + " " + order)
+	}
+	if primaryKey := data.GetPrimaryKey(); primaryKey != nil {
+		return smoothoperatorutils.Pointer(*primaryKey + " ASC")
+	}
+	return nil
+}
+
+// GetFeatureTypeGeopackagePath returns the path of the geopackage of a featureType in the mapfile
+func GetFeatureTypeGeopackagePath(wfs *pdoknlv3.WFS, featureType pdoknlv3.FeatureType) *string {
+	gpkg := featureType.Data.Gpkg
 	if gpkg == nil {
 		return nil
 	}
+	if prefetch.IsStreamed(wfs, gpkg.BlobKey) {
+		return smoothoperatorutils.Pointer(getGeopackageStreamPath(gpkg.BlobKey))
+	}
+	if datacache.UseDataCache(wfs) {
+		return smoothoperatorutils.Pointer(datacache.GetCachedFilePath(gpkg.BlobKey))
+	}
+	if datarefresh.UseDataRefresh(wfs) {
+		return smoothoperatorutils.Pointer(datarefresh.GetLinkPath(featureType.Name))
+	}
 	index := strings.LastIndex(gpkg.BlobKey, "/") + 1
 	blobName := gpkg.BlobKey[index:]
 	return smoothoperatorutils.Pointer(geopackagePath + "/" + blobName)
 }
 
+func MapWCSToMapfileGeneratorInput(wcs *pdoknlv3.WCS, ownerInfo *smoothoperatorv1.OwnerInfo) (WCSInput, error) {
+	service := wcs.Spec.Service
+
+	var metadataID string
+	if service.Inspire != nil && service.Inspire.ServiceMetadataURL.CSW != nil {
+		metadataID = service.Inspire.ServiceMetadataURL.CSW.MetadataIdentifier
+	}
+
+	extent := getDefaultExtent(service.DefaultCrs)
+	if service.Bbox != nil {
+		extent = service.Bbox.DefaultCRS.ToExtent()
+	}
+
+	input := WCSInput{
+		BaseServiceInput: BaseServiceInput{
+			Title:             service.Title,
+			Abstract:          service.Abstract,
+			Keywords:          strings.Join(service.KeywordsIncludingInspireKeyword(), ","),
+			OnlineResource:    wcs.URL().Scheme + "://" + wcs.URL().Host,
+			Path:              wcs.URL().Path,
+			MetadataID:        metadataID,
+			Extent:            extent,
+			NamespacePrefix:   service.Prefix,
+			NamespaceURI:      mapperutils.GetNamespaceURI(service.Prefix, ownerInfo),
+			AutomaticCasing:   wcs.Options().AutomaticCasing,
+			DataEPSG:          service.DefaultCrs,
+			EPSGList:          append([]string{service.DefaultCrs}, service.OtherCrs...),
+			DebugLevel:        mapserverDebugLevel,
+			AccessConstraints: service.AccessConstraints.String(),
+		},
+		OutputFormats: wcs.GetOutputFormats(),
+		Layers:        getWCSLayers(wcs, extent),
+	}
+
+	return input, nil
+}
+
+func getWCSLayers(wcs *pdoknlv3.WCS, serviceExtent string) (layers []WCSLayer) {
+	for _, coverage := range wcs.Spec.Service.Coverages {
+		metadataID := ""
+		if coverage.DatasetMetadataURL != nil && coverage.DatasetMetadataURL.CSW != nil {
+			metadataID = coverage.DatasetMetadataURL.CSW.MetadataIdentifier
+		}
+		extent := serviceExtent
+		if coverage.Bbox != nil && coverage.Bbox.DefaultCRS != nil {
+			extent = coverage.Bbox.DefaultCRS.ToExtent()
+		}
+		tif := coverage.Data.TIF
+		layer := WCSLayer{
+			BaseLayer: BaseLayer{
+				Name:            coverage.Name,
+				Title:           coverage.Title,
+				Abstract:        coverage.Abstract,
+				Keywords:        strings.Join(coverage.Keywords, ","),
+				Extent:          extent,
+				MetadataID:      metadataID,
+				GeometryType:    smoothoperatorutils.Pointer("Raster"),
+				TifPath:         GetTifPath(wcs, tif.BlobKey),
+				Resample:        &tif.Resample,
+				OversampleRatio: &tif.OversampleRatio,
+			},
+		}
+		for _, band := range coverage.Bands {
+			layer.Bands = append(layer.Bands, Band{
+				Name:        band.Name,
+				Description: band.Description,
+				UOM:         band.UOM,
+				NilValue:    band.NilValue,
+			})
+		}
+
+		layers = append(layers, layer)
+	}
+
+	return
+}
+
 func MapWMSToMapfileGeneratorInput(wms *pdoknlv3.WMS, ownerInfo *smoothoperatorv1.OwnerInfo) (WMSInput, error) {
 	service := wms.Spec.Service
 
@@ -233,6 +441,7 @@ func mapLayers(wms *pdoknlv3.WMS, extent string, result *WMSInput) {
 		} else if annotatedLayer.IsGroupLayer && !annotatedLayer.IsTopLayer {
 			groupLayer := GroupLayer{
 				Name:       *annotatedLayer.Name,
+				GroupName:  getGroupName(annotatedLayer.Layer, wms),
 				Title:      smoothoperatorutils.PointerVal(annotatedLayer.Title, ""),
 				Abstract:   smoothoperatorutils.PointerVal(annotatedLayer.Abstract, ""),
 				StyleName:  "",
@@ -253,22 +462,36 @@ func mapLayers(wms *pdoknlv3.WMS, extent string, result *WMSInput) {
 	result.EPSGList = epsgs
 }
 
-func getWMSLayer(serviceLayer pdoknlv3.Layer, serviceExtent string, wms *pdoknlv3.WMS) WMSLayer {
-	groupName := ""
+// getGroupName returns the name of the group the layer belongs to.
+// If the layer falls directly under a toplayer that has group layers, the groupname is omitted.
+// In a 2 level tree the toplayer is the group of all layers.
+func getGroupName(serviceLayer pdoknlv3.Layer, wms *pdoknlv3.WMS) string {
 	parent := wms.Spec.Service.GetParentLayer(serviceLayer)
-	// If the layer falls directly under the toplayer, the groupname is omitted
-	if !parent.IsTopLayer() && parent.IsGroupLayer() && parent.Name != nil && parent.Visible {
-		groupName = *parent.Name
+	if parent == nil || !parent.IsGroupLayer() || parent.Name == nil || !parent.Visible {
+		return ""
 	}
+	if wms.Spec.Service.IsTopLayer(parent) && slices.ContainsFunc(parent.Layers, func(layer pdoknlv3.Layer) bool { return layer.IsGroupLayer() }) {
+		return ""
+	}
+	return *parent.Name
+}
+
+func getWMSLayer(serviceLayer pdoknlv3.Layer, serviceExtent string, wms *pdoknlv3.WMS) WMSLayer {
+	groupName := getGroupName(serviceLayer, wms)
 
 	var columns []Column
 	if serviceLayer.Data != nil {
-		columns = getColumns(serviceLayer.Data.BaseData)
+		columns = getFeatureInfoColumns(serviceLayer, getColumns(serviceLayer.Data.BaseData))
 	}
 
-	var tableName *string
+	var tableName, filter, sqlView, geometryColumn, primaryKey, sortBy *string
 	if serviceLayer.Data != nil {
 		tableName = serviceLayer.Data.GetTableName()
+		filter = serviceLayer.Data.GetFilter()
+		sqlView = serviceLayer.Data.GetSQLView()
+		geometryColumn = getGeometryColumn(serviceLayer.Data.BaseData)
+		primaryKey = serviceLayer.Data.GetPrimaryKey()
+		sortBy = getSortBy(serviceLayer.Data.BaseData)
 	}
 
 	metadataID := ""
@@ -288,6 +511,11 @@ func getWMSLayer(serviceLayer pdoknlv3.Layer, serviceExtent string, wms *pdoknlv
 			GeometryType:   nil,
 			GeopackagePath: nil,
 			TableName:      tableName,
+			Filter:         filter,
+			SQLView:        sqlView,
+			GeometryColumn: geometryColumn,
+			PrimaryKey:     primaryKey,
+			SortBy:         sortBy,
 			Postgis:        nil,
 			MinScale:       serviceLayer.MinScaleDenominator,
 			MaxScale:       serviceLayer.MaxScaleDenominator,
@@ -296,14 +524,30 @@ func getWMSLayer(serviceLayer pdoknlv3.Layer, serviceExtent string, wms *pdoknlv
 		GroupName: groupName,
 		Styles:    []Style{},
 		Offsite:   "",
+		Opaque:    serviceLayer.Opaque,
+	}
+
+	if !serviceLayer.IsQueryable() {
+		result.Queryable = smoothoperatorutils.Pointer(false)
+	}
+	// The featureinfo-templates init container copies the custom templates next to the generated template
+	if template := wms.Spec.Service.GetFeatureInfoTemplate(&serviceLayer); template != nil {
+		result.Template = constants.HTMLTemplatesPath + "/" + *template
 	}
 
-	for _, style := range serviceLayer.Styles {
-		stylePath := "/styling/" + smoothoperatorutils.PointerVal(style.Visualization, "")
-		result.Styles = append(result.Styles, Style{
-			Path:  stylePath,
+	for i, style := range serviceLayer.Styles {
+		mapfileStyle := Style{
+			Path:  GetStylePath(wms, style, "/styling"),
 			Title: smoothoperatorutils.PointerVal(style.Title, ""),
-		})
+		}
+		if style.Colormap != nil {
+			mapfileStyle.Path = colormapStylesPath + "/" + getColormapStyleKey(*serviceLayer.Name, i)
+		}
+		if style.IsSLD() {
+			mapfileStyle.Name = style.Name
+			mapfileStyle.SLD = true
+		}
+		result.Styles = append(result.Styles, mapfileStyle)
 	}
 
 	if serviceLayer.Data != nil {
@@ -313,6 +557,31 @@ func getWMSLayer(serviceLayer pdoknlv3.Layer, serviceExtent string, wms *pdoknlv
 	return result
 }
 
+// getFeatureInfoColumns returns the columns in the GetFeatureInfo response of the layer, the feature id is always included
+func getFeatureInfoColumns(serviceLayer pdoknlv3.Layer, columns []Column) []Column {
+	if columns == nil {
+		return nil
+	}
+	primaryKey := smoothoperatorutils.PointerVal(serviceLayer.Data.GetPrimaryKey(), defaultIDColumn)
+	result := []Column{}
+	for _, column := range columns {
+		if column.Name == primaryKey || column.Name == defaultIDColumn || serviceLayer.IncludesFeatureInfoColumn(column.Name) {
+			result = append(result, column)
+		}
+	}
+	return result
+}
+
+// GetStylePath returns the path of the visualization of the style, stylingFilesPath is where the ConfigMap keys are mounted
+func GetStylePath(wms *pdoknlv3.WMS, style pdoknlv3.Style, stylingFilesPath string) string {
+	visualization := smoothoperatorutils.PointerVal(style.Visualization, "")
+	if !slices.Contains(wms.Spec.Service.StylingAssets.GetAllConfigMapRefKeys(), visualization) &&
+		wms.Spec.Service.StylingAssets.GetSLDBlobKey(visualization) != nil {
+		return constants.SLDPath + "/" + visualization
+	}
+	return stylingFilesPath + "/" + visualization
+}
+
 func getSymbols(wms *pdoknlv3.WMS) []string {
 	result := make([]string, 0)
 	service := wms.Spec.Service
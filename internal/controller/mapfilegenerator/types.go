@@ -2,9 +2,13 @@ package mapfilegenerator
 
 import (
 	"path"
-	"regexp"
 
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/cog"
+	"github.com/pdok/mapserver-operator/internal/controller/datacache"
+	"github.com/pdok/mapserver-operator/internal/controller/datarefresh"
+	"github.com/pdok/mapserver-operator/internal/controller/prefetch"
+	"github.com/pdok/mapserver-operator/internal/controller/utils"
 	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
 )
 
@@ -31,8 +35,11 @@ type BaseServiceInput struct {
 //nolint:tagliatelle
 type WFSInput struct {
 	BaseServiceInput
-	MaxFeatures string     `json:"service_wfs_maxfeatures"`
-	Layers      []WFSLayer `json:"layers"`
+	MaxFeatures          string        `json:"service_wfs_maxfeatures"`
+	OutputFormats        []string      `json:"outputformats,omitempty"`
+	OgcAPIOnlineResource *string       `json:"ogcapi_onlineresource,omitempty"`
+	StoredQueries        []StoredQuery `json:"stored_queries,omitempty"`
+	Layers               []WFSLayer    `json:"layers"`
 }
 
 //nolint:tagliatelle
@@ -51,6 +58,13 @@ type WMSInput struct {
 	DefResolution   string       `json:"defresolution,omitempty"`
 }
 
+//nolint:tagliatelle
+type WCSInput struct {
+	BaseServiceInput
+	OutputFormats []string   `json:"outputformats"`
+	Layers        []WCSLayer `json:"layers"`
+}
+
 //nolint:tagliatelle
 type BaseLayer struct {
 	Name            string   `json:"name"`
@@ -63,6 +77,11 @@ type BaseLayer struct {
 	GeometryType    *string  `json:"geometry_type,omitempty"`
 	GeopackagePath  *string  `json:"gpkg_path,omitempty"`
 	TableName       *string  `json:"tablename,omitempty"`
+	Filter          *string  `json:"filter,omitempty"`
+	SQLView         *string  `json:"sql_view,omitempty"`
+	GeometryColumn  *string  `json:"geometry_column,omitempty"`
+	PrimaryKey      *string  `json:"primary_key,omitempty"`
+	SortBy          *string  `json:"sortby,omitempty"`
 	Postgis         *bool    `json:"postgis,omitempty"`
 	MinScale        *string  `json:"minscale,omitempty"`
 	MaxScale        *string  `json:"maxscale,omitempty"`
@@ -72,13 +91,19 @@ type BaseLayer struct {
 	LabelNoClip     bool     `json:"label_no_clip,omitempty"`
 }
 
+//nolint:tagliatelle
 type WFSLayer struct {
 	BaseLayer
+	OutputFormats []string `json:"outputformats,omitempty"`
+	DataEPSG      *string  `json:"data_epsg,omitempty"`
+	EPSGList      []string `json:"epsg_list,omitempty"`
+	MaxFeatures   *string  `json:"wfs_maxfeatures,omitempty"`
 }
 
 //nolint:tagliatelle
 type GroupLayer struct {
 	Name       string `json:"name"`
+	GroupName  string `json:"group_name,omitempty"`
 	Title      string `json:"title"`
 	Abstract   string `json:"abstract"`
 	StyleName  string `json:"style_name"`
@@ -92,16 +117,57 @@ type WMSLayer struct {
 	Styles                      []Style `json:"styles"`
 	Offsite                     string  `json:"offsite,omitempty"`
 	GetFeatureInfoIncludesClass *bool   `json:"get_feature_info_includes_class,omitempty"`
+	// Processing are the PROCESSING directives of a raster layer, e.g. BANDS=1
+	Processing []string `json:"processing,omitempty"`
+	// Queryable is only set when the layer doesn't answer GetFeatureInfo requests
+	Queryable *bool `json:"queryable,omitempty"`
+	Opaque    bool  `json:"opaque,omitempty"`
+	// Template is the path of a custom HTML template, instead of the generated one
+	Template string `json:"template,omitempty"`
 }
 
-type Column struct {
+type WCSLayer struct {
+	BaseLayer
+	Bands []Band `json:"bands,omitempty"`
+}
+
+//nolint:tagliatelle
+type Band struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+	UOM         *string `json:"uom,omitempty"`
+	NilValue    *string `json:"nil_value,omitempty"`
+}
+
+//nolint:tagliatelle
+type StoredQuery struct {
+	ID          string                 `json:"id"`
+	Title       string                 `json:"title"`
+	Abstract    *string                `json:"abstract,omitempty"`
+	FeatureType string                 `json:"feature_type"`
+	Parameters  []StoredQueryParameter `json:"parameters,omitempty"`
+	Filter      string                 `json:"filter"`
+}
+
+type StoredQueryParameter struct {
 	Name  string  `json:"name"`
-	Alias *string `json:"alias,omitempty"`
+	Title *string `json:"title,omitempty"`
+	Type  string  `json:"type"`
+}
+
+type Column struct {
+	Name     string  `json:"name"`
+	Alias    *string `json:"alias,omitempty"`
+	Type     *string `json:"type,omitempty"`
+	Nullable *bool   `json:"nullable,omitempty"`
 }
 
 type Style struct {
 	Path  string `json:"path"`
 	Title string `json:"title,omitempty"`
+	// Name is only set for SLD documents, the style in the document is applied with this name
+	Name string `json:"name,omitempty"`
+	SLD  bool   `json:"sld,omitempty"`
 }
 
 func SetDataFields[O pdoknlv3.WMSWFS](obj O, wmsLayer *WMSLayer, data pdoknlv3.Data) {
@@ -110,27 +176,47 @@ func SetDataFields[O pdoknlv3.WMSWFS](obj O, wmsLayer *WMSLayer, data pdoknlv3.D
 		gpkg := data.Gpkg
 
 		wmsLayer.GeometryType = &gpkg.GeometryType
-		geopackageConstructedPath := "/srv/data/gpkg/" + path.Base(gpkg.BlobKey)
-		if !obj.Options().PrefetchData {
-			reReplace := regexp.MustCompile(`$[a-zA-Z0-9_]*]/`)
-			geopackageConstructedPath = path.Join("/vsiaz/geopackages", reReplace.ReplaceAllString(gpkg.BlobKey, ""))
-		}
-		wmsLayer.GeopackagePath = &geopackageConstructedPath
+		wmsLayer.GeopackagePath = smoothoperatorutils.Pointer(GetLayerGeopackagePath(obj, wmsLayer.Name, gpkg.BlobKey))
 	case data.TIF != nil:
 		tif := data.TIF
 		wmsLayer.GeometryType = smoothoperatorutils.Pointer("Raster")
-		wmsLayer.TifPath = smoothoperatorutils.Pointer(path.Join(tifPath, path.Base(tif.BlobKey)))
-		if !obj.Options().PrefetchData {
-			reReplace := regexp.MustCompile(`$[a-zA-Z0-9_]*]/`)
-			wmsLayer.TifPath = smoothoperatorutils.Pointer(path.Join("/vsiaz", reReplace.ReplaceAllString(tif.BlobKey, "")))
-		}
+		wmsLayer.TifPath = GetTifPath(obj, tif.BlobKey)
 		wmsLayer.Resample = &tif.Resample
 		wmsLayer.OversampleRatio = &tif.OversampleRatio
 		wmsLayer.Offsite = smoothoperatorutils.PointerVal(tif.Offsite, "")
 		wmsLayer.GetFeatureInfoIncludesClass = &tif.GetFeatureInfoIncludesClass
+		wmsLayer.Processing = getRasterProcessing(tif)
 	case data.Postgis != nil:
 		postgis := data.Postgis
 		wmsLayer.Postgis = smoothoperatorutils.Pointer(true)
 		wmsLayer.GeometryType = &postgis.GeometryType
 	}
 }
+
+// GetLayerGeopackagePath returns the path of the geopackage of a layer in the mapfile
+func GetLayerGeopackagePath[O pdoknlv3.WMSWFS](obj O, name, blobKey string) string {
+	geopackageConstructedPath := "/srv/data/gpkg/" + path.Base(blobKey)
+	if prefetch.IsStreamed(obj, blobKey) {
+		geopackageConstructedPath = getGeopackageStreamPath(blobKey)
+	} else if datacache.UseDataCache(obj) {
+		geopackageConstructedPath = datacache.GetCachedFilePath(blobKey)
+	} else if datarefresh.UseDataRefresh(obj) {
+		geopackageConstructedPath = datarefresh.GetLinkPath(name)
+	}
+	return geopackageConstructedPath
+}
+
+func getGeopackageStreamPath(blobKey string) string {
+	return path.Join("/vsiaz/geopackages", utils.ReplaceBucketVariable(blobKey, ""))
+}
+
+// GetTifPath returns the path of a TIFF in the mapfile
+func GetTifPath[O pdoknlv3.WMSWFS](obj O, blobKey string) *string {
+	if prefetch.IsStreamed(obj, blobKey) {
+		return smoothoperatorutils.Pointer(cog.GetStreamPath(blobKey))
+	}
+	if datacache.UseDataCache(obj) {
+		return smoothoperatorutils.Pointer(datacache.GetCachedFilePath(blobKey))
+	}
+	return smoothoperatorutils.Pointer(path.Join(tifPath, path.Base(blobKey)))
+}
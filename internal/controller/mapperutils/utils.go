@@ -39,6 +39,10 @@ func GetContainerResourceLimit[O pdoknlv3.WMSWFS](obj O, containerName string, r
 }
 
 // Use ephemeral volume when ephemeral storage is greater then 10Gi
+// MinEphemeralStorage is the ephemeral storage limit of the mapserver container when it doesn't use an ephemeral volume,
+// unless the podSpecPatch sets a higher limit
+var MinEphemeralStorage = resource.MustParse("200M")
+
 func UseEphemeralVolume[O pdoknlv3.WMSWFS](obj O) (bool, *resource.Quantity) {
 	value := EphemeralStorageLimit(obj)
 	threshold := resource.MustParse("10Gi")
@@ -50,14 +54,31 @@ func UseEphemeralVolume[O pdoknlv3.WMSWFS](obj O) (bool, *resource.Quantity) {
 	return false, nil
 }
 
+// EphemeralStorageLimit returns the ephemeral storage limit of the mapserver container,
+// with autoEphemeralStorage this is the size in the status once it is computed
 func EphemeralStorageLimit[O pdoknlv3.WMSWFS](obj O) *resource.Quantity {
+	if size := GetAutoEphemeralStorage(obj); size != nil {
+		return size
+	}
 	return GetContainerResourceLimit(obj, constants.MapserverName, corev1.ResourceEphemeralStorage)
 }
 
 func EphemeralStorageRequest[O pdoknlv3.WMSWFS](obj O) *resource.Quantity {
+	if size := GetAutoEphemeralStorage(obj); size != nil {
+		return size
+	}
 	return GetContainerResourceRequest(obj, constants.MapserverName, corev1.ResourceEphemeralStorage)
 }
 
+// GetAutoEphemeralStorage returns a copy of the computed ephemeral storage in the status when autoEphemeralStorage is used
+func GetAutoEphemeralStorage[O pdoknlv3.WMSWFS](obj O) *resource.Quantity {
+	if !obj.Options().AutoEphemeralStorage || obj.ServiceStatus().EphemeralStorage == nil {
+		return nil
+	}
+	size := obj.ServiceStatus().EphemeralStorage.DeepCopy()
+	return &size
+}
+
 func GetNamespaceURI(prefix string, ownerInfo *smoothoperatorv1.OwnerInfo) string {
 	return strings.ReplaceAll(*ownerInfo.Spec.NamespaceTemplate, "{{prefix}}", prefix)
 }
@@ -2,9 +2,12 @@ package mapserver
 
 import (
 	"errors"
+	"strconv"
 	"strings"
 
+	"github.com/pdok/mapserver-operator/internal/controller/cog"
 	"github.com/pdok/mapserver-operator/internal/controller/constants"
+	"github.com/pdok/mapserver-operator/internal/controller/datacache"
 
 	"github.com/pdok/mapserver-operator/internal/controller/utils"
 
@@ -15,7 +18,10 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 )
 
-const mimeTextXML = "text/xml"
+const (
+	mimeTextXML         = "text/xml"
+	mimeApplicationJSON = "application/json"
+)
 
 func GetMapserverContainer[O pdoknlv3.WMSWFS](obj O, images types.Images) (*corev1.Container, error) {
 	livenessProbe, readinessProbe, startupProbe, err := getProbes(obj)
@@ -27,7 +33,7 @@ func GetMapserverContainer[O pdoknlv3.WMSWFS](obj O, images types.Images) (*core
 		Name:            constants.MapserverName,
 		Image:           images.MapserverImage,
 		ImagePullPolicy: corev1.PullIfNotPresent,
-		Ports:           []corev1.ContainerPort{{ContainerPort: constants.MapserverPortNr, Protocol: corev1.ProtocolTCP}},
+		Ports:           []corev1.ContainerPort{{ContainerPort: constants.MapserverContainerPortNr, Protocol: corev1.ProtocolTCP}},
 		Env: []corev1.EnvVar{
 			{
 				Name:  "SERVICE_TYPE",
@@ -58,6 +64,19 @@ func GetMapserverContainer[O pdoknlv3.WMSWFS](obj O, images types.Images) (*core
 		container.Resources.Requests[corev1.ResourceCPU] = resource.MustParse("0.1")
 	}
 
+	if datacache.UseDataCache(obj) {
+		container.VolumeMounts = append(container.VolumeMounts, datacache.GetVolumeMount())
+	}
+
+	if cog.UseStreaming(obj) {
+		container.Env = append(container.Env, cog.GetEnv()...)
+	}
+
+	// ogc.lua only passes the OGC API Features requests on to mapserver when these are enabled
+	if wfs, ok := any(obj).(*pdoknlv3.WFS); ok && wfs.OgcAPIFeaturesEnabled() {
+		container.Env = append(container.Env, corev1.EnvVar{Name: "OGC_API_FEATURES", Value: "true"})
+	}
+
 	return &container, nil
 }
 
@@ -65,6 +84,7 @@ func getVolumeMounts(customMapfile bool, wms bool) []corev1.VolumeMount {
 	volumeMounts := []corev1.VolumeMount{
 		utils.GetBaseVolumeMount(),
 		utils.GetDataVolumeMount(),
+		{Name: constants.VarTmpVolumeName, MountPath: "/var/tmp"},
 	}
 
 	staticFiles, _ := static.GetStaticFiles()
@@ -122,6 +142,16 @@ func getProbes[O pdoknlv3.WMSWFS](obj O) (livenessProbe *corev1.Probe, readiness
 		if err != nil {
 			return nil, nil, nil, err
 		}
+	case pdoknlv3.ServiceTypeWCS:
+		wcs, _ := any(obj).(*pdoknlv3.WCS)
+		readinessProbe, err = getReadinessProbeForWCS(wcs)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		startupProbe, err = getStartupProbeForWCS(wcs)
+		if err != nil {
+			return nil, nil, nil, err
+		}
 	}
 	return
 }
@@ -136,7 +166,14 @@ func getReadinessProbeForWFS(wfs *pdoknlv3.WFS) (*corev1.Probe, error) {
 	if err != nil {
 		return nil, err
 	}
-	return getProbe(queryString, mime), nil
+	probe := getProbe(queryString, mime)
+
+	// Only ready when the OGC API landing page is served as well
+	if wfs.OgcAPIFeaturesEnabled() {
+		command := probe.Exec.Command
+		command[len(command)-1] += " && " + getProbeCmd(constants.MapserverOgcAPIPath+"?f=json", mimeApplicationJSON)
+	}
+	return probe, nil
 }
 
 func getReadinessProbeForWMS(wms *pdoknlv3.WMS) (*corev1.Probe, error) {
@@ -148,6 +185,15 @@ func getReadinessProbeForWMS(wms *pdoknlv3.WMS) (*corev1.Probe, error) {
 	return getProbe(queryString, mime), nil
 }
 
+func getReadinessProbeForWCS(wcs *pdoknlv3.WCS) (*corev1.Probe, error) {
+	queryString, mime, err := wcs.ReadinessQueryString()
+	if err != nil {
+		return nil, err
+	}
+
+	return getProbe(queryString, mime), nil
+}
+
 func getStartupProbeForWFS(wfs *pdoknlv3.WFS) (*corev1.Probe, error) {
 	if hc := wfs.Spec.HealthCheck; hc != nil {
 		return getProbe(hc.Querystring, hc.Mimetype), nil
@@ -165,6 +211,23 @@ func getStartupProbeForWFS(wfs *pdoknlv3.WFS) (*corev1.Probe, error) {
 	return getProbe(queryString, mimeTextXML), nil
 }
 
+func getStartupProbeForWCS(wcs *pdoknlv3.WCS) (*corev1.Probe, error) {
+	if hc := wcs.Spec.HealthCheck; hc != nil {
+		return getProbe(hc.Querystring, hc.Mimetype), nil
+	}
+
+	var coverageIDs []string
+	for _, coverage := range wcs.Spec.Service.Coverages {
+		coverageIDs = append(coverageIDs, coverage.Name)
+	}
+	if len(coverageIDs) == 0 {
+		return nil, errors.New("cannot get startup probe for WCS, coverages could not be found")
+	}
+
+	queryString := "SERVICE=WCS&VERSION=2.0.1&REQUEST=DescribeCoverage&COVERAGEID=" + strings.Join(coverageIDs, ",")
+	return getProbe(queryString, mimeTextXML), nil
+}
+
 func getStartupProbeForWMS(wms *pdoknlv3.WMS) (*corev1.Probe, error) {
 	if hc := wms.Spec.HealthCheck; hc != nil && hc.Querystring != nil {
 		return getProbe(*hc.Querystring, *hc.Mimetype), nil
@@ -187,7 +250,7 @@ func getStartupProbeForWMS(wms *pdoknlv3.WMS) (*corev1.Probe, error) {
 }
 
 func getProbe(queryString string, mimeType string) *corev1.Probe {
-	probeCmd := "wget -SO- -T 10 -t 2 'http://127.0.0.1:80/mapserver?" + queryString + "' 2>&1 | egrep -aiA10 'HTTP/1.1 200' | egrep -i 'Content-Type: " + mimeType + "'"
+	probeCmd := getProbeCmd("/mapserver?"+queryString, mimeType)
 	return &corev1.Probe{
 		ProbeHandler: corev1.ProbeHandler{Exec: &corev1.ExecAction{
 			Command: []string{
@@ -203,3 +266,7 @@ func getProbe(queryString string, mimeType string) *corev1.Probe {
 		TimeoutSeconds:      10,
 	}
 }
+
+func getProbeCmd(path string, mimeType string) string {
+	return "wget -SO- -T 10 -t 2 'http://127.0.0.1:" + strconv.Itoa(int(constants.MapserverContainerPortNr)) + path + "' 2>&1 | egrep -aiA10 'HTTP/1.1 200' | egrep -i 'Content-Type: " + mimeType + "'"
+}
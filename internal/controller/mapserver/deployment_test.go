@@ -5,6 +5,7 @@ import (
 
 	"github.com/pdok/mapserver-operator/api/v2beta1"
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/types"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/yaml"
@@ -54,6 +55,35 @@ func TestGetProbesForDeployment(t *testing.T) {
 	assert.Equal(t, &expectedStartup, startupResult)
 }
 
+// The containers run without NET_BIND_SERVICE, so lighttpd and the probes must use an unprivileged port
+func TestGetMapserverContainerPort(t *testing.T) {
+	wfs := getV3()
+	pdoknlv3.SetHost("https://service.pdok.nl")
+	container, err := GetMapserverContainer(wfs, types.Images{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []corev1.ContainerPort{{ContainerPort: 8080, Protocol: corev1.ProtocolTCP}}, container.Ports)
+	for _, probe := range []*corev1.Probe{container.LivenessProbe, container.ReadinessProbe, container.StartupProbe} {
+		assert.Contains(t, probe.Exec.Command[2], "'http://127.0.0.1:8080/mapserver?")
+	}
+	assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: "var-tmp", MountPath: "/var/tmp"})
+}
+
+func TestGetMapserverContainerOgcAPIFeatures(t *testing.T) {
+	wfs := getV3()
+	pdoknlv3.SetHost("https://service.pdok.nl")
+	ogcAPIFeatures := corev1.EnvVar{Name: "OGC_API_FEATURES", Value: "true"}
+
+	container, err := GetMapserverContainer(wfs, types.Images{})
+	assert.NoError(t, err)
+	assert.NotContains(t, container.Env, ogcAPIFeatures)
+
+	wfs.Spec.Options.OgcAPIFeatures = true
+	container, err = GetMapserverContainer(wfs, types.Images{})
+	assert.NoError(t, err)
+	assert.Contains(t, container.Env, ogcAPIFeatures)
+}
+
 //go:embed test_data/v2_input.yaml
 var v2Input []byte
 
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/constants"
+	"github.com/pdok/mapserver-operator/internal/controller/types"
+	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	namespaceNameLabelKey       = "kubernetes.io/metadata.name"
+	dnsPortNr             int32 = 53
+)
+
+var networkPolicyConfig = types.NetworkPolicyConfig{}
+
+func SetNetworkPolicyConfig(config types.NetworkPolicyConfig) {
+	networkPolicyConfig = config
+}
+
+func getBareNetworkPolicy[O pdoknlv3.WMSWFS](obj O) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getSuffixedName(obj, constants.MapserverName),
+			Namespace: obj.GetNamespace(),
+		},
+	}
+}
+
+func mutateNetworkPolicy[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O, networkPolicy *networkingv1.NetworkPolicy) error {
+	reconcilerClient := getReconcilerClient(r)
+
+	labels := addCommonLabels(obj, smoothoperatorutils.CloneOrEmptyMap(obj.GetLabels()))
+	if err := smoothoperatorutils.SetImmutableLabels(reconcilerClient, networkPolicy, labels); err != nil {
+		return err
+	}
+
+	matchLabels := smoothoperatorutils.CloneOrEmptyMap(labels)
+	networkPolicy.Spec = networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{
+			MatchLabels: matchLabels,
+		},
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		Ingress:     getNetworkPolicyIngressRules(obj),
+		Egress:      getNetworkPolicyEgressRules(obj),
+	}
+
+	if err := smoothoperatorutils.EnsureSetGVK(reconcilerClient, networkPolicy, networkPolicy); err != nil {
+		return err
+	}
+	return ctrl.SetControllerReference(obj, networkPolicy, getReconcilerScheme(r))
+}
+
+func getNetworkPolicyIngressRules[O pdoknlv3.WMSWFS](obj O) []networkingv1.NetworkPolicyIngressRule {
+	webservicePorts := []networkingv1.NetworkPolicyPort{getNetworkPolicyPort(corev1.ProtocolTCP, constants.MapserverContainerPortNr)}
+	if obj.Type() == pdoknlv3.ServiceTypeWMS && obj.Options().UseWebserviceProxy() {
+		webservicePorts = append(webservicePorts, getNetworkPolicyPort(corev1.ProtocolTCP, mapserverWebserviceProxyPortNr))
+	}
+
+	return []networkingv1.NetworkPolicyIngressRule{
+		{
+			Ports: webservicePorts,
+			From:  []networkingv1.NetworkPolicyPeer{getNamespacePeer(networkPolicyConfig.IngressNamespace)},
+		},
+		{
+			Ports: []networkingv1.NetworkPolicyPort{getNetworkPolicyPort(corev1.ProtocolTCP, constants.ApachePortNr)},
+			From:  []networkingv1.NetworkPolicyPeer{getNamespacePeer(networkPolicyConfig.MonitoringNamespace)},
+		},
+	}
+}
+
+func getNetworkPolicyEgressRules[O pdoknlv3.WMSWFS](obj O) []networkingv1.NetworkPolicyEgressRule {
+	rules := []networkingv1.NetworkPolicyEgressRule{
+		{
+			Ports: []networkingv1.NetworkPolicyPort{
+				getNetworkPolicyPort(corev1.ProtocolUDP, dnsPortNr),
+				getNetworkPolicyPort(corev1.ProtocolTCP, dnsPortNr),
+			},
+			To: []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{}}},
+		},
+		// Blob storage is always needed, the init containers download the config, styling and legends
+		// and without prefetching mapserver reads the data itself through /vsiaz
+		{
+			Ports: []networkingv1.NetworkPolicyPort{getNetworkPolicyPort(corev1.ProtocolTCP, networkPolicyConfig.BlobStoragePort)},
+			To:    getIPBlockPeers(networkPolicyConfig.BlobStorageCIDRs),
+		},
+	}
+
+	if obj.HasPostgisData() {
+		rules = append(rules, networkingv1.NetworkPolicyEgressRule{
+			Ports: []networkingv1.NetworkPolicyPort{getNetworkPolicyPort(corev1.ProtocolTCP, networkPolicyConfig.PostgisPort)},
+			To:    getIPBlockPeers(networkPolicyConfig.PostgisCIDRs),
+		})
+	}
+
+	return rules
+}
+
+func getNetworkPolicyPort(protocol corev1.Protocol, port int32) networkingv1.NetworkPolicyPort {
+	return networkingv1.NetworkPolicyPort{
+		Protocol: &protocol,
+		Port:     smoothoperatorutils.Pointer(intstr.FromInt32(port)),
+	}
+}
+
+func getNamespacePeer(namespace string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{namespaceNameLabelKey: namespace},
+		},
+	}
+}
+
+// getIPBlockPeers returns no peers (all destinations) when no CIDRs are configured
+func getIPBlockPeers(cidrs []string) []networkingv1.NetworkPolicyPeer {
+	var peers []networkingv1.NetworkPolicyPeer
+	for _, cidr := range cidrs {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+	}
+	return peers
+}
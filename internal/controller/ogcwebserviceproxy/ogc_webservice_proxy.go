@@ -1,6 +1,8 @@
 package ogcwebserviceproxy
 
 import (
+	"strconv"
+
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
 	"github.com/pdok/mapserver-operator/internal/controller/constants"
 	"github.com/pdok/mapserver-operator/internal/controller/types"
@@ -35,7 +37,7 @@ func GetOgcWebserviceProxyContainer(wms *pdoknlv3.WMS, images types.Images) (*co
 func getCommand(wms *pdoknlv3.WMS) []string {
 	command := []string{
 		"/ogc-webservice-proxy",
-		"-h=http://127.0.0.1/",
+		"-h=http://127.0.0.1:" + strconv.Itoa(int(constants.MapserverContainerPortNr)) + "/",
 		"-t=wms",
 		"-s=/input/service-config.yaml",
 	}
@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/blobdownload"
+	"github.com/pdok/mapserver-operator/internal/controller/blobmetadata"
+	"github.com/pdok/mapserver-operator/internal/controller/datacache"
+	"github.com/pdok/mapserver-operator/internal/controller/datarefresh"
+	"github.com/pdok/mapserver-operator/internal/controller/mapperutils"
+	"github.com/pdok/mapserver-operator/internal/controller/prefetch"
+	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const megabyte = 1_000_000
+
+var (
+	blobMetadataClient blobmetadata.Client
+	// blobMetadataInterval is how long the blob sizes read by the blob metadata client are kept
+	blobMetadataInterval = time.Hour
+	// blobMeasurements are the blobs of an object the blob metadata client read last and when
+	blobMeasurements     = map[k8stypes.UID]blobMeasurement{}
+	blobMeasurementsLock sync.Mutex
+
+	// ephemeralStorageHeadroom is the percentage that is added to the size of the prefetched blobs
+	ephemeralStorageHeadroom int64 = 25
+)
+
+type blobMeasurement struct {
+	blobKeys   []string
+	measuredAt time.Time
+}
+
+// SetBlobMetadataClient makes the operator read the blob sizes itself, instead of from the blob-download of the pods.
+// The sizes of an object are read again when its blobs change or after the interval.
+func SetBlobMetadataClient(c blobmetadata.Client, interval time.Duration) {
+	blobMetadataClient = c
+	blobMetadataInterval = interval
+	blobdownload.SetMeasureBlobs(c == nil)
+}
+
+func SetEphemeralStorageHeadroom(percentage int64) {
+	ephemeralStorageHeadroom = percentage
+}
+
+// updateBlobSizes updates the blob sizes and the computed ephemeral storage in the status. The sizes are read by the
+// blob metadata client when configured, otherwise they are copied from the blob-download init container of the
+// newest pod. A changed size can change which blobs with prefetch auto are downloaded and the ephemeral storage,
+// which rolls out new pods.
+func updateBlobSizes[R Reconciler, O pdoknlv3.WMSWFS](ctx context.Context, r R, obj O) error {
+	status := obj.ServiceStatus()
+	if !prefetch.UseBlobSizes(obj) {
+		if len(status.Blobs) == 0 && status.EphemeralStorage == nil {
+			return nil
+		}
+		status.Blobs = nil
+		status.EphemeralStorage = nil
+		return r.Status().Update(ctx, any(obj).(client.Object))
+	}
+
+	sizes, err := getBlobSizes(ctx, r, obj)
+	if err != nil {
+		return err
+	}
+	if sizes == nil {
+		sizes = status.Blobs
+	}
+
+	previous := status.DeepCopy()
+	status.Blobs = sizes
+	status.EphemeralStorage = nil
+	if obj.Options().AutoEphemeralStorage {
+		status.EphemeralStorage = getAutoEphemeralStorage(obj)
+	}
+	if equality.Semantic.DeepEqual(previous.Blobs, status.Blobs) && equality.Semantic.DeepEqual(previous.EphemeralStorage, status.EphemeralStorage) {
+		return nil
+	}
+	return r.Status().Update(ctx, any(obj).(client.Object))
+}
+
+// getBlobSizes returns the sizes of the measured blobs, nil if they aren't read yet or weren't read again. A blob
+// metadata client that fails is logged and doesn't fail the reconcile.
+func getBlobSizes[R Reconciler, O pdoknlv3.WMSWFS](ctx context.Context, r R, obj O) ([]pdoknlv3.BlobSize, error) {
+	if blobMetadataClient != nil {
+		blobKeys := prefetch.GetMeasuredBlobKeys(obj)
+		if !startBlobMeasurement(obj.GetUID(), blobKeys) {
+			return nil, nil
+		}
+		sizes, err := blobmetadata.GetSizes(ctx, blobMetadataClient, blobKeys, obj.ServiceStatus())
+		if err != nil {
+			// The blobs that can't be read keep their size in the status, a retry follows on the next reconcile
+			log.FromContext(ctx).Error(err, "unable to read the size of all blobs, keeping their previous size")
+			forgetBlobMeasurement(obj.GetUID())
+		}
+		return sizes, nil
+	}
+
+	reconcilerClient := getReconcilerClient(r)
+	labels := addCommonLabels(obj, smoothoperatorutils.CloneOrEmptyMap(obj.GetLabels()))
+	podList := &corev1.PodList{}
+	if err := reconcilerClient.List(ctx, podList, client.InNamespace(obj.GetNamespace()), client.MatchingLabels(labels)); err != nil {
+		return nil, fmt.Errorf("unable to list pods: %w", err)
+	}
+	return prefetch.GetBlobSizes(obj, podList.Items)
+}
+
+// startBlobMeasurement records that the blobs of an object are read now, false if they were read within the interval.
+// Measurements older than the interval, e.g. of deleted objects, are dropped.
+func startBlobMeasurement(uid k8stypes.UID, blobKeys []string) bool {
+	blobMeasurementsLock.Lock()
+	defer blobMeasurementsLock.Unlock()
+
+	now := time.Now()
+	for key, measurement := range blobMeasurements {
+		if now.Sub(measurement.measuredAt) >= blobMetadataInterval {
+			delete(blobMeasurements, key)
+		}
+	}
+	if measurement, ok := blobMeasurements[uid]; ok && slices.Equal(measurement.blobKeys, blobKeys) {
+		return false
+	}
+	blobMeasurements[uid] = blobMeasurement{blobKeys: blobKeys, measuredAt: now}
+	return true
+}
+
+func forgetBlobMeasurement(uid k8stypes.UID) {
+	blobMeasurementsLock.Lock()
+	defer blobMeasurementsLock.Unlock()
+	delete(blobMeasurements, uid)
+}
+
+// getAutoEphemeralStorage returns the ephemeral storage of the mapserver container for the prefetched blobs in the
+// status plus the headroom, rounded up to megabytes. Data refresh downloads a new version of a geopackage next to
+// the current one, so these count twice. With the data cache the blobs are on the node instead.
+// Nil while the size of a prefetched blob is unknown.
+func getAutoEphemeralStorage[O pdoknlv3.WMSWFS](obj O) *resource.Quantity {
+	var total int64
+	if !datacache.UseDataCache(obj) {
+		streamed := prefetch.GetStreamedBlobKeys(obj)
+		geopackages := map[string]bool{}
+		for _, gpkg := range obj.GeoPackages() {
+			geopackages[gpkg.BlobKey] = true
+		}
+
+		for _, blobKey := range prefetch.GetMeasuredBlobKeys(obj) {
+			if slices.Contains(streamed, blobKey) {
+				continue
+			}
+			size := obj.ServiceStatus().GetBlobSize(blobKey)
+			if size == nil {
+				return nil
+			}
+			total += *size
+			if geopackages[blobKey] && datarefresh.UseDataRefresh(obj) {
+				total += *size
+			}
+		}
+	}
+
+	total = total * (100 + ephemeralStorageHeadroom) / 100
+	megabytes := (total + megabyte - 1) / megabyte
+	return resource.NewScaledQuantity(max(megabytes, mapperutils.MinEphemeralStorage.Value()/megabyte), resource.Mega)
+}
@@ -0,0 +1,187 @@
+package prefetch
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/constants"
+	"github.com/pdok/mapserver-operator/internal/controller/mapperutils"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ranks orders the policies, a blob that is used more than once gets the policy that prefetches the most
+var ranks = map[string]int{
+	pdoknlv3.PrefetchNever:  0,
+	pdoknlv3.PrefetchAuto:   1,
+	pdoknlv3.PrefetchAlways: 2,
+}
+
+// getPolicies returns the prefetch policy of every geopackage and TIFF blob of the webservice
+func getPolicies[O pdoknlv3.WMSWFS](obj O) map[string]string {
+	defaultPolicy := pdoknlv3.PrefetchNever
+	if obj.Options().PrefetchData {
+		defaultPolicy = pdoknlv3.PrefetchAlways
+	}
+
+	policies := map[string]string{}
+	add := func(blobKey string, prefetch *string) {
+		policy := defaultPolicy
+		if prefetch != nil {
+			policy = *prefetch
+		}
+		if current, ok := policies[blobKey]; !ok || ranks[policy] > ranks[current] {
+			policies[blobKey] = policy
+		}
+	}
+
+	for _, gpkg := range obj.GeoPackages() {
+		add(gpkg.BlobKey, gpkg.Prefetch)
+	}
+	for _, tif := range getTIFs(obj) {
+		add(tif.BlobKey, tif.Prefetch)
+	}
+	return policies
+}
+
+func getTIFs[O pdoknlv3.WMSWFS](obj O) []*pdoknlv3.TIF {
+	switch webservice := any(obj).(type) {
+	case *pdoknlv3.WMS:
+		return webservice.GetTIFs()
+	case *pdoknlv3.WCS:
+		return webservice.GetTIFs()
+	}
+	return nil
+}
+
+// GetStreamedBlobKeys returns the sorted blobKeys of the geopackages and TIFFs that mapserver streams from blob storage.
+// A blob with prefetch auto is prefetched when its size is known and it fits in the ephemeral storage
+// that is left by the blobs that are always prefetched.
+func GetStreamedBlobKeys[O pdoknlv3.WMSWFS](obj O) []string {
+	policies := getPolicies(obj)
+	status := obj.ServiceStatus()
+
+	available := getEphemeralStorage(obj)
+	for blobKey, policy := range policies {
+		if size := status.GetBlobSize(blobKey); policy == pdoknlv3.PrefetchAlways && size != nil {
+			available -= *size
+		}
+	}
+
+	streamed := []string{}
+	for _, blobKey := range slices.Sorted(maps.Keys(policies)) {
+		switch policies[blobKey] {
+		case pdoknlv3.PrefetchNever:
+			streamed = append(streamed, blobKey)
+		case pdoknlv3.PrefetchAuto:
+			if size := status.GetBlobSize(blobKey); size != nil && *size <= available {
+				available -= *size
+			} else {
+				streamed = append(streamed, blobKey)
+			}
+		}
+	}
+	return streamed
+}
+
+// getEphemeralStorage returns the ephemeral storage limit of the mapserver container in bytes. This is the limit of the
+// podSpecPatch, also with autoEphemeralStorage, as the computed size depends on the blobs that are prefetched.
+func getEphemeralStorage[O pdoknlv3.WMSWFS](obj O) int64 {
+	if limit := mapperutils.GetContainerResourceLimit(obj, constants.MapserverName, corev1.ResourceEphemeralStorage); limit != nil {
+		return max(limit.Value(), mapperutils.MinEphemeralStorage.Value())
+	}
+	return mapperutils.MinEphemeralStorage.Value()
+}
+
+// IsStreamed returns whether the geopackage or TIFF with the blobKey is streamed from blob storage
+func IsStreamed[O pdoknlv3.WMSWFS](obj O, blobKey string) bool {
+	return slices.Contains(GetStreamedBlobKeys(obj), blobKey)
+}
+
+// MayPrefetch returns whether geopackages or TIFFs may be downloaded before mapserver starts
+func MayPrefetch[O pdoknlv3.WMSWFS](obj O) bool {
+	if obj.Options().PrefetchData {
+		return true
+	}
+	for _, policy := range getPolicies(obj) {
+		if policy != pdoknlv3.PrefetchNever {
+			return true
+		}
+	}
+	return false
+}
+
+// UseAutoPrefetch returns whether a geopackage or TIFF has prefetch auto
+func UseAutoPrefetch[O pdoknlv3.WMSWFS](obj O) bool {
+	return slices.Contains(slices.Collect(maps.Values(getPolicies(obj))), pdoknlv3.PrefetchAuto)
+}
+
+// UseBlobSizes returns whether the sizes of the blobs are read, for prefetch auto or autoEphemeralStorage
+func UseBlobSizes[O pdoknlv3.WMSWFS](obj O) bool {
+	return UseAutoPrefetch(obj) || obj.Options().AutoEphemeralStorage
+}
+
+// GetMeasuredBlobKeys returns the sorted blobKeys whose size is read, these are all blobs that may be prefetched
+func GetMeasuredBlobKeys[O pdoknlv3.WMSWFS](obj O) []string {
+	if !UseBlobSizes(obj) {
+		return []string{}
+	}
+	blobKeys := []string{}
+	for blobKey, policy := range getPolicies(obj) {
+		if policy != pdoknlv3.PrefetchNever {
+			blobKeys = append(blobKeys, blobKey)
+		}
+	}
+	slices.Sort(blobKeys)
+	return blobKeys
+}
+
+// GetBlobSizes returns the sizes in the termination message of the blob-download init container of the newest pod
+// where it terminated. The sizes are read before the downloads, so they are reported even when a download failed
+// because the ephemeral storage is too small. Nil if no pod got that far yet.
+func GetBlobSizes[O pdoknlv3.WMSWFS](obj O, pods []corev1.Pod) ([]pdoknlv3.BlobSize, error) {
+	var message *string
+	var newest *corev1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		if newest != nil && !newest.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			continue
+		}
+		for _, status := range pod.Status.InitContainerStatuses {
+			if status.Name == constants.BlobDownloadName && status.State.Terminated != nil {
+				newest, message = pod, &status.State.Terminated.Message
+			}
+		}
+	}
+	if message == nil {
+		return nil, nil
+	}
+	return parseMessage(obj, *message)
+}
+
+func parseMessage[O pdoknlv3.WMSWFS](obj O, message string) ([]pdoknlv3.BlobSize, error) {
+	measured := GetMeasuredBlobKeys(obj)
+	sizes := []pdoknlv3.BlobSize{}
+	for _, line := range strings.Split(strings.TrimSpace(message), "\n") {
+		fields := strings.Fields(line)
+		// The size is missing when rclone couldn't read the blob, it is streamed until its size is known
+		if len(fields) < 2 {
+			continue
+		}
+		blobKey := fields[0]
+		// The blob was removed since the pod started
+		if !slices.Contains(measured, blobKey) {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size of blob %s: %w", blobKey, err)
+		}
+		sizes = append(sizes, pdoknlv3.BlobSize{BlobKey: blobKey, Size: size})
+	}
+	slices.SortFunc(sizes, func(a, b pdoknlv3.BlobSize) int { return strings.Compare(a.BlobKey, b.BlobKey) })
+	return sizes, nil
+}
@@ -0,0 +1,136 @@
+package prefetch
+
+import (
+	"testing"
+	"time"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/controller/constants"
+	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func getWMS(prefetchData bool) *pdoknlv3.WMS {
+	gpkgLayer := func(name, blobKey string, prefetch *string) pdoknlv3.Layer {
+		return pdoknlv3.Layer{
+			Name: smoothoperatorutils.Pointer(name),
+			Data: &pdoknlv3.Data{BaseData: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: blobKey, Prefetch: prefetch}}},
+		}
+	}
+	tifLayer := func(name, blobKey string, prefetch *string) pdoknlv3.Layer {
+		return pdoknlv3.Layer{
+			Name: smoothoperatorutils.Pointer(name),
+			Data: &pdoknlv3.Data{TIF: &pdoknlv3.TIF{BlobKey: blobKey, Prefetch: prefetch}},
+		}
+	}
+	return &pdoknlv3.WMS{
+		Spec: pdoknlv3.WMSSpec{
+			Options: &pdoknlv3.Options{BaseOptions: pdoknlv3.BaseOptions{PrefetchData: prefetchData}},
+			Service: pdoknlv3.WMSService{
+				Layer: pdoknlv3.Layer{
+					Name: smoothoperatorutils.Pointer("top"),
+					Layers: []pdoknlv3.Layer{
+						gpkgLayer("default", "geopackages-bucket/key/default.gpkg", nil),
+						gpkgLayer("small", "geopackages-bucket/key/small.gpkg", smoothoperatorutils.Pointer(pdoknlv3.PrefetchAuto)),
+						// The blob is always prefetched for the other layer
+						gpkgLayer("shared", "geopackages-bucket/key/default.gpkg", smoothoperatorutils.Pointer(pdoknlv3.PrefetchNever)),
+						tifLayer("mosaic", "tifs-bucket/key/mosaic.vrt", smoothoperatorutils.Pointer(pdoknlv3.PrefetchAuto)),
+						tifLayer("streamed", "tifs-bucket/key/streamed.tif", smoothoperatorutils.Pointer(pdoknlv3.PrefetchNever)),
+					},
+				},
+			},
+		},
+	}
+}
+
+func setEphemeralStorageLimit(wms *pdoknlv3.WMS, limit string) {
+	wms.Spec.PodSpecPatch = corev1.PodSpec{
+		Containers: []corev1.Container{{
+			Name: constants.MapserverName,
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse(limit)},
+			},
+		}},
+	}
+}
+
+func TestGetStreamedBlobKeys(t *testing.T) {
+	// The sizes aren't known yet
+	wms := getWMS(true)
+	assert.Equal(t, []string{"geopackages-bucket/key/small.gpkg", "tifs-bucket/key/mosaic.vrt", "tifs-bucket/key/streamed.tif"}, GetStreamedBlobKeys(wms))
+	assert.False(t, IsStreamed(wms, "geopackages-bucket/key/default.gpkg"))
+
+	// Only the geopackage fits next to the blob that is always prefetched
+	setEphemeralStorageLimit(wms, "1G")
+	wms.Status.Blobs = []pdoknlv3.BlobSize{
+		{BlobKey: "geopackages-bucket/key/default.gpkg", Size: 300_000_000},
+		{BlobKey: "geopackages-bucket/key/small.gpkg", Size: 100_000_000},
+		{BlobKey: "tifs-bucket/key/mosaic.vrt", Size: 700_000_000},
+	}
+	assert.Equal(t, []string{"tifs-bucket/key/mosaic.vrt", "tifs-bucket/key/streamed.tif"}, GetStreamedBlobKeys(wms))
+
+	setEphemeralStorageLimit(wms, "2G")
+	assert.Equal(t, []string{"tifs-bucket/key/streamed.tif"}, GetStreamedBlobKeys(wms))
+
+	// Without prefetchData the blobs without prefetch are streamed
+	assert.Equal(t, []string{"geopackages-bucket/key/default.gpkg", "geopackages-bucket/key/small.gpkg", "tifs-bucket/key/mosaic.vrt", "tifs-bucket/key/streamed.tif"}, GetStreamedBlobKeys(getWMS(false)))
+}
+
+func TestMayPrefetch(t *testing.T) {
+	assert.True(t, MayPrefetch(getWMS(false)))
+
+	wfs := &pdoknlv3.WFS{
+		Spec: pdoknlv3.WFSSpec{
+			Options: &pdoknlv3.WFSOptions{BaseOptions: pdoknlv3.BaseOptions{PrefetchData: false}},
+			Service: pdoknlv3.WFSService{
+				FeatureTypes: []pdoknlv3.FeatureType{
+					{Name: "gpkg", Data: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: "geopackages-bucket/key/file.gpkg"}}},
+				},
+			},
+		},
+	}
+	assert.False(t, MayPrefetch(wfs))
+	assert.False(t, UseAutoPrefetch(wfs))
+	assert.Empty(t, GetMeasuredBlobKeys(wfs))
+}
+
+func TestGetMeasuredBlobKeys(t *testing.T) {
+	assert.Equal(t, []string{"geopackages-bucket/key/default.gpkg", "geopackages-bucket/key/small.gpkg", "tifs-bucket/key/mosaic.vrt"}, GetMeasuredBlobKeys(getWMS(true)))
+}
+
+func TestGetBlobSizes(t *testing.T) {
+	wms := getWMS(true)
+	pod := func(created time.Time, message string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)},
+			Status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{{
+					Name:  constants.BlobDownloadName,
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0, Message: message}},
+				}},
+			},
+		}
+	}
+
+	sizes, err := GetBlobSizes(wms, nil)
+	require.NoError(t, err)
+	assert.Nil(t, sizes)
+
+	now := time.Now()
+	sizes, err = GetBlobSizes(wms, []corev1.Pod{
+		pod(now, "tifs-bucket/key/mosaic.vrt 2000\ngeopackages-bucket/key/small.gpkg\ngeopackages-bucket/key/removed.gpkg 10\ngeopackages-bucket/key/default.gpkg 1000\n"),
+		pod(now.Add(-time.Hour), "tifs-bucket/key/mosaic.vrt 1\n"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []pdoknlv3.BlobSize{
+		{BlobKey: "geopackages-bucket/key/default.gpkg", Size: 1000},
+		{BlobKey: "tifs-bucket/key/mosaic.vrt", Size: 2000},
+	}, sizes)
+
+	_, err = GetBlobSizes(wms, []corev1.Pod{pod(now, "tifs-bucket/key/mosaic.vrt large\n")})
+	assert.Error(t, err)
+}
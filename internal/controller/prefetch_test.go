@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestGetAutoEphemeralStorage(t *testing.T) {
+	wfs := &pdoknlv3.WFS{
+		Spec: pdoknlv3.WFSSpec{
+			Options: &pdoknlv3.WFSOptions{BaseOptions: pdoknlv3.BaseOptions{PrefetchData: true, AutoEphemeralStorage: true}},
+			Service: pdoknlv3.WFSService{
+				FeatureTypes: []pdoknlv3.FeatureType{
+					{Name: "a", Data: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: "geopackages-bucket/key/a.gpkg"}}},
+					{Name: "b", Data: pdoknlv3.BaseData{Gpkg: &pdoknlv3.Gpkg{BlobKey: "geopackages-bucket/key/b.gpkg"}}},
+				},
+			},
+		},
+	}
+
+	// The size of b isn't known yet
+	wfs.Status.Blobs = []pdoknlv3.BlobSize{{BlobKey: "geopackages-bucket/key/a.gpkg", Size: 4_000_000_000}}
+	assert.Nil(t, getAutoEphemeralStorage(wfs))
+
+	wfs.Status.Blobs = append(wfs.Status.Blobs, pdoknlv3.BlobSize{BlobKey: "geopackages-bucket/key/b.gpkg", Size: 1})
+	expected := resource.MustParse("5001M")
+	assert.Equal(t, expected.Value(), getAutoEphemeralStorage(wfs).Value())
+
+	// A new version is downloaded next to the current one
+	wfs.Spec.Options.DataRefresh = true
+	expected = resource.MustParse("10001M")
+	assert.Equal(t, expected.Value(), getAutoEphemeralStorage(wfs).Value())
+
+	// Small blobs get the minimum
+	wfs.Status.Blobs = []pdoknlv3.BlobSize{{BlobKey: "geopackages-bucket/key/a.gpkg", Size: 1}, {BlobKey: "geopackages-bucket/key/b.gpkg", Size: 1}}
+	assert.Equal(t, int64(200_000_000), getAutoEphemeralStorage(wfs).Value())
+}
+
+func TestStartBlobMeasurement(t *testing.T) {
+	defer func(interval time.Duration) { blobMetadataInterval = interval }(blobMetadataInterval)
+	blobMetadataInterval = time.Hour
+
+	assert.True(t, startBlobMeasurement("a", []string{"bucket/a.gpkg"}))
+	assert.False(t, startBlobMeasurement("a", []string{"bucket/a.gpkg"}))
+	// The blobs of a changed
+	assert.True(t, startBlobMeasurement("a", []string{"bucket/a.gpkg", "bucket/b.gpkg"}))
+	assert.False(t, startBlobMeasurement("a", []string{"bucket/a.gpkg", "bucket/b.gpkg"}))
+	assert.True(t, startBlobMeasurement("b", []string{"bucket/a.gpkg"}))
+
+	// A failed measurement is read again
+	forgetBlobMeasurement("a")
+	assert.True(t, startBlobMeasurement("a", []string{"bucket/a.gpkg", "bucket/b.gpkg"}))
+
+	// After the interval
+	blobMetadataInterval = 0
+	assert.True(t, startBlobMeasurement("a", []string{"bucket/a.gpkg", "bucket/b.gpkg"}))
+	assert.Len(t, blobMeasurements, 1)
+}
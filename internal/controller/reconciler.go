@@ -7,7 +7,7 @@ import (
 )
 
 type Reconciler interface {
-	*WFSReconciler | *WMSReconciler
+	*WFSReconciler | *WMSReconciler | *WCSReconciler
 	client.StatusClient
 }
 
@@ -17,6 +17,8 @@ func getReconcilerClient[R Reconciler](r R) client.Client {
 		return any(r).(*WFSReconciler).Client
 	case *WMSReconciler:
 		return any(r).(*WMSReconciler).Client
+	case *WCSReconciler:
+		return any(r).(*WCSReconciler).Client
 	}
 
 	return nil
@@ -28,6 +30,8 @@ func getReconcilerScheme[R Reconciler](r R) *runtime.Scheme {
 		return any(r).(*WFSReconciler).Scheme
 	case *WMSReconciler:
 		return any(r).(*WMSReconciler).Scheme
+	case *WCSReconciler:
+		return any(r).(*WCSReconciler).Scheme
 	}
 
 	return nil
@@ -39,6 +43,8 @@ func getReconcilerImages[R Reconciler](r R) *types.Images {
 		return &any(r).(*WFSReconciler).Images
 	case *WMSReconciler:
 		return &any(r).(*WMSReconciler).Images
+	case *WCSReconciler:
+		return &any(r).(*WCSReconciler).Images
 	}
 
 	return nil
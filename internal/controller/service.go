@@ -37,7 +37,7 @@ func mutateService[R Reconciler, O pdoknlv3.WMSWFS](r R, obj O, service *corev1.
 		{
 			Name:       constants.MapserverName,
 			Port:       constants.MapserverPortNr,
-			TargetPort: intstr.FromInt32(constants.MapserverPortNr),
+			TargetPort: intstr.FromInt32(constants.MapserverContainerPortNr),
 			Protocol:   corev1.ProtocolTCP,
 		},
 	}
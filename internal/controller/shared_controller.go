@@ -11,9 +11,12 @@ import (
 
 	"github.com/pkg/errors"
 
+	"go.opentelemetry.io/otel/trace"
+
 	traefikiov1alpha1 "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	networkingv1 "k8s.io/api/networking/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -24,9 +27,12 @@ import (
 	"github.com/pdok/smooth-operator/model"
 
 	"github.com/pdok/mapserver-operator/internal/controller/constants"
+	"github.com/pdok/mapserver-operator/internal/controller/datarefresh"
+	"github.com/pdok/mapserver-operator/internal/controller/prefetch"
 
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
 	"github.com/pdok/mapserver-operator/internal/controller/types"
+	"github.com/pdok/mapserver-operator/internal/tracing"
 	smoothoperatorv1 "github.com/pdok/smooth-operator/api/v1"
 	smoothoperatorstatus "github.com/pdok/smooth-operator/pkg/status"
 	smoothoperatorutils "github.com/pdok/smooth-operator/pkg/util"
@@ -46,6 +52,8 @@ func createControllerManager(mgr ctrl.Manager, obj client.Object) *builder.Typed
 		kind = "WMS"
 	case *pdoknlv3.WFS:
 		kind = "WFS"
+	case *pdoknlv3.WCS:
+		kind = "WCS"
 	}
 
 	controllerMgr := ctrl.NewControllerManagedBy(mgr).For(obj).Named(strings.ToLower(kind))
@@ -56,6 +64,8 @@ func createControllerManager(mgr ctrl.Manager, obj client.Object) *builder.Typed
 		Owns(&traefikiov1alpha1.IngressRoute{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Owns(&autoscalingv2.HorizontalPodAutoscaler{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Owns(&policyv1.PodDisruptionBudget{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&networkingv1.NetworkPolicy{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&corev1.PersistentVolumeClaim{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Owns(&smoothoperatorv1.OwnerInfo{}, builder.WithPredicates(predicate.GenerationChangedPredicate{}))
 
 	return controllerMgr.Watches(&appsv1.ReplicaSet{}, smoothoperatorstatus.GetReplicaSetEventHandlerForObj(mgr, kind))
@@ -70,6 +80,9 @@ func ttlExpired[O pdoknlv3.WMSWFS](obj O) bool {
 	case *pdoknlv3.WMS:
 		wms := any(obj).(*pdoknlv3.WMS)
 		lifecycle = wms.Spec.Lifecycle
+	case *pdoknlv3.WCS:
+		wcs := any(obj).(*pdoknlv3.WCS)
+		lifecycle = wcs.Spec.Lifecycle
 	}
 
 	if lifecycle != nil && lifecycle.TTLInDays != nil {
@@ -103,6 +116,8 @@ func addCommonLabels[O pdoknlv3.WMSWFS](obj O, labels map[string]string) map[str
 		inspire = any(obj).(*pdoknlv3.WFS).Spec.Service.Inspire != nil
 	case *pdoknlv3.WMS:
 		inspire = any(obj).(*pdoknlv3.WMS).Spec.Service.Inspire != nil
+	case *pdoknlv3.WCS:
+		inspire = any(obj).(*pdoknlv3.WCS).Spec.Service.Inspire != nil
 	}
 
 	labels[InspireLabelKey] = strconv.FormatBool(inspire)
@@ -113,83 +128,160 @@ func addCommonLabels[O pdoknlv3.WMSWFS](obj O, labels map[string]string) map[str
 func createOrUpdateAllForWMSWFS[R Reconciler, O pdoknlv3.WMSWFS](ctx context.Context, r R, obj O, ownerInfo *smoothoperatorv1.OwnerInfo) (operationResults map[string]controllerutil.OperationResult, err error) {
 	reconcilerClient := getReconcilerClient(r)
 
-	hashedConfigMapNames, operationResults, err := createOrUpdateConfigMaps(ctx, r, obj, ownerInfo)
+	// region Extents
+	{
+		regionCtx, span := startRegionSpan(ctx, obj, "Extents")
+		err = updateExtents(regionCtx, r, obj)
+		tracing.EndSpan(span, err)
+		if err != nil {
+			return operationResults, err
+		}
+	}
+	// end region Extents
+
+	// region BlobSizes
+	{
+		regionCtx, span := startRegionSpan(ctx, obj, "BlobSizes")
+		err = updateBlobSizes(regionCtx, r, obj)
+		tracing.EndSpan(span, err)
+		if err != nil {
+			return operationResults, err
+		}
+	}
+	// end region BlobSizes
+
+	// region ConfigMaps
+	regionCtx, span := startRegionSpan(ctx, obj, "ConfigMaps")
+	hashedConfigMapNames, operationResults, err := createOrUpdateConfigMaps(regionCtx, r, obj, ownerInfo)
+	tracing.EndSpan(span, err)
 	if err != nil {
 		return operationResults, err
 	}
+	// end region ConfigMaps
+
+	// region DataCacheVolume
+	{
+		regionCtx, span := startRegionSpan(ctx, obj, "DataCacheVolume")
+		err = createOrUpdateOrDeleteDataCacheVolume(regionCtx, r, obj, operationResults)
+		tracing.EndSpan(span, err)
+		if err != nil {
+			return operationResults, err
+		}
+	}
+	// end region DataCacheVolume
 
 	// region Deployment
 	{
+		regionCtx, span := startRegionSpan(ctx, obj, "Deployment")
 		deployment := getBareDeployment(obj)
-		operationResults[smoothoperatorutils.GetObjectFullName(reconcilerClient, deployment)], err = controllerutil.CreateOrUpdate(ctx, reconcilerClient, deployment, func() error {
+		operationResults[smoothoperatorutils.GetObjectFullName(reconcilerClient, deployment)], err = controllerutil.CreateOrUpdate(regionCtx, reconcilerClient, deployment, func() error {
 			return mutateDeployment(r, obj, deployment, hashedConfigMapNames)
 		})
 		if err != nil && !strings.Contains(err.Error(), "the object has been modified; please apply your changes to the latest version and try again") {
-			return operationResults, fmt.Errorf("unable to create/update resource %s: %w", smoothoperatorutils.GetObjectFullName(reconcilerClient, deployment), err)
+			err = fmt.Errorf("unable to create/update resource %s: %w", smoothoperatorutils.GetObjectFullName(reconcilerClient, deployment), err)
+			tracing.EndSpan(span, err)
+			return operationResults, err
 		}
+		tracing.EndSpan(span, nil)
 	}
 	// end region Deployment
 
 	// region TraefikMiddleware
 	if obj.Options().IncludeIngress {
+		regionCtx, span := startRegionSpan(ctx, obj, "TraefikMiddleware")
 		middleware := getBareCorsHeadersMiddleware(obj)
-		operationResults[smoothoperatorutils.GetObjectFullName(reconcilerClient, middleware)], err = controllerutil.CreateOrUpdate(ctx, reconcilerClient, middleware, func() error {
+		operationResults[smoothoperatorutils.GetObjectFullName(reconcilerClient, middleware)], err = controllerutil.CreateOrUpdate(regionCtx, reconcilerClient, middleware, func() error {
 			return mutateCorsHeadersMiddleware(r, obj, middleware)
 		})
 		if err != nil {
-			return operationResults, fmt.Errorf("unable to create/update resource %s: %w", smoothoperatorutils.GetObjectFullName(reconcilerClient, middleware), err)
+			err = fmt.Errorf("unable to create/update resource %s: %w", smoothoperatorutils.GetObjectFullName(reconcilerClient, middleware), err)
+			tracing.EndSpan(span, err)
+			return operationResults, err
 		}
+		tracing.EndSpan(span, nil)
 	}
 	// end region TraefikMiddleware
 
 	// region PodDisruptionBudget
 	{
-		err = createOrUpdateOrDeletePodDisruptionBudget(ctx, r, obj, operationResults)
+		regionCtx, span := startRegionSpan(ctx, obj, "PodDisruptionBudget")
+		err = createOrUpdateOrDeletePodDisruptionBudget(regionCtx, r, obj, operationResults)
 		if err != nil {
+			tracing.EndSpan(span, err)
 			return operationResults, err
 		}
+		tracing.EndSpan(span, nil)
 	}
 	// end region PodDisruptionBudget
 
 	// region HorizontalAutoScaler
 	{
+		regionCtx, span := startRegionSpan(ctx, obj, "HorizontalAutoScaler")
 		autoscaler := getBareHorizontalPodAutoScaler(obj)
-		operationResults[smoothoperatorutils.GetObjectFullName(reconcilerClient, autoscaler)], err = controllerutil.CreateOrUpdate(ctx, reconcilerClient, autoscaler, func() error {
+		operationResults[smoothoperatorutils.GetObjectFullName(reconcilerClient, autoscaler)], err = controllerutil.CreateOrUpdate(regionCtx, reconcilerClient, autoscaler, func() error {
 			return mutateHorizontalPodAutoscaler(r, obj, autoscaler)
 		})
 		if err != nil {
-			return operationResults, fmt.Errorf("unable to create/update resource %s: %w", smoothoperatorutils.GetObjectFullName(reconcilerClient, autoscaler), err)
+			err = fmt.Errorf("unable to create/update resource %s: %w", smoothoperatorutils.GetObjectFullName(reconcilerClient, autoscaler), err)
+			tracing.EndSpan(span, err)
+			return operationResults, err
 		}
+		tracing.EndSpan(span, nil)
 	}
 	// end region HorizontalAutoScaler
 
 	// region IngressRoute
 	if obj.Options().IncludeIngress {
+		regionCtx, span := startRegionSpan(ctx, obj, "IngressRoute")
 		ingress := getBareIngressRoute(obj)
-		operationResults[smoothoperatorutils.GetObjectFullName(reconcilerClient, ingress)], err = controllerutil.CreateOrUpdate(ctx, reconcilerClient, ingress, func() error {
+		operationResults[smoothoperatorutils.GetObjectFullName(reconcilerClient, ingress)], err = controllerutil.CreateOrUpdate(regionCtx, reconcilerClient, ingress, func() error {
 			return mutateIngressRoute(r, obj, ingress)
 		})
 		if err != nil {
-			return operationResults, fmt.Errorf("unable to create/update resource %s: %w", smoothoperatorutils.GetObjectFullName(reconcilerClient, ingress), err)
+			err = fmt.Errorf("unable to create/update resource %s: %w", smoothoperatorutils.GetObjectFullName(reconcilerClient, ingress), err)
+			tracing.EndSpan(span, err)
+			return operationResults, err
 		}
+		tracing.EndSpan(span, nil)
 	}
 	// end region IngressRoute
 
 	// region Service
 	{
+		regionCtx, span := startRegionSpan(ctx, obj, "Service")
 		service := getBareService(obj)
-		operationResults[smoothoperatorutils.GetObjectFullName(reconcilerClient, service)], err = controllerutil.CreateOrUpdate(ctx, reconcilerClient, service, func() error {
+		operationResults[smoothoperatorutils.GetObjectFullName(reconcilerClient, service)], err = controllerutil.CreateOrUpdate(regionCtx, reconcilerClient, service, func() error {
 			return mutateService(r, obj, service)
 		})
 		if err != nil {
-			return operationResults, fmt.Errorf("unable to create/update resource %s: %w", smoothoperatorutils.GetObjectFullName(reconcilerClient, service), err)
+			err = fmt.Errorf("unable to create/update resource %s: %w", smoothoperatorutils.GetObjectFullName(reconcilerClient, service), err)
+			tracing.EndSpan(span, err)
+			return operationResults, err
 		}
+		tracing.EndSpan(span, nil)
 	}
 	// end region Service
 
+	// region NetworkPolicy
+	{
+		regionCtx, span := startRegionSpan(ctx, obj, "NetworkPolicy")
+		err = createOrUpdateOrDeleteNetworkPolicy(regionCtx, r, obj, operationResults)
+		if err != nil {
+			tracing.EndSpan(span, err)
+			return operationResults, err
+		}
+		tracing.EndSpan(span, nil)
+	}
+	// end region NetworkPolicy
+
 	return operationResults, nil
 }
 
+// startRegionSpan starts a child span for a single region of createOrUpdateAllForWMSWFS
+func startRegionSpan[O pdoknlv3.WMSWFS](ctx context.Context, obj O, region string) (context.Context, trace.Span) {
+	return tracing.StartSpan(ctx, "createOrUpdate"+region, obj, string(obj.Type()))
+}
+
 func createOrUpdateConfigMaps[R Reconciler, O pdoknlv3.WMSWFS](ctx context.Context, r R, obj O, ownerInfo *smoothoperatorv1.OwnerInfo) (hashedConfigMapNames types.HashedConfigMapNames, operationResults map[string]controllerutil.OperationResult, err error) {
 	operationResults, configMaps := make(map[string]controllerutil.OperationResult), make(map[string]func(R, O, *corev1.ConfigMap) error)
 	configMaps[constants.MapserverName] = mutateConfigMap
@@ -201,9 +293,12 @@ func createOrUpdateConfigMaps[R Reconciler, O pdoknlv3.WMSWFS](ctx context.Conte
 	configMaps[constants.CapabilitiesGeneratorName] = func(r R, o O, cm *corev1.ConfigMap) error {
 		return mutateConfigMapCapabilitiesGenerator(r, o, cm, ownerInfo)
 	}
-	if obj.Options().PrefetchData {
+	if prefetch.MayPrefetch(obj) {
 		configMaps[constants.InitScriptsName] = mutateConfigMapBlobDownload
 	}
+	if datarefresh.UseDataRefresh(obj) {
+		configMaps[constants.DataManifestName] = mutateConfigMapDataManifest
+	}
 	if obj.Type() == pdoknlv3.ServiceTypeWMS {
 		wms, _ := any(obj).(*pdoknlv3.WMS)
 		wmsReconciler := (*WMSReconciler)(r)
@@ -288,6 +383,28 @@ func createOrUpdateOrDeletePodDisruptionBudget[O pdoknlv3.WMSWFS, R Reconciler](
 	return nil
 }
 
+func createOrUpdateOrDeleteNetworkPolicy[O pdoknlv3.WMSWFS, R Reconciler](ctx context.Context, reconciler R, obj O, operationResults map[string]controllerutil.OperationResult) (err error) {
+	reconcilerClient := getReconcilerClient(reconciler)
+	networkPolicy := getBareNetworkPolicy(obj)
+	if !networkPolicyConfig.Enabled {
+		err = reconcilerClient.Delete(ctx, networkPolicy)
+		if err == nil {
+			operationResults[smoothoperatorutils.GetObjectFullName(reconcilerClient, networkPolicy)] = "deleted"
+		}
+		if client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("unable to delete resource %s: %w", smoothoperatorutils.GetObjectFullName(reconcilerClient, networkPolicy), err)
+		}
+	} else {
+		operationResults[smoothoperatorutils.GetObjectFullName(reconcilerClient, networkPolicy)], err = controllerutil.CreateOrUpdate(ctx, reconcilerClient, networkPolicy, func() error {
+			return mutateNetworkPolicy(reconciler, obj, networkPolicy)
+		})
+		if err != nil {
+			return fmt.Errorf("unable to create/update resource %s: %w", smoothoperatorutils.GetObjectFullName(reconcilerClient, networkPolicy), err)
+		}
+	}
+	return nil
+}
+
 func recoveredPanicToError(rec any) (err error) {
 	switch x := rec.(type) {
 	case string:
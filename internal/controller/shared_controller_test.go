@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/pdok/mapserver-operator/internal/controller/constants"
+	"github.com/pdok/mapserver-operator/internal/controller/datarefresh"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/pdok/mapserver-operator/api/v2beta1"
@@ -20,6 +21,7 @@ import (
 	smoothoperatorvalidation "github.com/pdok/smooth-operator/pkg/validation"
 	traefikiov1alpha1 "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
 	v2 "k8s.io/api/autoscaling/v2"
+	networkingv1 "k8s.io/api/networking/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/yaml"
@@ -42,6 +44,7 @@ const (
 	testImageName5 = "test.test/image:test5"
 	testImageName6 = "test.test/image:test6"
 	testImageName7 = "test.test/image:test7"
+	testImageName8 = "test.test/image:test8"
 )
 
 func getHashedConfigMapNameFromClient[O pdoknlv3.WMSWFS](ctx context.Context, obj O, volumeName string) (string, error) {
@@ -67,6 +70,7 @@ func getExpectedObjects[O pdoknlv3.WMSWFS](ctx context.Context, obj O, includeBl
 		getBareIngressRoute(obj),
 		getBareCorsHeadersMiddleware(obj),
 		getBarePodDisruptionBudget(obj),
+		getBareNetworkPolicy(obj),
 	}
 
 	// Add all ConfigMaps with hashed names
@@ -168,7 +172,6 @@ func testMutate[T any](kind string, result *T, expectedFile string, mutate func(
 	}
 }
 
-//nolint:unparam
 func testMutateConfigMap(m *corev1.ConfigMap, expectedFile string, mutate func(*corev1.ConfigMap) error, ignoreValues bool) {
 	clearConfigMapValues := func(cm *corev1.ConfigMap) {
 		newMap := map[string]string{}
@@ -220,6 +223,8 @@ func testMutates[R Reconciler, O pdoknlv3.WMSWFS](reconcilerFn func() R, resourc
 		fileName = "wfs.yaml"
 	case pdoknlv3.ServiceTypeWMS:
 		fileName = "wms.yaml"
+	case pdoknlv3.ServiceTypeWCS:
+		fileName = "wcs.yaml"
 	default:
 		panic("unknown servicetype")
 	}
@@ -249,6 +254,9 @@ func testMutates[R Reconciler, O pdoknlv3.WMSWFS](reconcilerFn func() R, resourc
 		case *pdoknlv3.WFS:
 			wfs := any(resource).(*pdoknlv3.WFS)
 			_, validationError = wfs.ValidateCreate(k8sClient)
+		case *pdoknlv3.WCS:
+			wcs := any(resource).(*pdoknlv3.WCS)
+			_, validationError = wcs.ValidateCreate(k8sClient)
 		}
 		Expect(validationError).NotTo(HaveOccurred())
 		Expect(k8sClient.Delete(ctx, &owner)).To(Succeed())
@@ -274,6 +282,16 @@ func testMutates[R Reconciler, O pdoknlv3.WMSWFS](reconcilerFn func() R, resourc
 		}
 	})
 
+	It("Should generate a correct DataManifest Configmap", func() {
+		if !datarefresh.UseDataRefresh(resource) {
+			Skip("data refresh is not used")
+		}
+		cm := getBareConfigMap(resource, constants.DataManifestName)
+		testMutateConfigMap(cm, outputPath+"configmap-data-manifest.yaml", func(cm *corev1.ConfigMap) error {
+			return mutateConfigMapDataManifest(reconcilerFn(), resource, cm)
+		}, false)
+	})
+
 	It("Should generate a correct MapfileGenerator Configmap", func() {
 		if path, include := shouldIncludeFile("configmap-mapfile-generator.yaml"); include {
 			cm := getBareConfigMap(resource, constants.MapfileGeneratorName)
@@ -349,6 +367,12 @@ func testMutates[R Reconciler, O pdoknlv3.WMSWFS](reconcilerFn func() R, resourc
 		})
 	})
 
+	It("Should generate a correct NetworkPolicy", func() {
+		testMutate("NetworkPolicy", getBareNetworkPolicy(resource), outputPath+"networkpolicy.yaml", func(n *networkingv1.NetworkPolicy) error {
+			return mutateNetworkPolicy(reconcilerFn(), resource, n)
+		})
+	})
+
 	It("Should generate a correct HorizontalPodAutoscaler", func() {
 		testMutate("PodDisruptionBudget", getBareHorizontalPodAutoScaler(resource), outputPath+"horizontalpodautoscaler.yaml", func(h *v2.HorizontalPodAutoscaler) error {
 			return mutateHorizontalPodAutoscaler(reconcilerFn(), resource, h)
@@ -371,7 +395,7 @@ func readTestFile(fileName string) ([]byte, error) {
 	// Apply defaults
 	un := unstructured.Unstructured{}
 	err = yaml.Unmarshal(dat, &un)
-	if slices.Contains([]string{"WMS", "WFS"}, un.GetKind()) {
+	if slices.Contains([]string{"WMS", "WFS", "WCS"}, un.GetKind()) {
 		defaulted, err := smoothoperatorvalidation.ApplySchemaDefaults(un.Object)
 		if err != nil {
 			return []byte{}, err
@@ -447,6 +471,8 @@ func TestGetVolumesForDeployment(t *testing.T) {
 	expected := []corev1.Volume{
 		{Name: constants.BaseVolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
 		{Name: constants.DataVolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		{Name: constants.TmpVolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		{Name: constants.VarTmpVolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
 		{Name: constants.MapserverName, VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "rws-nwbwegen-v1-0-wfs-mapserver-bb59c7f4f4"}, DefaultMode: smoothoperatorutils.Pointer(int32(420))}}},
 		{Name: constants.ConfigMapCapabilitiesGeneratorVolumeName, VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "rws-nwbwegen-v1-0-wfs-capabilities-generator-6m4mfkgb5d"}, DefaultMode: smoothoperatorutils.Pointer(int32(420))}}},
 		{Name: constants.ConfigMapMapfileGeneratorVolumeName, VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "rws-nwbwegen-v1-0-wfs-mapfile-generator-bbbtd999dh"}, DefaultMode: smoothoperatorutils.Pointer(int32(420))}}},
@@ -2,6 +2,7 @@ if lighty.r.req_attr["request.method"] == "GET" then
 
     -- obtain service type from environment
     serviceType = os.getenv('SERVICE_TYPE'):lower()
+    ogcApiFeatures = os.getenv('OGC_API_FEATURES') == 'true'
 
     path = lighty.r.req_attr["uri.path"]
     query = lighty.r.req_attr["uri.query"]
@@ -10,7 +11,21 @@ if lighty.r.req_attr["request.method"] == "GET" then
     if serviceType == "wms" then
         _, _, file = path:find(".*/legend/(.*)")
         if file then
-            if file:find(".*%.png") then
+            local contentType
+            if file:find(".*%.png$") then
+                contentType = "image/png"
+            elseif file:find(".*%.jpg$") then
+                contentType = "image/jpeg"
+            elseif file:find(".*%.gif$") then
+                contentType = "image/gif"
+            elseif file:find(".*%.svg$") then
+                contentType = "image/svg+xml"
+            elseif file:find(".*%.sld$") then
+                -- SLD documents are published next to the legends by the legend-generator
+                contentType = "application/vnd.ogc.sld+xml"
+            end
+
+            if contentType then
                 local legendPath = "/var/www/legend/" .. file
                 local stat = lighty.stat(legendPath)
                 if (not stat or not stat.is_file) then
@@ -18,7 +33,12 @@ if lighty.r.req_attr["request.method"] == "GET" then
                     return 404
                 end
                 lighty.content = { { filename = legendPath } }
-                lighty.header['Content-Type'] = "image/png"
+                lighty.header['Content-Type'] = contentType
+                -- an (SVG) legend is never rendered as a document with scripts
+                lighty.header['Content-Security-Policy'] = "default-src 'none'; style-src 'unsafe-inline'"
+                lighty.header['X-Content-Type-Options'] = 'nosniff'
+                local fileName = file:match("([^/]+)$"):gsub('[\r\n"\\]', '')
+                lighty.header['Content-Disposition'] = 'inline; filename="' .. fileName .. '"'
                 return 200
             end
 
@@ -26,6 +46,11 @@ if lighty.r.req_attr["request.method"] == "GET" then
         end
     end
 
+    -- OGC API Features requests (/ogc/collections...) are handled by mapserver itself
+    if serviceType == "wfs" and ogcApiFeatures and (path:find("/ogc$") or path:find("/ogc/") or path:find("/ogcapi")) then
+        return
+    end
+
     params = {}
     if query then
         for k, v in query:gmatch("([^?&=]+)=([^&]+)") do
@@ -53,6 +78,10 @@ if lighty.r.req_attr["request.method"] == "GET" then
         version = '2.0.0'
     end
 
+    if (service == 'wcs' and (not version or (version ~= '1.0.0' and version ~= '1.1.0'))) then
+        version = '2.0.1'
+    end
+
     -- serve static content
     request = params['request']
     if request then
@@ -65,6 +94,8 @@ if lighty.r.req_attr["request.method"] == "GET" then
                 staticFile = '/var/www/config/capabilities_wms_130.xml'
             elseif (service == 'wfs' and version == '2.0.0') then
                 staticFile = '/var/www/config/capabilities_wfs_200.xml'
+            elseif (service == 'wcs' and version == '2.0.1') then
+                staticFile = '/var/www/config/capabilities_wcs_201.xml'
             end
         elseif service == 'wfs' and request == 'getfeature' then
             startindex = params['startindex']
@@ -35,6 +35,7 @@ import (
 	"testing"
 
 	pdoknlv2beta1 "github.com/pdok/mapserver-operator/api/v2beta1"
+	"github.com/pdok/mapserver-operator/internal/controller/types"
 	smoothoperatorv1 "github.com/pdok/smooth-operator/api/v1"
 	smoothoperatorvalidation "github.com/pdok/smooth-operator/pkg/validation"
 	traefikiov1alpha1 "github.com/traefik/traefik/v3/pkg/provider/kubernetes/crd/traefikio/v1alpha1"
@@ -60,6 +61,16 @@ import (
 // These tests use Ginkgo (BDD-style Go testing framework). Refer to
 // http://onsi.github.io/ginkgo/ to learn more about Ginkgo.
 
+var testNetworkPolicyConfig = types.NetworkPolicyConfig{
+	Enabled:             true,
+	IngressNamespace:    "traefik",
+	MonitoringNamespace: "monitoring",
+	BlobStorageCIDRs:    []string{"10.0.0.0/24"},
+	BlobStoragePort:     443,
+	PostgisCIDRs:        []string{"10.0.1.0/24"},
+	PostgisPort:         5432,
+}
+
 var (
 	ctx       context.Context
 	cancel    context.CancelFunc
@@ -109,6 +120,7 @@ var _ = BeforeSuite(func() {
 			Paths: []string{
 				filepath.Join("..", "..", "config", "crd", "bases", "pdok.nl_wfs.yaml"),
 				filepath.Join("..", "..", "config", "crd", "bases", "pdok.nl_wms.yaml"),
+				filepath.Join("..", "..", "config", "crd", "bases", "pdok.nl_wcs.yaml"),
 				traefikCRDPath,
 				ownerInfoCRDPath,
 			},
@@ -173,9 +185,12 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 	err = smoothoperatorvalidation.LoadSchemasForCRD(cfg, "default", "wms.pdok.nl")
 	Expect(err).NotTo(HaveOccurred())
+	err = smoothoperatorvalidation.LoadSchemasForCRD(cfg, "default", "wcs.pdok.nl")
+	Expect(err).NotTo(HaveOccurred())
 
 	pdoknlv3.SetHost("http://localhost:32788")
 	SetStorageClassName("test-storage")
+	SetNetworkPolicyConfig(testNetworkPolicyConfig)
 })
 
 var _ = AfterSuite(func() {
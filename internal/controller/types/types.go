@@ -18,4 +18,24 @@ type Images struct {
 	FeatureinfoGeneratorImage  string
 	OgcWebserviceProxyImage    string
 	ApacheExporterImage        string
+	LegendGeneratorImage       string
+}
+
+type NetworkPolicyConfig struct {
+	Enabled             bool
+	IngressNamespace    string
+	MonitoringNamespace string
+	BlobStorageCIDRs    []string
+	BlobStoragePort     int32
+	PostgisCIDRs        []string
+	PostgisPort         int32
+}
+
+type DataCacheConfig struct {
+	Enabled             bool
+	Namespace           string
+	HostPath            string
+	BlobsConfigMapName  string
+	BlobsSecretName     string
+	RefreshIntervalSecs int32
 }
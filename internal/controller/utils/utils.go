@@ -1,11 +1,16 @@
 package utils //nolint:revive
 
 import (
+	"regexp"
+
 	"github.com/pdok/mapserver-operator/internal/controller/constants"
 
 	corev1 "k8s.io/api/core/v1"
 )
 
+// bucketVariableRegex matches the bucket variable at the start of a blobKey, e.g. ${BLOBS_GEOPACKAGES_BUCKET}/
+var bucketVariableRegex = regexp.MustCompile(`^\$\{[a-zA-Z0-9_]+}/`)
+
 type EnvFromSourceType string
 
 const (
@@ -44,6 +49,11 @@ func GetDataVolumeMount() corev1.VolumeMount {
 	return corev1.VolumeMount{Name: constants.DataVolumeName, MountPath: "/var/www", ReadOnly: false}
 }
 
+// GetTmpVolumeMount returns the writable /tmp, the root filesystem of every container is read-only
+func GetTmpVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{Name: constants.TmpVolumeName, MountPath: "/tmp"}
+}
+
 func GetConfigVolumeMount(volumeName string) corev1.VolumeMount {
 	return corev1.VolumeMount{Name: volumeName, MountPath: "/input", ReadOnly: true}
 }
@@ -51,3 +61,9 @@ func GetConfigVolumeMount(volumeName string) corev1.VolumeMount {
 func GetMapfileVolumeMount() corev1.VolumeMount {
 	return corev1.VolumeMount{Name: constants.ConfigMapCustomMapfileVolumeName, MountPath: "/srv/data/config/mapfile"}
 }
+
+// ReplaceBucketVariable replaces the bucket variable at the start of a blobKey by the container, GDAL doesn't expand it
+// when the blob is streamed through /vsiaz
+func ReplaceBucketVariable(blobKey string, container string) string {
+	return bucketVariableRegex.ReplaceAllString(blobKey, container+"/")
+}
@@ -0,0 +1,144 @@
+/*
+MIT License
+
+Copyright (c) 2024 Publieke Dienstverlening op de Kaart
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/pdok/mapserver-operator/internal/controller/types"
+	"github.com/pdok/mapserver-operator/internal/tracing"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	smoothoperatorv1 "github.com/pdok/smooth-operator/api/v1"
+	smoothoperatorstatus "github.com/pdok/smooth-operator/pkg/status"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// WCSReconciler reconciles a WCS object
+type WCSReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Images types.Images
+}
+
+// +kubebuilder:rbac:groups=pdok.nl,resources=wcs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=pdok.nl,resources=wcs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=pdok.nl,resources=wcs/finalizers,verbs=update
+// +kubebuilder:rbac:groups=pdok.nl,resources=ownerinfo,verbs=get;list;watch
+// +kubebuilder:rbac:groups=pdok.nl,resources=ownerinfo/status,verbs=get
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=apps,resources=replicasets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=configmaps;services,verbs=watch;create;get;update;list;delete
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=watch;list;get
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=watch;create;get;update;list;delete
+// +kubebuilder:rbac:groups=traefik.io,resources=ingressroutes;middlewares,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=create;update;delete;list;watch
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets/status,verbs=get;update
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets/finalizers,verbs=update
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=core,resources=persistentvolumes;persistentvolumeclaims,verbs=get;list;watch;create;update;delete
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+// The Reconcile function compares the state specified by
+// the WCS object against the actual cluster state, and then
+// perform operations to make the cluster state reflect the state specified by
+// the user.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.20.0/pkg/reconcile
+func (r *WCSReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	lgr := log.FromContext(ctx)
+	lgr.Info("Starting reconcile for WCS resource", "name", req.NamespacedName)
+
+	ctx, span := tracing.StartSpanForRequest(ctx, "WCSReconciler.Reconcile", req.NamespacedName, "WCS")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	// Fetch the WCS instance
+	wcs := &pdoknlv3.WCS{}
+	if err = r.Get(ctx, req.NamespacedName, wcs); err != nil {
+		if apierrors.IsNotFound(err) {
+			lgr.Info("WCS resource not found", "name", req.NamespacedName)
+		} else {
+			lgr.Error(err, "unable to fetch WCS resource", "error", err)
+		}
+		return result, client.IgnoreNotFound(err)
+	}
+
+	lgr.Info("Fetching OwnerInfo", "name", req.NamespacedName)
+	// Fetch the OwnerInfo instance
+	ownerInfo := &smoothoperatorv1.OwnerInfo{}
+	objectKey := client.ObjectKey{
+		Namespace: wcs.Namespace,
+		Name:      wcs.Spec.Service.OwnerInfoRef,
+	}
+	if err := r.Get(ctx, objectKey, ownerInfo); err != nil {
+		if apierrors.IsNotFound(err) {
+			lgr.Info("OwnerInfo resource not found", "name", req.NamespacedName)
+		} else {
+			lgr.Error(err, "unable to fetch OwnerInfo resource", "error", err)
+		}
+		return result, err
+	}
+
+	// Recover from a panic so we can add the error to the status of the Atom
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = recoveredPanicToError(rec)
+			smoothoperatorstatus.LogAndUpdateStatusError(ctx, r.Client, wcs, err)
+		}
+	}()
+
+	// Check TTL, delete if expired
+	if ttlExpired(wcs) {
+		err = r.Delete(ctx, wcs)
+
+		return result, err
+	}
+
+	ensureLabel(wcs, "pdok.nl/service-type", "wcs")
+
+	lgr.Info("creating resources for wcs", "wcs", wcs.Name)
+	operationResults, err := createOrUpdateAllForWMSWFS(ctx, r, wcs, ownerInfo)
+	if err != nil {
+		lgr.Info("failed creating resources for wcs", "wcs", wcs.Name)
+		smoothoperatorstatus.LogAndUpdateStatusError(ctx, r.Client, wcs, err)
+		return result, err
+	}
+	lgr.Info("finished creating resources for wcs", "wcs", wcs.Name)
+	smoothoperatorstatus.LogAndUpdateStatusFinished(ctx, r.Client, wcs, operationResults)
+
+	return result, err
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *WCSReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return createControllerManager(mgr, &pdoknlv3.WCS{}).Complete(r)
+}
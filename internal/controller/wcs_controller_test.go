@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"github.com/pdok/mapserver-operator/internal/controller/types"
+
+	. "github.com/onsi/ginkgo/v2" //nolint:revive // ginkgo bdd
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+)
+
+var _ = Describe("Testing WCS Controller", func() {
+
+	Context("Testing Mutate functions for Minimal WCS", func() {
+		testMutates(getWCSReconciler, &pdoknlv3.WCS{}, "minimal")
+	})
+})
+
+func getWCSReconciler() *WCSReconciler {
+	return &WCSReconciler{
+		Client: k8sClient,
+		Scheme: k8sClient.Scheme(),
+		Images: types.Images{
+			MultitoolImage:             testImageName1,
+			MapfileGeneratorImage:      testImageName2,
+			MapserverImage:             testImageName3,
+			CapabilitiesGeneratorImage: testImageName4,
+			ApacheExporterImage:        testImageName5,
+		},
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"context"
 
 	"github.com/pdok/mapserver-operator/internal/controller/types"
+	"github.com/pdok/mapserver-operator/internal/tracing"
 
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
 	smoothoperatorv1 "github.com/pdok/smooth-operator/api/v1"
@@ -53,6 +54,7 @@ type WFSReconciler struct {
 // +kubebuilder:rbac:groups=pdok.nl,resources=ownerinfo/status,verbs=get
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups=apps,resources=replicasets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=configmaps;services,verbs=watch;create;get;update;list;delete
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=watch;list;get
 // +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=watch;create;get;update;list;delete
@@ -60,6 +62,8 @@ type WFSReconciler struct {
 // +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=create;update;delete;list;watch
 // +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets/status,verbs=get;update
 // +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets/finalizers,verbs=update
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=core,resources=persistentvolumes;persistentvolumeclaims,verbs=get;list;watch;create;update;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -74,6 +78,9 @@ func (r *WFSReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result
 	lgr := log.FromContext(ctx)
 	lgr.Info("Starting reconcile for WFS resource", "name", req.NamespacedName)
 
+	ctx, span := tracing.StartSpanForRequest(ctx, "WFSReconciler.Reconcile", req.NamespacedName, "WFS")
+	defer func() { tracing.EndSpan(span, err) }()
+
 	// Fetch the WFS instance
 	wfs := &pdoknlv3.WFS{}
 	if err = r.Get(ctx, req.NamespacedName, wfs); err != nil {
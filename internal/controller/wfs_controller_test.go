@@ -37,6 +37,10 @@ var _ = Describe("Testing WFS Controller", func() {
 		testMutates(getWFSReconciler, &pdoknlv3.WFS{}, "noprefetch", "configmap-init-scripts.yaml")
 	})
 
+	Context("Testing Mutate functions for WFS with dataRefresh", func() {
+		testMutates(getWFSReconciler, &pdoknlv3.WFS{}, "datarefresh")
+	})
+
 	Context("When reconciling a resource", func() {
 
 		ctx := context.Background()
@@ -28,6 +28,7 @@ import (
 	"context"
 
 	"github.com/pdok/mapserver-operator/internal/controller/types"
+	"github.com/pdok/mapserver-operator/internal/tracing"
 
 	"github.com/pdok/mapserver-operator/internal/controller/featureinfogenerator"
 	"github.com/pdok/mapserver-operator/internal/controller/legendgenerator"
@@ -64,6 +65,7 @@ type WMSReconciler struct {
 // +kubebuilder:rbac:groups=pdok.nl,resources=ownerinfo/status,verbs=
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups=apps,resources=replicasets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=configmaps;services,verbs=watch;create;get;update;list;delete
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=watch;list;get
 // +kubebuilder:rbac:groups=traefik.io,resources=ingressroutes;middlewares,verbs=get;list;watch;create;update;delete
@@ -71,6 +73,8 @@ type WMSReconciler struct {
 // +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=create;update;delete;list;watch
 // +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets/status,verbs=get;update
 // +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets/finalizers,verbs=update
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=core,resources=persistentvolumes;persistentvolumeclaims,verbs=get;list;watch;create;update;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -85,6 +89,9 @@ func (r *WMSReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result
 	lgr := log.FromContext(ctx)
 	lgr.Info("Starting reconcile for WMS resource", "name", req.NamespacedName)
 
+	ctx, span := tracing.StartSpanForRequest(ctx, "WMSReconciler.Reconcile", req.NamespacedName, "WMS")
+	defer func() { tracing.EndSpan(span, err) }()
+
 	// Fetch the WMS instance
 	wms := &pdoknlv3.WMS{}
 	if err = r.Get(ctx, req.NamespacedName, wms); err != nil {
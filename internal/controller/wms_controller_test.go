@@ -275,6 +275,7 @@ func getWMSReconciler() *WMSReconciler {
 			FeatureinfoGeneratorImage:  testImageName5,
 			OgcWebserviceProxyImage:    testImageName6,
 			ApacheExporterImage:        testImageName7,
+			LegendGeneratorImage:       testImageName8,
 		},
 	}
 }
@@ -0,0 +1,426 @@
+package crs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	degrees = math.Pi / 180
+
+	// maxIterations of the iterative latitude calculations
+	maxIterations = 15
+	tolerance     = 1e-12
+)
+
+// ellipsoids by their proj4 +ellps name, semi-major axis and inverse flattening
+var ellipsoids = map[string][2]float64{
+	"GRS80":  {6378137, 298.257222101},
+	"WGS84":  {6378137, 298.257223563},
+	"bessel": {6377397.155, 299.1528128},
+	"intl":   {6378388, 297},
+}
+
+// projection converts between geographic coordinates (lon/lat in degrees) and the coordinates of a CRS.
+// Datum shifts are ignored, accurate to about a hundred meters, enough for bounding boxes.
+type projection interface {
+	forward(lon, lat float64) (x, y float64)
+	inverse(x, y float64) (lon, lat float64)
+}
+
+// parameters of a proj4 definition, e.g. "+proj=utm +zone=31 +ellps=GRS80"
+type parameters map[string]string
+
+func parseProj4(definition string) (parameters, error) {
+	params := parameters{}
+	for _, field := range strings.Fields(definition) {
+		if !strings.HasPrefix(field, "+") {
+			return nil, fmt.Errorf("invalid proj4 parameter %s", field)
+		}
+		key, value, _ := strings.Cut(field[1:], "=")
+		params[key] = value
+	}
+	if params["proj"] == "" {
+		return nil, errors.New("missing +proj in proj4 definition")
+	}
+	return params, nil
+}
+
+func (p parameters) float(key string, defaultValue float64) (float64, error) {
+	value, ok := p[key]
+	if !ok {
+		return defaultValue, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid proj4 parameter +%s=%s", key, value)
+	}
+	return f, nil
+}
+
+func (p parameters) floats(keys ...string) ([]float64, error) {
+	defaults := map[string]float64{"k": 1, "k_0": 1}
+	result := make([]float64, 0, len(keys))
+	for _, key := range keys {
+		f, err := p.float(key, defaults[key])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, f)
+	}
+	return result, nil
+}
+
+// ellipsoid returns the semi-major axis and the eccentricity
+func (p parameters) ellipsoid() (float64, float64, error) {
+	a, rf := ellipsoids["WGS84"][0], ellipsoids["WGS84"][1]
+	if name, ok := p["ellps"]; ok {
+		ellps, ok := ellipsoids[name]
+		if !ok {
+			return 0, 0, fmt.Errorf("unknown ellipsoid %s", name)
+		}
+		a, rf = ellps[0], ellps[1]
+	}
+	if _, ok := p["a"]; ok {
+		values, err := p.floats("a", "b")
+		if err != nil {
+			return 0, 0, err
+		}
+		if values[1] == 0 || values[1] == values[0] {
+			return values[0], 0, nil
+		}
+		f := (values[0] - values[1]) / values[0]
+		return values[0], math.Sqrt(f * (2 - f)), nil
+	}
+	f := 1 / rf
+	return a, math.Sqrt(f * (2 - f)), nil
+}
+
+func newProjection(definition string) (projection, error) {
+	params, err := parseProj4(definition)
+	if err != nil {
+		return nil, err
+	}
+	a, e, err := params.ellipsoid()
+	if err != nil {
+		return nil, err
+	}
+
+	// UTM is a transverse mercator with fixed parameters
+	if params["proj"] == "utm" {
+		zone, err := strconv.Atoi(params["zone"])
+		if err != nil || zone < 1 || zone > 60 {
+			return nil, fmt.Errorf("invalid utm zone %s", params["zone"])
+		}
+		params["lon_0"] = strconv.Itoa(zone*6 - 183)
+		params["k_0"] = "0.9996"
+		params["x_0"] = "500000"
+		if _, south := params["south"]; south {
+			params["y_0"] = "10000000"
+		}
+		params["proj"] = "tmerc"
+	}
+
+	values, err := params.floats("lat_0", "lon_0", "x_0", "y_0", "lat_1", "lat_2", "lat_ts")
+	if err != nil {
+		return nil, err
+	}
+	lat0, lon0, x0, y0, lat1, lat2, latTS := values[0]*degrees, values[1]*degrees, values[2], values[3], values[4]*degrees, values[5]*degrees, values[6]*degrees
+	k0 := 1.0
+	for _, key := range []string{"k", "k_0"} {
+		if _, ok := params[key]; ok {
+			if k0, err = params.float(key, 1); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch params["proj"] {
+	case "longlat", "latlong":
+		return longLat{}, nil
+	case "merc":
+		if latTS != 0 {
+			k0 = math.Cos(latTS) / math.Sqrt(1-e*e*math.Sin(latTS)*math.Sin(latTS))
+		}
+		return mercator{a: a, e: e, k0: k0, lon0: lon0, x0: x0, y0: y0}, nil
+	case "tmerc":
+		return newTransverseMercator(a, e, k0, lat0, lon0, x0, y0), nil
+	case "laea":
+		return newLambertAzimuthalEqualArea(a, e, lat0, lon0, x0, y0), nil
+	case "lcc":
+		if _, ok := params["lat_2"]; !ok {
+			lat2 = lat1
+		}
+		return newLambertConformalConic(a, e, k0, lat0, lon0, lat1, lat2, x0, y0)
+	case "sterea":
+		return newObliqueStereographic(a, e, k0, lat0, lon0, x0, y0), nil
+	default:
+		return nil, fmt.Errorf("unsupported projection %s", params["proj"])
+	}
+}
+
+type longLat struct{}
+
+func (longLat) forward(lon, lat float64) (float64, float64) {
+	return lon, lat
+}
+
+func (longLat) inverse(x, y float64) (float64, float64) {
+	return x, y
+}
+
+// isometricLatitude returns ln(tan(π/4 + φ/2) * ((1 - e sinφ) / (1 + e sinφ))^(e/2))
+func isometricLatitude(phi, e float64) float64 {
+	sinPhi := e * math.Sin(phi)
+	return math.Log(math.Tan(math.Pi/4+phi/2) * math.Pow((1-sinPhi)/(1+sinPhi), e/2))
+}
+
+// latitudeFromIsometric inverts isometricLatitude
+func latitudeFromIsometric(psi, e float64) float64 {
+	t := math.Exp(-psi)
+	phi := math.Pi/2 - 2*math.Atan(t)
+	for range maxIterations {
+		sinPhi := e * math.Sin(phi)
+		next := math.Pi/2 - 2*math.Atan(t*math.Pow((1-sinPhi)/(1+sinPhi), e/2))
+		if math.Abs(next-phi) < tolerance {
+			return next
+		}
+		phi = next
+	}
+	return phi
+}
+
+type mercator struct {
+	a, e, k0, lon0, x0, y0 float64
+}
+
+func (p mercator) forward(lon, lat float64) (float64, float64) {
+	return p.x0 + p.a*p.k0*(lon*degrees-p.lon0), p.y0 + p.a*p.k0*isometricLatitude(lat*degrees, p.e)
+}
+
+func (p mercator) inverse(x, y float64) (float64, float64) {
+	lon := (x-p.x0)/(p.a*p.k0) + p.lon0
+	return lon / degrees, latitudeFromIsometric((y-p.y0)/(p.a*p.k0), p.e) / degrees
+}
+
+// transverseMercator uses the Krüger series, see https://en.wikipedia.org/wiki/Transverse_Mercator:_Redfearn_series
+type transverseMercator struct {
+	e, k0, lon0, x0, y0, a1 float64
+	alpha, beta, delta      [3]float64
+	northingLat0            float64
+}
+
+func newTransverseMercator(a, e, k0, lat0, lon0, x0, y0 float64) transverseMercator {
+	f := 1 - math.Sqrt(1-e*e)
+	n := f / (2 - f)
+	n2, n3 := n*n, n*n*n
+	p := transverseMercator{
+		e: e, k0: k0, lon0: lon0, x0: x0, y0: y0,
+		a1:    a / (1 + n) * (1 + n2/4 + n2*n2/64),
+		alpha: [3]float64{n/2 - 2*n2/3 + 5*n3/16, 13*n2/48 - 3*n3/5, 61 * n3 / 240},
+		beta:  [3]float64{n/2 - 2*n2/3 + 37*n3/96, n2/48 + n3/15, 17 * n3 / 480},
+		delta: [3]float64{2*n - 2*n2/3 - 2*n3, 7*n2/3 - 8*n3/5, 56 * n3 / 15},
+	}
+	_, p.northingLat0 = p.project(0, lat0)
+	return p
+}
+
+// project returns the unscaled easting and northing relative to the central meridian and the equator
+func (p transverseMercator) project(dLon, phi float64) (float64, float64) {
+	t := math.Sinh(math.Atanh(math.Sin(phi)) - p.e*math.Atanh(p.e*math.Sin(phi)))
+	xi := math.Atan2(t, math.Cos(dLon))
+	eta := math.Atanh(math.Sin(dLon) / math.Sqrt(1+t*t))
+	easting, northing := eta, xi
+	for j := range 3 {
+		k := 2 * float64(j+1)
+		easting += p.alpha[j] * math.Cos(k*xi) * math.Sinh(k*eta)
+		northing += p.alpha[j] * math.Sin(k*xi) * math.Cosh(k*eta)
+	}
+	return p.k0 * p.a1 * easting, p.k0 * p.a1 * northing
+}
+
+func (p transverseMercator) forward(lon, lat float64) (float64, float64) {
+	easting, northing := p.project(lon*degrees-p.lon0, lat*degrees)
+	return p.x0 + easting, p.y0 + northing - p.northingLat0
+}
+
+func (p transverseMercator) inverse(x, y float64) (float64, float64) {
+	xi := (y - p.y0 + p.northingLat0) / (p.k0 * p.a1)
+	eta := (x - p.x0) / (p.k0 * p.a1)
+	xiPrime, etaPrime := xi, eta
+	for j := range 3 {
+		k := 2 * float64(j+1)
+		xiPrime -= p.beta[j] * math.Sin(k*xi) * math.Cosh(k*eta)
+		etaPrime -= p.beta[j] * math.Cos(k*xi) * math.Sinh(k*eta)
+	}
+	chi := math.Asin(math.Sin(xiPrime) / math.Cosh(etaPrime))
+	phi := chi
+	for j := range 3 {
+		phi += p.delta[j] * math.Sin(2*float64(j+1)*chi)
+	}
+	lon := p.lon0 + math.Atan2(math.Sinh(etaPrime), math.Cos(xiPrime))
+	return lon / degrees, phi / degrees
+}
+
+// lambertAzimuthalEqualArea implements EPSG method 9820
+type lambertAzimuthalEqualArea struct {
+	e, lon0, x0, y0     float64
+	qp, rq, d, beta0    float64
+	sinBeta0, cosBeta0  float64
+	latitudeCoefficient [3]float64
+}
+
+func newLambertAzimuthalEqualArea(a, e, lat0, lon0, x0, y0 float64) lambertAzimuthalEqualArea {
+	e2 := e * e
+	p := lambertAzimuthalEqualArea{e: e, lon0: lon0, x0: x0, y0: y0}
+	p.qp = p.q(math.Pi / 2)
+	p.rq = a * math.Sqrt(p.qp/2)
+	p.beta0 = math.Asin(p.q(lat0) / p.qp)
+	p.sinBeta0, p.cosBeta0 = math.Sin(p.beta0), math.Cos(p.beta0)
+	p.d = a * (math.Cos(lat0) / math.Sqrt(1-e2*math.Sin(lat0)*math.Sin(lat0))) / (p.rq * p.cosBeta0)
+	p.latitudeCoefficient = [3]float64{
+		e2/3 + 31*e2*e2/180 + 517*e2*e2*e2/5040,
+		23*e2*e2/360 + 251*e2*e2*e2/3780,
+		761 * e2 * e2 * e2 / 45360,
+	}
+	return p
+}
+
+func (p lambertAzimuthalEqualArea) q(phi float64) float64 {
+	sinPhi := math.Sin(phi)
+	e2 := p.e * p.e
+	if p.e == 0 {
+		return 2 * sinPhi
+	}
+	return (1 - e2) * (sinPhi/(1-e2*sinPhi*sinPhi) - 1/(2*p.e)*math.Log((1-p.e*sinPhi)/(1+p.e*sinPhi)))
+}
+
+func (p lambertAzimuthalEqualArea) forward(lon, lat float64) (float64, float64) {
+	beta := math.Asin(p.q(lat*degrees) / p.qp)
+	dLon := lon*degrees - p.lon0
+	b := p.rq * math.Sqrt(2/(1+p.sinBeta0*math.Sin(beta)+p.cosBeta0*math.Cos(beta)*math.Cos(dLon)))
+	x := p.x0 + b*p.d*math.Cos(beta)*math.Sin(dLon)
+	y := p.y0 + b/p.d*(p.cosBeta0*math.Sin(beta)-p.sinBeta0*math.Cos(beta)*math.Cos(dLon))
+	return x, y
+}
+
+func (p lambertAzimuthalEqualArea) inverse(x, y float64) (float64, float64) {
+	dx, dy := x-p.x0, y-p.y0
+	rho := math.Sqrt(math.Pow(dx/p.d, 2) + math.Pow(p.d*dy, 2))
+	if rho == 0 {
+		return p.lon0 / degrees, math.Asin(p.sinBeta0) / degrees
+	}
+	c := 2 * math.Asin(rho/(2*p.rq))
+	betaPrime := math.Asin(math.Cos(c)*p.sinBeta0 + p.d*dy*math.Sin(c)*p.cosBeta0/rho)
+	lon := p.lon0 + math.Atan2(dx*math.Sin(c), p.d*rho*p.cosBeta0*math.Cos(c)-p.d*p.d*dy*p.sinBeta0*math.Sin(c))
+	lat := betaPrime
+	for j := range 3 {
+		lat += p.latitudeCoefficient[j] * math.Sin(2*float64(j+1)*betaPrime)
+	}
+	return lon / degrees, lat / degrees
+}
+
+// lambertConformalConic implements EPSG methods 9801 (1SP) and 9802 (2SP)
+type lambertConformalConic struct {
+	a, e, lon0, x0, y0, n, f, rho0 float64
+}
+
+func newLambertConformalConic(a, e, k0, lat0, lon0, lat1, lat2, x0, y0 float64) (lambertConformalConic, error) {
+	m := func(phi float64) float64 {
+		return math.Cos(phi) / math.Sqrt(1-e*e*math.Sin(phi)*math.Sin(phi))
+	}
+	t := func(phi float64) float64 {
+		return math.Exp(-isometricLatitude(phi, e))
+	}
+
+	p := lambertConformalConic{a: a, e: e, lon0: lon0, x0: x0, y0: y0}
+	if lat1 == lat2 {
+		p.n = math.Sin(lat1)
+	} else {
+		p.n = (math.Log(m(lat1)) - math.Log(m(lat2))) / (math.Log(t(lat1)) - math.Log(t(lat2)))
+	}
+	if p.n == 0 {
+		return p, errors.New("lcc requires standard parallels away from the equator")
+	}
+	p.f = k0 * m(lat1) / (p.n * math.Pow(t(lat1), p.n))
+	p.rho0 = a * p.f * math.Pow(t(lat0), p.n)
+	return p, nil
+}
+
+func (p lambertConformalConic) forward(lon, lat float64) (float64, float64) {
+	rho := p.a * p.f * math.Pow(math.Exp(-isometricLatitude(lat*degrees, p.e)), p.n)
+	theta := p.n * (lon*degrees - p.lon0)
+	return p.x0 + rho*math.Sin(theta), p.y0 + p.rho0 - rho*math.Cos(theta)
+}
+
+func (p lambertConformalConic) inverse(x, y float64) (float64, float64) {
+	dx, dy := x-p.x0, p.rho0-(y-p.y0)
+	rho := math.Copysign(math.Sqrt(dx*dx+dy*dy), p.n)
+	theta := math.Atan2(math.Copysign(1, p.n)*dx, math.Copysign(1, p.n)*dy)
+	t := math.Pow(rho/(p.a*p.f), 1/p.n)
+	return (theta/p.n + p.lon0) / degrees, latitudeFromIsometric(-math.Log(t), p.e) / degrees
+}
+
+// obliqueStereographic implements EPSG method 9809, used by the Dutch RD New
+type obliqueStereographic struct {
+	e, lon0, x0, y0, n, c, chi0, r2k0 float64
+}
+
+func newObliqueStereographic(a, e, k0, lat0, lon0, x0, y0 float64) obliqueStereographic {
+	e2 := e * e
+	sinLat0 := math.Sin(lat0)
+	rho0 := a * (1 - e2) / math.Pow(1-e2*sinLat0*sinLat0, 1.5)
+	nu0 := a / math.Sqrt(1-e2*sinLat0*sinLat0)
+	n := math.Sqrt(1 + e2*math.Pow(math.Cos(lat0), 4)/(1-e2))
+	s1 := (1 + sinLat0) / (1 - sinLat0)
+	s2 := (1 - e*sinLat0) / (1 + e*sinLat0)
+	w1 := math.Pow(s1*math.Pow(s2, e), n)
+	sinChi0 := (w1 - 1) / (w1 + 1)
+	c := (n + sinLat0) * (1 - sinChi0) / ((n - sinLat0) * (1 + sinChi0))
+	w2 := c * w1
+	return obliqueStereographic{
+		e: e, lon0: lon0, x0: x0, y0: y0, n: n, c: c,
+		chi0: math.Asin((w2 - 1) / (w2 + 1)),
+		r2k0: 2 * math.Sqrt(rho0*nu0) * k0,
+	}
+}
+
+func (p obliqueStereographic) forward(lon, lat float64) (float64, float64) {
+	sinLat := math.Sin(lat * degrees)
+	sa := (1 + sinLat) / (1 - sinLat)
+	sb := (1 - p.e*sinLat) / (1 + p.e*sinLat)
+	w := p.c * math.Pow(sa*math.Pow(sb, p.e), p.n)
+	chi := math.Asin((w - 1) / (w + 1))
+	dLambda := p.n * (lon*degrees - p.lon0)
+	b := 1 + math.Sin(chi)*math.Sin(p.chi0) + math.Cos(chi)*math.Cos(p.chi0)*math.Cos(dLambda)
+	x := p.x0 + p.r2k0*math.Cos(chi)*math.Sin(dLambda)/b
+	y := p.y0 + p.r2k0*(math.Sin(chi)*math.Cos(p.chi0)-math.Cos(chi)*math.Sin(p.chi0)*math.Cos(dLambda))/b
+	return x, y
+}
+
+func (p obliqueStereographic) inverse(x, y float64) (float64, float64) {
+	dx, dy := x-p.x0, y-p.y0
+	g := p.r2k0 * math.Tan(math.Pi/4-p.chi0/2)
+	h := 2*p.r2k0*math.Tan(p.chi0) + g
+	i := math.Atan(dx / (h + dy))
+	j := math.Atan(dx/(g-dy)) - i
+	chi := p.chi0 + 2*math.Atan((dy-dx*math.Tan(j/2))/p.r2k0)
+	lon := p.lon0 + (j+2*i)/p.n
+
+	psi := 0.5 * math.Log((1+math.Sin(chi))/(p.c*(1-math.Sin(chi)))) / p.n
+	lat := 2*math.Atan(math.Exp(psi)) - math.Pi/2
+	for range maxIterations {
+		sinLat := math.Sin(lat)
+		psiI := isometricLatitude(lat, p.e)
+		next := lat - (psiI-psi)*math.Cos(lat)*(1-p.e*p.e*sinLat*sinLat)/(1-p.e*p.e)
+		if math.Abs(next-lat) < tolerance {
+			lat = next
+			break
+		}
+		lat = next
+	}
+	return lon / degrees, lat / degrees
+}
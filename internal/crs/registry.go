@@ -0,0 +1,237 @@
+package crs
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+
+	smoothoperatormodel "github.com/pdok/smooth-operator/model"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// WGS84 is the CRS of the WGS84 bounding boxes in the capabilities, in lon/lat order
+	WGS84 = "CRS:84"
+
+	// samples along each side of a bounding box when reprojecting it
+	samples = 10
+)
+
+// AxisOrder of a CRS in WMS 1.3.0 requests and capabilities
+type AxisOrder string
+
+const (
+	EastNorth AxisOrder = "xy"
+	NorthEast AxisOrder = "yx"
+)
+
+//go:embed registry.yaml
+var defaultRegistry []byte
+
+var registry *Registry
+
+func init() {
+	var err error
+	if registry, err = parseRegistry(defaultRegistry); err != nil {
+		panic(err)
+	}
+}
+
+// Registry holds the CRSs supported by the operator
+type Registry struct {
+	// WGS84BBox is the area of the default bounding boxes, used for the CRSs without a bbox
+	//nolint:tagliatelle
+	WGS84BBox smoothoperatormodel.BBox `json:"wgs84BBox"`
+
+	// CRSs in the registry
+	CRSs []CRS `json:"crs"`
+}
+
+// CRS is a coordinate reference system in the registry
+type CRS struct {
+	// Code of the CRS, e.g. EPSG:28992
+	Code string `json:"code"`
+
+	// Proj4 definition of the CRS, used to reproject bounding boxes
+	Proj4 string `json:"proj4"`
+
+	// AxisOrder of the CRS in WMS 1.3.0, defaults to xy
+	AxisOrder AxisOrder `json:"axisOrder,omitempty"`
+
+	// BBox is the default bounding box in easting/northing order, computed from the registry wgs84BBox when omitted
+	BBox *smoothoperatormodel.BBox `json:"bbox,omitempty"`
+
+	projection projection
+}
+
+// LoadRegistry replaces the default registry by the one in the YAML file at path, e.g. mounted from a ConfigMap.
+// An empty path keeps the default registry.
+func LoadRegistry(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read CRS registry %s: %w", path, err)
+	}
+	loaded, err := parseRegistry(data)
+	if err != nil {
+		return fmt.Errorf("invalid CRS registry %s: %w", path, err)
+	}
+	registry = loaded
+	return nil
+}
+
+func parseRegistry(data []byte) (*Registry, error) {
+	result := &Registry{}
+	if err := yaml.UnmarshalStrict(data, result); err != nil {
+		return nil, err
+	}
+	if len(result.CRSs) == 0 {
+		return nil, errors.New("no CRSs configured")
+	}
+
+	wgs84BBox, err := toFloats(result.WGS84BBox)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wgs84BBox: %w", err)
+	}
+
+	codes := []string{}
+	for i := range result.CRSs {
+		crs := &result.CRSs[i]
+		if slices.Contains(codes, crs.Code) {
+			return nil, fmt.Errorf("CRS %s is configured multiple times", crs.Code)
+		}
+		codes = append(codes, crs.Code)
+
+		switch crs.AxisOrder {
+		case "":
+			crs.AxisOrder = EastNorth
+		case EastNorth, NorthEast:
+		default:
+			return nil, fmt.Errorf("invalid axisOrder %s of CRS %s", crs.AxisOrder, crs.Code)
+		}
+
+		if crs.projection, err = newProjection(crs.Proj4); err != nil {
+			return nil, fmt.Errorf("invalid proj4 of CRS %s: %w", crs.Code, err)
+		}
+
+		if crs.BBox == nil {
+			crs.BBox = fromFloats(reproject(wgs84BBox, longLat{}, crs.projection), crs.projection)
+		} else if _, err := toFloats(*crs.BBox); err != nil {
+			return nil, fmt.Errorf("invalid bbox of CRS %s: %w", crs.Code, err)
+		}
+	}
+	return result, nil
+}
+
+// Codes returns the codes of all CRSs in the registry
+func Codes() []string {
+	codes := make([]string, 0, len(registry.CRSs))
+	for _, crs := range registry.CRSs {
+		codes = append(codes, crs.Code)
+	}
+	return codes
+}
+
+// IsSupported returns whether the CRS is in the registry
+func IsSupported(code string) bool {
+	_, ok := Get(code)
+	return ok
+}
+
+// Get returns the CRS from the registry
+func Get(code string) (*CRS, bool) {
+	for i := range registry.CRSs {
+		if registry.CRSs[i].Code == code {
+			return &registry.CRSs[i], true
+		}
+	}
+	return nil, false
+}
+
+// DefaultBBox returns the default bounding box of the CRS in easting/northing order
+func DefaultBBox(code string) (smoothoperatormodel.BBox, bool) {
+	crs, ok := Get(code)
+	if !ok {
+		return smoothoperatormodel.BBox{}, false
+	}
+	return *crs.BBox, true
+}
+
+// IsNorthEast returns whether the axis order of the CRS in WMS 1.3.0 is northing/easting (lat/lon)
+func IsNorthEast(code string) bool {
+	crs, ok := Get(code)
+	return ok && crs.AxisOrder == NorthEast
+}
+
+// Transform reprojects a bounding box in easting/northing order from one CRS to another,
+// the result is the bounding box of the reprojected area
+func Transform(bbox smoothoperatormodel.BBox, from, to string) (smoothoperatormodel.BBox, error) {
+	fromCRS, ok := Get(from)
+	if !ok {
+		return bbox, fmt.Errorf("CRS %s is not supported", from)
+	}
+	toCRS, ok := Get(to)
+	if !ok {
+		return bbox, fmt.Errorf("CRS %s is not supported", to)
+	}
+	extent, err := toFloats(bbox)
+	if err != nil {
+		return bbox, err
+	}
+	if from == to {
+		return bbox, nil
+	}
+	return *fromFloats(reproject(extent, fromCRS.projection, toCRS.projection), toCRS.projection), nil
+}
+
+// reproject samples the sides of the extent, which is sufficient for the projections of the registry
+func reproject(extent [4]float64, from, to projection) [4]float64 {
+	result := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	add := func(x, y float64) {
+		lon, lat := from.inverse(x, y)
+		x, y = to.forward(lon, lat)
+		result[0], result[1] = math.Min(result[0], x), math.Min(result[1], y)
+		result[2], result[3] = math.Max(result[2], x), math.Max(result[3], y)
+	}
+	for i := 0; i <= samples; i++ {
+		x := extent[0] + (extent[2]-extent[0])*float64(i)/samples
+		y := extent[1] + (extent[3]-extent[1])*float64(i)/samples
+		add(x, extent[1])
+		add(x, extent[3])
+		add(extent[0], y)
+		add(extent[2], y)
+	}
+	return result
+}
+
+func toFloats(bbox smoothoperatormodel.BBox) (result [4]float64, err error) {
+	for i, value := range []string{bbox.MinX, bbox.MinY, bbox.MaxX, bbox.MaxY} {
+		if result[i], err = strconv.ParseFloat(value, 64); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// fromFloats rounds to 1e-6 degrees for geographic and to centimeters for projected coordinates
+func fromFloats(extent [4]float64, to projection) *smoothoperatormodel.BBox {
+	factor := 100.0
+	if _, geographic := to.(longLat); geographic {
+		factor = 1e6
+	}
+	format := func(f float64) string {
+		return strconv.FormatFloat(math.Round(f*factor)/factor, 'f', -1, 64)
+	}
+	return &smoothoperatormodel.BBox{
+		MinX: format(extent[0]),
+		MinY: format(extent[1]),
+		MaxX: format(extent[2]),
+		MaxY: format(extent[3]),
+	}
+}
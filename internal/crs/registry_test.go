@@ -0,0 +1,90 @@
+package crs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	smoothoperatormodel "github.com/pdok/smooth-operator/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjections(t *testing.T) {
+	tests := []struct {
+		code     string
+		lon, lat float64
+		x, y     float64
+	}{
+		// EPSG Guidance Note 7-2 examples
+		{code: "EPSG:28992", lon: 6, lat: 53, x: 196105.283, y: 557057.739},
+		{code: "EPSG:3035", lon: 5, lat: 50, x: 3962799.45, y: 2999718.85},
+		{code: "EPSG:3034", lon: 10, lat: 52, x: 4000000, y: 2800000},
+		{code: "EPSG:25831", lon: 3, lat: 0, x: 500000, y: 0},
+		{code: "EPSG:3857", lon: 180, lat: 0, x: 20037508.34, y: 0},
+		{code: "EPSG:3395", lon: 0, lat: 45, x: 0, y: 5591295.92},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			crs, ok := Get(tt.code)
+			require.True(t, ok)
+			x, y := crs.projection.forward(tt.lon, tt.lat)
+			assert.InDelta(t, tt.x, x, 0.01)
+			assert.InDelta(t, tt.y, y, 0.01)
+			lon, lat := crs.projection.inverse(x, y)
+			assert.InDelta(t, tt.lon, lon, 1e-8)
+			assert.InDelta(t, tt.lat, lat, 1e-8)
+		})
+	}
+}
+
+func TestDefaultBBoxes(t *testing.T) {
+	for _, code := range Codes() {
+		bbox, ok := DefaultBBox(code)
+		require.True(t, ok)
+		extent, err := toFloats(bbox)
+		require.NoError(t, err, code)
+		assert.Less(t, extent[0], extent[2], code)
+		assert.Less(t, extent[1], extent[3], code)
+	}
+
+	bbox, _ := DefaultBBox("EPSG:32631")
+	assert.Equal(t, smoothoperatormodel.BBox{MinX: "466260.75", MinY: "5562302.4", MaxX: "812032.65", MaxY: "6183720.51"}, bbox)
+}
+
+func TestTransform(t *testing.T) {
+	bbox, err := Transform(smoothoperatormodel.BBox{MinX: "-25000", MinY: "250000", MaxX: "280000", MaxY: "860000"}, "EPSG:28992", WGS84)
+	require.NoError(t, err)
+	assert.Equal(t, smoothoperatormodel.BBox{MinX: "2.527089", MinY: "50.213616", MaxX: "7.374765", MaxY: "55.722559"}, bbox)
+
+	_, err = Transform(bbox, WGS84, "EPSG:1234")
+	assert.Error(t, err)
+}
+
+func TestLoadRegistry(t *testing.T) {
+	defer func() {
+		registry, _ = parseRegistry(defaultRegistry)
+	}()
+
+	path := filepath.Join(t.TempDir(), "registry.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+wgs84BBox: {minx: "2.5", miny: "50", maxx: "7.5", maxy: "56"}
+crs:
+  - code: EPSG:4326
+    proj4: +proj=longlat +ellps=WGS84
+    axisOrder: yx
+`), 0o600))
+	require.NoError(t, LoadRegistry(path))
+	assert.Equal(t, []string{"EPSG:4326"}, Codes())
+	assert.True(t, IsNorthEast("EPSG:4326"))
+	bbox, _ := DefaultBBox("EPSG:4326")
+	assert.Equal(t, smoothoperatormodel.BBox{MinX: "2.5", MinY: "50", MaxX: "7.5", MaxY: "56"}, bbox)
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+wgs84BBox: {minx: "2.5", miny: "50", maxx: "7.5", maxy: "56"}
+crs:
+  - code: EPSG:4326
+    proj4: +proj=unknown
+`), 0o600))
+	assert.ErrorContains(t, LoadRegistry(path), "unsupported projection unknown")
+}
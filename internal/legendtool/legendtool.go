@@ -0,0 +1,247 @@
+// Package legendtool generates the legends of a WMS with GetLegendGraphic requests to mapserv.
+package legendtool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// Mapserv runs a request with the query string against mapserver and returns the response body
+type Mapserv func(ctx context.Context, query string) ([]byte, error)
+
+// NewMapserv returns a Mapserv that runs the mapserv binary, the mapfile and config are taken from the environment
+func NewMapserv(binary string) Mapserv {
+	return func(ctx context.Context, query string) ([]byte, error) {
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, binary, "-nh", "QUERY_STRING="+query) //nolint:gosec // the binary is a flag of the legend-generator
+		cmd.Stderr = &stderr
+		body, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("mapserv failed: %w: %s", err, stderr.String())
+		}
+		return body, nil
+	}
+}
+
+type Generator struct {
+	// OutputDir is where the legends are written, one directory per layer
+	OutputDir string
+	// Parallelism is the number of legends that are generated at the same time
+	Parallelism int
+	Mapserv     Mapserv
+}
+
+// Run generates the legends of the input, fixes the group layer legends and publishes the SLD documents.
+// A legend that fails doesn't stop the others, all errors are returned at the end.
+func (g Generator) Run(ctx context.Context, input Input) error {
+	errs := g.generateLegends(ctx, input.Legends)
+
+	for i, reference := range input.Legends {
+		if dataLayers, ok := input.GroupLayers[reference.Layer]; ok && !reference.Static && errs[i] == nil {
+			if err := g.fixGroupLayerLegend(reference, dataLayers); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for _, reference := range input.Remove {
+		slog.Info("Removing legend", "layer", reference.Layer, "style", reference.Style)
+		if err := os.Remove(filepath.Join(g.OutputDir, reference.GetFile())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, sld := range input.SLDs {
+		slog.Info("Publishing SLD", "layer", sld.Layer, "style", sld.Style)
+		if err := copyFile(sld.Path, filepath.Join(g.OutputDir, sld.Layer, sld.Style+".sld")); err != nil {
+			errs = append(errs, fmt.Errorf("unable to publish SLD for layer %s, style %s: %w", sld.Layer, sld.Style, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (g Generator) generateLegends(ctx context.Context, references []LegendReference) []error {
+	errs := make([]error, len(references))
+
+	parallelism := max(g.Parallelism, 1)
+	semaphore := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, reference := range references {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			errs[i] = g.generateLegend(ctx, reference)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("legend for layer %s, style %s: %w", reference.Layer, reference.Style, errs[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errs
+}
+
+func (g Generator) generateLegend(ctx context.Context, reference LegendReference) error {
+	format := reference.GetFormat()
+	path := filepath.Join(g.OutputDir, reference.GetFile())
+
+	var body []byte
+	var err error
+	if reference.Static {
+		slog.Info("Validating legend", "layer", reference.Layer, "style", reference.Style)
+		body, err = os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+	} else {
+		slog.Info("Generating legend", "layer", reference.Layer, "style", reference.Style)
+		body, err = g.Mapserv(ctx, getLegendGraphicQuery(reference))
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = validate(body, format); err != nil {
+		return err
+	}
+
+	if reference.Static {
+		return nil
+	}
+	return writeFile(path, body)
+}
+
+func getLegendGraphicQuery(reference LegendReference) string {
+	query := url.Values{
+		"SERVICE":     {"WMS"},
+		"VERSION":     {"1.3.0"},
+		"REQUEST":     {"GetLegendGraphic"},
+		"SLD_VERSION": {"1.1.0"},
+		"LANGUAGE":    {"dut"},
+		"LAYER":       {reference.Layer},
+		"STYLE":       {reference.Style},
+		"FORMAT":      {reference.GetFormat()},
+	}
+	if reference.Width > 0 {
+		query.Set("WIDTH", strconv.Itoa(int(reference.Width)))
+	}
+	if reference.Height > 0 {
+		query.Set("HEIGHT", strconv.Itoa(int(reference.Height)))
+	}
+	return query.Encode()
+}
+
+// validate checks that the legend is an image in the format
+func validate(body []byte, format string) error {
+	if format == "image/svg+xml" {
+		if !bytes.Contains(body, []byte("<svg")) {
+			return errors.New("legend is not an svg image")
+		}
+		return nil
+	}
+
+	_, name, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("legend is not an image, it starts with %q: %w", string(body[:min(len(body), 80)]), err)
+	}
+	if "image/"+name != format {
+		return fmt.Errorf("legend is an image/%s image instead of %s", name, format)
+	}
+	return nil
+}
+
+// fixGroupLayerLegend replaces the legend of a group layer by the legends of its data layers below each other
+func (g Generator) fixGroupLayerLegend(reference LegendReference, dataLayers []string) error {
+	images := []image.Image{}
+	for _, dataLayer := range dataLayers {
+		dataLayerReference := LegendReference{Layer: dataLayer, Style: reference.Style, Format: reference.Format}
+		file, err := os.Open(filepath.Join(g.OutputDir, dataLayerReference.GetFile()))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		img, _, err := image.Decode(file)
+		_ = file.Close()
+		if err != nil {
+			return fmt.Errorf("unable to decode legend of layer %s, style %s: %w", dataLayer, reference.Style, err)
+		}
+		images = append(images, img)
+	}
+
+	if len(images) == 0 {
+		slog.Info("No data layer legends for group layer", "layer", reference.Layer, "style", reference.Style)
+		return nil
+	}
+
+	slog.Info("Concatenating data layer legends for group layer", "layer", reference.Layer, "style", reference.Style)
+	legend := appendVertically(images)
+	var buffer bytes.Buffer
+	if err := encode(&buffer, legend, reference.GetFormat()); err != nil {
+		return fmt.Errorf("unable to encode legend of group layer %s, style %s: %w", reference.Layer, reference.Style, err)
+	}
+	return writeFile(filepath.Join(g.OutputDir, reference.GetFile()), buffer.Bytes())
+}
+
+func appendVertically(images []image.Image) *image.RGBA {
+	width, height := 0, 0
+	for _, img := range images {
+		width = max(width, img.Bounds().Dx())
+		height += img.Bounds().Dy()
+	}
+
+	result := image.NewRGBA(image.Rect(0, 0, width, height))
+	y := 0
+	for _, img := range images {
+		bounds := img.Bounds()
+		draw.Draw(result, image.Rect(0, y, bounds.Dx(), y+bounds.Dy()), img, bounds.Min, draw.Src)
+		y += bounds.Dy()
+	}
+	return result
+}
+
+func encode(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case "image/png":
+		return png.Encode(w, img)
+	case "image/jpeg":
+		return jpeg.Encode(w, img, nil)
+	case "image/gif":
+		return gif.Encode(w, img, nil)
+	default:
+		return fmt.Errorf("unable to concatenate %s legends", format)
+	}
+}
+
+func copyFile(source, target string) error {
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	return writeFile(target, data)
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec // the legends are served by the webserver
+		return err
+	}
+	return os.WriteFile(path, data, 0o644) //nolint:gosec // the legends are served by the webserver
+}
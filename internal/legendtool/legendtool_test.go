@@ -0,0 +1,115 @@
+package legendtool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getPNG(t *testing.T, width, height int) []byte {
+	var buffer bytes.Buffer
+	require.NoError(t, png.Encode(&buffer, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return buffer.Bytes()
+}
+
+// fakeMapserv returns a legend of 20 px high for every layer, with a width of 10 px per character of the layer name
+func fakeMapserv(t *testing.T, calls *atomic.Int32) Mapserv {
+	return func(_ context.Context, query string) ([]byte, error) {
+		calls.Add(1)
+		values, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		assert.Equal(t, "GetLegendGraphic", values.Get("REQUEST"))
+		if values.Get("LAYER") == "broken" {
+			return []byte("<ServiceExceptionReport/>"), nil
+		}
+		if values.Get("LAYER") == "failing" {
+			return nil, errors.New("mapserv failed")
+		}
+		return getPNG(t, 10*len(values.Get("LAYER")), 20), nil
+	}
+}
+
+// readSize returns the width and height of a legend in the output directory
+func readSize(t *testing.T, dir, file string) [2]int {
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	require.NoError(t, err)
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	return [2]int{config.Width, config.Height}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "static"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "static", "default.png"), getPNG(t, 50, 50), 0o644))
+	sld := filepath.Join(t.TempDir(), "style.sld")
+	require.NoError(t, os.WriteFile(sld, []byte("<StyledLayerDescriptor/>"), 0o644))
+
+	calls := atomic.Int32{}
+	generator := Generator{OutputDir: dir, Parallelism: 2, Mapserv: fakeMapserv(t, &calls)}
+	err := generator.Run(context.Background(), Input{
+		Legends: []LegendReference{
+			{Layer: "group", Style: "default"},
+			{Layer: "with space", Style: "default"},
+			{Layer: "data", Style: "default"},
+			{Layer: "static", Style: "default", Width: 78, Static: true},
+		},
+		GroupLayers: map[string][]string{"group": {"with space", "data", "missing"}},
+		Remove:      []LegendReference{{Layer: "data", Style: "default"}},
+		SLDs:        []SLDReference{{Layer: "data", Style: "default", Path: sld}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+
+	assert.NoFileExists(t, filepath.Join(dir, "data", "default.png"))
+	assert.FileExists(t, filepath.Join(dir, "with space", "default.png"))
+	assert.FileExists(t, filepath.Join(dir, "data", "default.sld"))
+
+	// The legends of the data layers below each other
+	assert.Equal(t, [2]int{100, 40}, readSize(t, dir, "group/default.png"))
+	assert.Equal(t, [2]int{100, 20}, readSize(t, dir, "with space/default.png"))
+	assert.Equal(t, [2]int{50, 50}, readSize(t, dir, "static/default.png"))
+}
+
+func TestRunReturnsAllErrors(t *testing.T) {
+	dir := t.TempDir()
+	calls := atomic.Int32{}
+	generator := Generator{OutputDir: dir, Mapserv: fakeMapserv(t, &calls)}
+	err := generator.Run(context.Background(), Input{
+		Legends: []LegendReference{
+			{Layer: "broken", Style: "default"},
+			{Layer: "failing", Style: "default"},
+			{Layer: "layer", Style: "default"},
+			{Layer: "layer", Style: "jpeg", Format: "image/jpeg"},
+			{Layer: "static", Style: "default", Static: true},
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "layer broken, style default: legend is not an image")
+	assert.Contains(t, err.Error(), "layer failing, style default: mapserv failed")
+	assert.Contains(t, err.Error(), "layer layer, style jpeg: legend is an image/png image instead of image/jpeg")
+	assert.Contains(t, err.Error(), "layer static, style default")
+	assert.NoFileExists(t, filepath.Join(dir, "broken", "default.png"))
+	assert.NoFileExists(t, filepath.Join(dir, "layer", "jpeg.jpg"))
+	assert.Equal(t, [2]int{50, 20}, readSize(t, dir, "layer/default.png"))
+}
+
+func TestGetLegendGraphicQuery(t *testing.T) {
+	query, err := url.ParseQuery(getLegendGraphicQuery(LegendReference{Layer: "a layer", Style: "style", Width: 78, Height: 20}))
+	require.NoError(t, err)
+	assert.Equal(t, "a layer", query.Get("LAYER"))
+	assert.Equal(t, "style", query.Get("STYLE"))
+	assert.Equal(t, "image/png", query.Get("FORMAT"))
+	assert.Equal(t, "78", query.Get("WIDTH"))
+	assert.Equal(t, "20", query.Get("HEIGHT"))
+}
@@ -0,0 +1,71 @@
+package legendtool
+
+// DefaultFormat is the format of a legend when none is given
+const DefaultFormat = "image/png"
+
+// Extensions are the file extensions allowed per legend format, the first one is used for the published legend
+var Extensions = map[string][]string{
+	DefaultFormat:   {".png"},
+	"image/jpeg":    {".jpg", ".jpeg"},
+	"image/gif":     {".gif"},
+	"image/svg+xml": {".svg"},
+}
+
+// Input is the configuration of the legend-generator
+type Input struct {
+	// Legends are generated with a GetLegendGraphic request, or only validated if they are static
+	Legends []LegendReference `yaml:"legends" json:"legends"`
+
+	// GroupLayers maps a group layer on its data layers, the legend of a group layer is replaced by the legends of its
+	// data layers below each other. Only set when the group layers are rewritten to their data layers (legend fixer)
+	GroupLayers map[string][]string `yaml:"groupLayers,omitempty" json:"groupLayers,omitempty"`
+
+	// Remove are the legends that are removed after the group layer legends are fixed
+	Remove []LegendReference `yaml:"remove,omitempty" json:"remove,omitempty"`
+
+	// SLDs are the SLD documents that are published next to the legends
+	SLDs []SLDReference `yaml:"slds,omitempty" json:"slds,omitempty"`
+}
+
+type LegendReference struct {
+	Layer string `yaml:"layer" json:"layer"`
+	Style string `yaml:"style" json:"style"`
+
+	// Format of the legend, defaults to image/png
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+
+	// Width and Height of the legend in px, the size of the legend image is used if not set
+	Width  int32 `yaml:"width,omitempty" json:"width,omitempty"`
+	Height int32 `yaml:"height,omitempty" json:"height,omitempty"`
+
+	// Static legends are downloaded from blob storage instead of generated
+	Static bool `yaml:"static,omitempty" json:"static,omitempty"`
+}
+
+type SLDReference struct {
+	Layer string `yaml:"layer" json:"layer"`
+	Style string `yaml:"style" json:"style"`
+	// Path of the SLD document
+	Path string `yaml:"path" json:"path"`
+}
+
+// GetFormat returns the format of the legend, DefaultFormat if not set
+func (reference LegendReference) GetFormat() string {
+	if reference.Format == "" {
+		return DefaultFormat
+	}
+	return reference.Format
+}
+
+// GetFile returns the path of the legend relative to the output directory
+func (reference LegendReference) GetFile() string {
+	return reference.Layer + "/" + reference.Style + GetExtension(reference.GetFormat())
+}
+
+// GetExtension returns the extension of the published legend of a format, the png extension for an unknown format
+func GetExtension(format string) string {
+	if extensions, ok := Extensions[format]; ok {
+		return extensions[0]
+	}
+	return Extensions[DefaultFormat][0]
+}
@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	TracerName  = "github.com/pdok/mapserver-operator"
+	ServiceName = "mapserver-operator"
+)
+
+// Options configures the OTLP trace exporter
+type Options struct {
+	// Endpoint of the OTLP gRPC collector (host:port), tracing is disabled when empty
+	Endpoint string
+	// Insecure disables TLS towards the collector
+	Insecure bool
+	// SampleRatio is the fraction of traces that is sampled, between 0 and 1
+	SampleRatio float64
+}
+
+// Setup registers a global TracerProvider that exports spans via OTLP.
+// When no endpoint is configured the global no-op provider is left in place.
+// The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, options Options) (shutdown func(context.Context) error, err error) {
+	shutdown = func(context.Context) error { return nil }
+	if options.Endpoint == "" {
+		return shutdown, nil
+	}
+
+	exporterOptions := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(options.Endpoint)}
+	if options.Insecure {
+		exporterOptions = append(exporterOptions, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, exporterOptions...)
+	if err != nil {
+		return shutdown, fmt.Errorf("unable to create OTLP trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName)))
+	if err != nil {
+		return shutdown, fmt.Errorf("unable to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer of the operator from the global TracerProvider
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// StartSpan starts a span with the namespace, name and kind of obj as attributes
+func StartSpan(ctx context.Context, spanName string, obj metav1.Object, kind string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, spanName, trace.WithAttributes(ObjectAttributes(obj, kind)...))
+}
+
+// StartSpanForRequest starts a span for a reconcile request of which the object is not fetched yet
+func StartSpanForRequest(ctx context.Context, spanName string, key k8stypes.NamespacedName, kind string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, spanName, trace.WithAttributes(
+		attribute.String("k8s.namespace.name", key.Namespace),
+		attribute.String("k8s.object.name", key.Name),
+		attribute.String("k8s.object.kind", kind),
+	))
+}
+
+// ObjectAttributes returns the span attributes identifying a (custom) resource
+func ObjectAttributes(obj metav1.Object, kind string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("k8s.namespace.name", obj.GetNamespace()),
+		attribute.String("k8s.object.name", obj.GetName()),
+		attribute.String("k8s.object.kind", kind),
+	}
+}
+
+// EndSpan records err (if any) on span and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,102 @@
+package v3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/pdok/mapserver-operator/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	apix "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+)
+
+const conversionPath = "/convert"
+
+// SetupConversionWebhookWithManager registers the conversion webhook with tracing in the manager.
+// It must be called before the other webhooks, as these only register a conversion webhook when there is none yet.
+func SetupConversionWebhookWithManager(mgr ctrl.Manager) {
+	mgr.GetWebhookServer().Register(conversionPath, NewTracedConversionHandler(conversion.NewWebhookHandler(mgr.GetScheme())))
+}
+
+// NewTracedConversionHandler wraps a conversion handler with a span per ConversionReview.
+// A failed conversion is recorded as an error on the span.
+func NewTracedConversionHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		review := apix.ConversionReview{}
+		_ = json.Unmarshal(body, &review)
+		ctx, span := tracing.Tracer().Start(r.Context(), "ConversionWebhook.Convert", trace.WithAttributes(getConversionAttributes(review.Request)...))
+
+		request := r.Clone(ctx)
+		request.Body = io.NopCloser(bytes.NewReader(body))
+		recorder := &responseRecorder{header: w.Header(), statusCode: http.StatusOK}
+		handler.ServeHTTP(recorder, request)
+
+		tracing.EndSpan(span, getConversionError(recorder))
+		w.WriteHeader(recorder.statusCode)
+		_, _ = w.Write(recorder.body.Bytes())
+	})
+}
+
+func getConversionAttributes(request *apix.ConversionRequest) []attribute.KeyValue {
+	if request == nil {
+		return nil
+	}
+	attributes := []attribute.KeyValue{
+		attribute.String("k8s.conversion.desired_api_version", request.DesiredAPIVersion),
+		attribute.Int("k8s.conversion.objects", len(request.Objects)),
+	}
+	// Conversion reviews almost always hold a single object
+	if len(request.Objects) > 0 {
+		obj := unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(request.Objects[0].Raw); err == nil {
+			attributes = append(attributes, tracing.ObjectAttributes(&obj, obj.GetKind())...)
+		}
+	}
+	return attributes
+}
+
+func getConversionError(recorder *responseRecorder) error {
+	if recorder.statusCode != http.StatusOK {
+		return errors.New(http.StatusText(recorder.statusCode))
+	}
+	review := apix.ConversionReview{}
+	if err := json.Unmarshal(recorder.body.Bytes(), &review); err != nil {
+		return err
+	}
+	if review.Response != nil && review.Response.Result.Status == metav1.StatusFailure {
+		return errors.New(review.Response.Result.Message)
+	}
+	return nil
+}
+
+// responseRecorder buffers the response, so the span ends before the response is written
+type responseRecorder struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
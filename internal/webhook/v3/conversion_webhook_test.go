@@ -0,0 +1,60 @@
+package v3
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	apix "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const conversionReview = `{
+  "apiVersion": "apiextensions.k8s.io/v1",
+  "kind": "ConversionReview",
+  "request": {
+    "uid": "uid",
+    "desiredAPIVersion": "pdok.nl/v3",
+    "objects": [{"apiVersion": "pdok.nl/v2beta1", "kind": "WMS", "metadata": {"name": "name", "namespace": "namespace"}}]
+  }
+}`
+
+func TestTracedConversionHandler(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	respond := func(status string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			review := apix.ConversionReview{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&review))
+			review.Response = &apix.ConversionResponse{UID: review.Request.UID, Result: metav1.Status{Status: status, Message: "conversion failed"}}
+			assert.NoError(t, json.NewEncoder(w).Encode(review))
+		})
+	}
+
+	for _, status := range []string{metav1.StatusSuccess, metav1.StatusFailure} {
+		response := httptest.NewRecorder()
+		NewTracedConversionHandler(respond(status)).ServeHTTP(response, httptest.NewRequest(http.MethodPost, conversionPath, strings.NewReader(conversionReview)))
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Contains(t, response.Body.String(), `"uid":"uid"`)
+	}
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("k8s.object.kind", "WMS"))
+	assert.Contains(t, spans[0].Attributes(), attribute.String("k8s.conversion.desired_api_version", "pdok.nl/v3"))
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "conversion failed", spans[1].Status().Description)
+}
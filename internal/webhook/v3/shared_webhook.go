@@ -88,6 +88,10 @@ func getSampleFilename[W pdoknlv3.WMSWFS](webservice W) (string, error) {
 		if _, ok := any(webservice).(*pdoknlv3.WMS); ok {
 			return samplesPath + "v3_wms.yaml", nil
 		}
+	case *pdoknlv3.WCS:
+		if _, ok := any(webservice).(*pdoknlv3.WCS); ok {
+			return samplesPath + "v3_wcs.yaml", nil
+		}
 	}
 	return "", errors.New("unknown webservice type, cannot determine sample filename")
 }
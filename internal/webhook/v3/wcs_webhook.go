@@ -0,0 +1,88 @@
+//nolint:dupl
+package v3
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/tracing"
+)
+
+// log is for logging in this package.
+var wcsLog = logf.Log.WithName("wcs-resource")
+
+// SetupWCSWebhookWithManager registers the webhook for WCS in the manager.
+func SetupWCSWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&pdoknlv3.WCS{}).
+		WithValidator(&WCSCustomValidator{mgr.GetClient()}).
+		Complete()
+}
+
+// TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
+// NOTE: If you want to customise the 'path', use the flags '--defaulting-path' or '--validation-path'.
+// +kubebuilder:webhook:path=/validate-pdok-nl-v3-wcs,mutating=false,failurePolicy=fail,sideEffects=None,groups=pdok.nl,resources=wcs,verbs=create;update,versions=v3,name=vwcs-v3.kb.io,admissionReviewVersions=v1
+
+// WCSCustomValidator struct is responsible for validating the WCS resource
+// when it is created, updated, or deleted.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as this struct is used only for temporary operations and does not need to be deeply copied.
+type WCSCustomValidator struct {
+	Client client.Client
+}
+
+var _ webhook.CustomValidator = &WCSCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type WCS.
+func (v *WCSCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (warnings admission.Warnings, err error) {
+	wcs, ok := obj.(*pdoknlv3.WCS)
+	if !ok {
+		return nil, fmt.Errorf("expected a WCS object but got %T", obj)
+	}
+	wcsLog.Info("Validation for WCS upon creation", "name", wcs.GetName())
+
+	_, span := tracing.StartSpan(ctx, "WCSCustomValidator.ValidateCreate", wcs, "WCS")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return wcs.ValidateCreate(v.Client)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type WCS.
+func (v *WCSCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (warnings admission.Warnings, err error) {
+	wcs, ok := newObj.(*pdoknlv3.WCS)
+	if !ok {
+		return nil, fmt.Errorf("expected a WCS object for the newObj but got %T", newObj)
+	}
+	wcsOld, ok := oldObj.(*pdoknlv3.WCS)
+	if !ok {
+		return nil, fmt.Errorf("expected a WCS object for the oldObj but got %T", newObj)
+	}
+	wcsLog.Info("Validation for WCS upon update", "name", wcs.GetName())
+
+	_, span := tracing.StartSpan(ctx, "WCSCustomValidator.ValidateUpdate", wcs, "WCS")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return wcs.ValidateUpdate(v.Client, wcsOld)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type WCS.
+func (v *WCSCustomValidator) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	wcs, ok := obj.(*pdoknlv3.WCS)
+	if !ok {
+		return nil, fmt.Errorf("expected a WCS object but got %T", obj)
+	}
+	wcsLog.Info("Validation for WCS upon deletion", "name", wcs.GetName())
+
+	// TODO(user): fill in your validation logic upon object deletion.
+
+	return nil, nil
+}
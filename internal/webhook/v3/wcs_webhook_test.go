@@ -0,0 +1,158 @@
+package v3
+
+//nolint:revive // Complains about the dot imports
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/crs"
+	smoothoperatorv1 "github.com/pdok/smooth-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("WCS Webhook", func() {
+	var (
+		obj       *pdoknlv3.WCS
+		oldObj    *pdoknlv3.WCS
+		validator WCSCustomValidator
+		ownerInfo *smoothoperatorv1.OwnerInfo
+	)
+
+	BeforeEach(func() {
+		validator = WCSCustomValidator{k8sClient}
+		Expect(validator).NotTo(BeNil(), "Expected validator to be initialized")
+
+		sample := &pdoknlv3.WCS{}
+		err := readSample(sample)
+		Expect(err).ToNot(HaveOccurred(), "Reading and parsing the WCS V3 sample failed")
+
+		obj = sample.DeepCopy()
+		oldObj = sample.DeepCopy()
+
+		Expect(obj).NotTo(BeNil(), "Expected obj to be initialized")
+		Expect(oldObj).NotTo(BeNil(), "Expected oldObj to be initialized")
+
+		ownerInfoSample := &smoothoperatorv1.OwnerInfo{}
+		Expect(readOwnerInfo(ownerInfoSample)).To(Succeed(), "Reading and parsing the Ownerinfo sample failed")
+		ownerInfo = ownerInfoSample.DeepCopy()
+		Expect(ownerInfo).NotTo(BeNil())
+		Expect(createOwnerInfo(ctx, k8sClient, ownerInfo)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, ownerInfo)).To(Succeed())
+	})
+
+	Context("When creating or updating WCS under Validating Webhook", func() {
+		ctx := context.Background()
+
+		It("Creates the WCS from the sample", func() {
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Warns if the name contains WCS", func() {
+			obj.Name += "-wcs"
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(warnings).To(Equal(getValidationWarnings(
+				obj,
+				*field.NewPath("metadata").Child("name"),
+				"name should not contain wcs",
+				[]string{},
+			)))
+		})
+
+		It("Should deny creation if the defaultCRS is not in the CRS registry", func() {
+			obj.Spec.Service.DefaultCrs = "EPSG:1234"
+			obj.Spec.Service.Bbox = nil
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.NotSupported(
+				field.NewPath("spec").Child("service").Child("defaultCrs"),
+				"EPSG:1234",
+				crs.Codes(),
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny Create when an outputFormat is configured multiple times", func() {
+			obj.Spec.Service.OutputFormats = []string{"image/tiff", "image/tiff"}
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Duplicate(
+				field.NewPath("spec").Child("service").Child("outputFormats").Index(1),
+				"image/tiff",
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny creation if multiple coverages have the same name", func() {
+			obj.Spec.Service.Coverages = append(obj.Spec.Service.Coverages, obj.Spec.Service.Coverages[0])
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Duplicate(
+				field.NewPath("spec").Child("service").Child("coverages").Index(1).Child("name"),
+				obj.Spec.Service.Coverages[1].Name,
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny creation if a coverage has multiple bands with the same name", func() {
+			Expect(obj.Spec.Service.Coverages[0].Bands).NotTo(BeEmpty())
+			coverage := &obj.Spec.Service.Coverages[0]
+			coverage.Bands = append(coverage.Bands, coverage.Bands[0])
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Duplicate(
+				field.NewPath("spec").Child("service").Child("coverages").Index(0).Child("bands").Index(1).Child("name"),
+				coverage.Bands[1].Name,
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny Create when dataRefresh is set", func() {
+			obj.Spec.Options = &pdoknlv3.BaseOptions{PrefetchData: true, DataRefresh: true}
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Forbidden(
+				field.NewPath("spec").Child("options").Child("dataRefresh"),
+				"only applies to geopackages, a WCS only serves rasters",
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny Create when mapserver container doesn't have ephemeral storage", func() {
+			obj.Spec.PodSpecPatch = corev1.PodSpec{}
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Required(field.NewPath("spec").
+				Child("podSpecPatch").
+				Child("containers").
+				Key("mapserver").
+				Child("resources").
+				Child("limits").
+				Child(corev1.ResourceEphemeralStorage.String()), ""))))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny create if the OwnerInfoRef misses WFS", func() {
+			ownerInfo.Spec.WFS = nil
+			Expect(updateOwnerInfo(ctx, k8sClient, ownerInfo)).To(Succeed())
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Required(
+				field.NewPath("spec").Child("service").Child("ownerInfoRef"),
+				"spec.WFS missing in "+ownerInfo.Name,
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should accept an update without changes", func() {
+			warnings, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+	})
+})
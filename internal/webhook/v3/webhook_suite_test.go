@@ -89,6 +89,7 @@ var _ = BeforeSuite(func() {
 		CRDDirectoryPaths: []string{
 			filepath.Join("..", "..", "config", "crd", "bases", "pdok.nl_wfs.yaml"),
 			filepath.Join("..", "..", "config", "crd", "bases", "pdok.nl_wms.yaml"),
+			filepath.Join("..", "..", "config", "crd", "bases", "pdok.nl_wcs.yaml"),
 			ownerInfoCRDPath,
 		},
 		ErrorIfCRDPathMissing: false,
@@ -130,9 +131,14 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).NotTo(HaveOccurred())
 
+	SetupConversionWebhookWithManager(mgr)
+
 	err = SetupWFSWebhookWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
+	err = SetupWCSWebhookWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
 	// +kubebuilder:scaffold:webhook
 
 	go func() {
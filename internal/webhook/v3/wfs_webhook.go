@@ -14,6 +14,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/tracing"
 )
 
 // log is for logging in this package.
@@ -42,18 +43,21 @@ type WFSCustomValidator struct {
 var _ webhook.CustomValidator = &WFSCustomValidator{}
 
 // ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type WFS.
-func (v *WFSCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+func (v *WFSCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (warnings admission.Warnings, err error) {
 	wfs, ok := obj.(*pdoknlv3.WFS)
 	if !ok {
 		return nil, fmt.Errorf("expected a WFS object but got %T", obj)
 	}
 	wfsLog.Info("Validation for WFS upon creation", "name", wfs.GetName())
 
+	_, span := tracing.StartSpan(ctx, "WFSCustomValidator.ValidateCreate", wfs, "WFS")
+	defer func() { tracing.EndSpan(span, err) }()
+
 	return wfs.ValidateCreate(v.Client)
 }
 
 // ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type WFS.
-func (v *WFSCustomValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+func (v *WFSCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (warnings admission.Warnings, err error) {
 	wfs, ok := newObj.(*pdoknlv3.WFS)
 	if !ok {
 		return nil, fmt.Errorf("expected a WFS object for the newObj but got %T", newObj)
@@ -64,6 +68,9 @@ func (v *WFSCustomValidator) ValidateUpdate(_ context.Context, oldObj, newObj ru
 	}
 	wfsLog.Info("Validation for WFS upon update", "name", wfs.GetName())
 
+	_, span := tracing.StartSpan(ctx, "WFSCustomValidator.ValidateUpdate", wfs, "WFS")
+	defer func() { tracing.EndSpan(span, err) }()
+
 	return wfs.ValidateUpdate(v.Client, wfsOld)
 }
 
@@ -13,6 +13,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/crs"
 	smoothoperatorv1 "github.com/pdok/smooth-operator/api/v1"
 	smoothoperatormodel "github.com/pdok/smooth-operator/model"
 	corev1 "k8s.io/api/core/v1"
@@ -100,17 +101,26 @@ var _ = Describe("WFS Webhook", func() {
 			)))
 		})
 
-		It("Should deny creation if there is no bounding box and the defaultCRS is not EPSG:28992", func() {
+		It("Should deny creation if the defaultCRS is not in the CRS registry", func() {
 			obj.Spec.Service.DefaultCrs = "EPSG:1234"
 			obj.Spec.Service.Bbox = nil
 			warnings, err := validator.ValidateCreate(ctx, obj)
-			Expect(err).To(Equal(getValidationError(obj, field.Required(
-				field.NewPath("spec").Child("service").Child("bbox").Child("defaultCRS"),
-				"when service.defaultCRS is not 'EPSG:28992'",
+			Expect(err).To(Equal(getValidationError(obj, field.NotSupported(
+				field.NewPath("spec").Child("service").Child("defaultCrs"),
+				"EPSG:1234",
+				crs.Codes(),
 			))))
 			Expect(warnings).To(BeEmpty())
 		})
 
+		It("Should accept creation without a bounding box, the default bbox of the CRS registry is used", func() {
+			obj.Spec.Service.DefaultCrs = "EPSG:4326"
+			obj.Spec.Service.Bbox = nil
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
 		It("Warns if the mapfile and service/featuretype bbox are both set", func() {
 			Expect(obj.Spec.Service.FeatureTypes[0].Bbox).NotTo(BeNil())
 			Expect(obj.Spec.Service.FeatureTypes[0].Bbox.DefaultCRS).NotTo(BeNil())
@@ -130,6 +140,137 @@ var _ = Describe("WFS Webhook", func() {
 				))))
 		})
 
+		It("Warns if ogcApiFeatures is combined with a mapfile", func() {
+			obj.Spec.Service.Mapfile = &pdoknlv3.Mapfile{}
+			obj.Spec.Service.Bbox = nil
+			for i := range obj.Spec.Service.FeatureTypes {
+				obj.Spec.Service.FeatureTypes[i].Bbox = nil
+			}
+			obj.Spec.Options = &pdoknlv3.WFSOptions{BaseOptions: obj.Options().BaseOptions, OgcAPIFeatures: true}
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(warnings).To(Equal(getValidationWarnings(
+				obj,
+				*field.NewPath("spec").Child("options").Child("ogcApiFeatures"),
+				"the OGCAPI metadata should be part of service.mapfile",
+				[]string{},
+			)))
+		})
+
+		It("Should deny Create when an outputFormat is not supported", func() {
+			obj.Spec.Service.OutputFormats = []string{pdoknlv3.GML32OutputFormat, "application/pdf"}
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.NotSupported(
+				field.NewPath("spec").Child("service").Child("outputFormats").Index(1),
+				"application/pdf",
+				pdoknlv3.SupportedWFSOutputFormats,
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny Create when a featureType outputFormat is not one of the service outputFormats", func() {
+			obj.Spec.Service.OutputFormats = []string{pdoknlv3.GML32OutputFormat}
+			obj.Spec.Service.FeatureTypes[0].OutputFormats = []string{"text/csv"}
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Invalid(
+				field.NewPath("spec").Child("service").Child("featureTypes").Index(0).Child("outputFormats").Index(0),
+				"text/csv",
+				"should be one of service.outputFormats",
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny Create when a stored query filter references an undefined parameter", func() {
+			obj.Spec.Service.StoredQueries = []pdoknlv3.StoredQuery{{
+				ID:          "urn:ogc:def:query:OGC-WFS::GetByID",
+				Title:       "Get by id",
+				FeatureType: obj.Spec.Service.FeatureTypes[0].Name,
+				Filter:      "<fes:Filter><fes:ResourceId rid=\"${id}\"/></fes:Filter>",
+			}}
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Invalid(
+				field.NewPath("spec").Child("service").Child("storedQueries").Index(0).Child("filter"),
+				"${id}",
+				"parameter is not defined in parameters",
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny Create when a data filter references an unknown column", func() {
+			filter := "column = 'value' AND other > 1"
+			obj.Spec.Service.FeatureTypes[0].Data.Gpkg.Filter = &filter
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Invalid(
+				field.NewPath("spec").Child("service").Child("featureTypes").Index(0).Child("data").Child("gpkg").Child("filter"),
+				"other",
+				"must only reference columns",
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny Create when the primaryKey is not one of the columns", func() {
+			obj.Spec.Service.FeatureTypes[0].Data.Gpkg.PrimaryKey = ptr.To("id")
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Invalid(
+				field.NewPath("spec").Child("service").Child("featureTypes").Index(0).Child("data").Child("gpkg").Child("primaryKey"),
+				"id",
+				"must be one of the (non geometry) columns",
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny Create when a featureType countDefault exceeds the maximum", func() {
+			obj.Spec.Service.FeatureTypes[0].CountDefault = ptr.To(pdoknlv3.MaxCountDefault + 1)
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Invalid(
+				field.NewPath("spec").Child("service").Child("featureTypes").Index(0).Child("countDefault"),
+				pdoknlv3.MaxCountDefault+1,
+				"should be between 1 and 10000",
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny Create when a featureType otherCrs is not in the CRS registry", func() {
+			obj.Spec.Service.FeatureTypes[0].OtherCrs = []string{"EPSG:4326", "EPSG:1234"}
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.NotSupported(
+				field.NewPath("spec").Child("service").Child("featureTypes").Index(0).Child("otherCrs").Index(1),
+				"EPSG:1234",
+				crs.Codes(),
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should admit Create when the service otherCrs also has the defaultCrs and no featureType overrides them", func() {
+			obj.Spec.Service.OtherCrs = append(obj.Spec.Service.OtherCrs, obj.Spec.Service.DefaultCrs)
+			for i := range obj.Spec.Service.FeatureTypes {
+				obj.Spec.Service.FeatureTypes[i].DefaultCrs = nil
+				obj.Spec.Service.FeatureTypes[i].OtherCrs = nil
+			}
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny Create when a featureType otherCrs also has its defaultCrs", func() {
+			obj.Spec.Service.FeatureTypes[0].OtherCrs = []string{obj.Spec.Service.DefaultCrs}
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Invalid(
+				field.NewPath("spec").Child("service").Child("featureTypes").Index(0).Child("defaultCrs"),
+				obj.Spec.Service.DefaultCrs,
+				"is also one of the otherCrs",
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
 		It("Should deny Create when a otherCrs has the same crs multiple times", func() {
 			crs := "EPSG:3035"
 			obj.Spec.Service.OtherCrs = []string{crs, crs}
@@ -231,6 +372,29 @@ var _ = Describe("WFS Webhook", func() {
 			Expect(warnings).To(BeEmpty())
 		})
 
+		It("Should deny Create when useDataCache is set without prefetchData", func() {
+			obj.Spec.Options = &pdoknlv3.WFSOptions{BaseOptions: pdoknlv3.BaseOptions{PrefetchData: false, UseDataCache: true}}
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Invalid(
+				field.NewPath("spec").Child("options").Child("useDataCache"),
+				true,
+				"requires prefetchData",
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny Create when dataRefresh is combined with useDataCache", func() {
+			obj.Spec.Options = &pdoknlv3.WFSOptions{BaseOptions: pdoknlv3.BaseOptions{PrefetchData: true, UseDataCache: true, DataRefresh: true}}
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Forbidden(
+				field.NewPath("spec").Child("options").Child("dataRefresh"),
+				"cannot be combined with useDataCache",
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
 		It("Should deny creation if multiple featureTypes have the same name", func() {
 			Expect(len(obj.Spec.Service.FeatureTypes)).To(BeNumerically(">", 1))
 			obj.Spec.Service.FeatureTypes[1].Name = obj.Spec.Service.FeatureTypes[0].Name
@@ -14,6 +14,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	pdoknlv3 "github.com/pdok/mapserver-operator/api/v3"
+	"github.com/pdok/mapserver-operator/internal/tracing"
 )
 
 // log is for logging in this package.
@@ -43,18 +44,21 @@ type WMSCustomValidator struct {
 var _ webhook.CustomValidator = &WMSCustomValidator{}
 
 // ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type WMS.
-func (v *WMSCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+func (v *WMSCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (warnings admission.Warnings, err error) {
 	wms, ok := obj.(*pdoknlv3.WMS)
 	if !ok {
 		return nil, fmt.Errorf("expected a WMS object but got %T", obj)
 	}
 	wmsLog.Info("Validation for WMS upon creation", "name", wms.GetName())
 
+	_, span := tracing.StartSpan(ctx, "WMSCustomValidator.ValidateCreate", wms, "WMS")
+	defer func() { tracing.EndSpan(span, err) }()
+
 	return wms.ValidateCreate(v.Client)
 }
 
 // ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type WMS.
-func (v *WMSCustomValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+func (v *WMSCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (warnings admission.Warnings, err error) {
 	wms, ok := newObj.(*pdoknlv3.WMS)
 	if !ok {
 		return nil, fmt.Errorf("expected a WMS object for the newObj but got %T", newObj)
@@ -65,6 +69,9 @@ func (v *WMSCustomValidator) ValidateUpdate(_ context.Context, oldObj, newObj ru
 	}
 	wmsLog.Info("Validation for WMS upon update", "name", wms.GetName())
 
+	_, span := tracing.StartSpan(ctx, "WMSCustomValidator.ValidateUpdate", wms, "WMS")
+	defer func() { tracing.EndSpan(span, err) }()
+
 	return wms.ValidateUpdate(v.Client, wmsOld)
 }
 
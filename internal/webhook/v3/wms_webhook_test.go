@@ -165,6 +165,20 @@ var _ = Describe("WMS Webhook", func() {
 			Expect(warnings).To(BeEmpty())
 		})
 
+		It("Should deny Create when featureInfoTemplates have the same key", func() {
+			obj.Spec.Service.FeatureInfoTemplates = []pdoknlv3.FeatureInfoTemplatesRef{
+				{Name: "templates", Keys: []string{"layer.html"}},
+				{Name: "other-templates", Keys: []string{"other.html", "layer.html"}},
+			}
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Duplicate(
+				field.NewPath("spec").Child("service").Child("featureInfoTemplates").Index(1).Child("keys").Index(1),
+				"layer.html",
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
 		It("Should deny Create when multiple layers have the same name", func() {
 			layerName := "equal"
 			obj.Spec.Service.Layer.Layers[0].Name = &layerName
@@ -191,6 +205,31 @@ var _ = Describe("WMS Webhook", func() {
 			Expect(warnings).To(BeEmpty())
 		})
 
+		It("Should deny Create when Group Layer has featureInfo set", func() {
+			featureInfo := &pdoknlv3.FeatureInfo{Enabled: ptr.To(false)}
+			obj.Spec.Service.Layer.Layers[1].FeatureInfo = featureInfo
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Invalid(
+				field.NewPath("spec").Child("service").Child("layer").Child("layers").Index(1).Child("featureInfo"),
+				featureInfo,
+				"must not be set on a GroupLayer",
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny Create when featureInfo includes a column that isn't in the data", func() {
+			obj.Spec.Service.Layer.Layers[0].FeatureInfo = &pdoknlv3.FeatureInfo{Columns: []string{"column", "unknown"}}
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Invalid(
+				field.NewPath("spec").Child("service").Child("layer").Child("layers").Index(0).Child("featureInfo").Child("columns").Index(1),
+				"unknown",
+				"must be one of the columns of the data",
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
 		It("Warns when mapfile and layer boundingboxes are both set", func() {
 			withMapfile(obj)
 			obj.Spec.Service.Layer.BoundingBoxes = []pdoknlv3.WMSBoundingBox{{
@@ -422,6 +461,22 @@ var _ = Describe("WMS Webhook", func() {
 			Expect(warnings).To(BeEmpty())
 		})
 
+		It("Should deny Create when a Style has a colormap on a Layer without tif data", func() {
+			colormap := &pdoknlv3.Colormap{
+				Type:    pdoknlv3.ColormapTypeClassified,
+				Entries: []pdoknlv3.ColormapEntry{{Value: "1", Color: "#FFFFFF"}},
+			}
+			obj.Spec.Service.Layer.Layers[0].Styles[0].Colormap = colormap
+
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(Equal(getValidationError(obj, field.Invalid(
+				field.NewPath("spec").Child("service").Child("layer").Child("layers").Index(0).Child("styles").Index(0).Child("colormap"),
+				colormap,
+				"can only be set on a DataLayer with tif data",
+			))))
+			Expect(warnings).To(BeEmpty())
+		})
+
 		It("Should deny Create when a when a Group Layer style isn't implemented in a sub Data Layer", func() {
 			obj.Spec.Service.Layer.Layers[1].Styles[0].Name = "new"
 